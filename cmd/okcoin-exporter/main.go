@@ -0,0 +1,139 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command okcoin-exporter serves OKCoin tickers, candle stick closes,
+// account balances and API call statistics for Prometheus to scrape.
+//
+// Usage:
+//
+//	okcoin-exporter [-listen localhost:9791] [-symbols btc_usd,ltc_usd]
+//		[-periods 1min,1hour] [-funds=true] [-timeout 10s]
+//
+// The exchange is queried on every scrape of /metrics. Balances are
+// only exported when the OKCOIN_API_KEY and OKCOIN_API_SECRET
+// environment variables are set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/collector"
+)
+
+func main() {
+	opts, err := parseArgs(os.Args[1:], os.Stderr)
+	if err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "okcoin-exporter: %v\n", err)
+		}
+		os.Exit(2)
+	}
+
+	client, err := okcoin.NewClientFromEnv()
+	if err != nil {
+		if opts.cfg.Funds {
+			log.Printf("not exporting balances: %v", err)
+			opts.cfg.Funds = false
+		}
+		client, _ = okcoin.NewDefaultClient()
+	}
+	opts.cfg.Client = client
+
+	h, err := newHandler(opts.cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("serving metrics at http://%s/metrics", opts.listen)
+	log.Fatal(http.ListenAndServe(opts.listen, h))
+}
+
+type options struct {
+	listen string
+	cfg    *collector.Config
+}
+
+func parseArgs(args []string, stderr io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("okcoin-exporter", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	listen := fs.String("listen", "localhost:9791", "the address to serve metrics on")
+	symbols := fs.String("symbols", "btc_usd,ltc_usd,eth_usd", "comma-separated symbols to export the tickers of")
+	periods := fs.String("periods", "1min", "comma-separated periods of the candle stick closes to export, or none")
+	funds := fs.Bool("funds", true, "export the account's balances if credentials are set")
+	timeout := fs.Duration("timeout", collector.DefaultTimeout, "how long a scrape may take")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	cfg := &collector.Config{Funds: *funds, Timeout: *timeout}
+	for _, s := range splitList(*symbols) {
+		base, quote, err := okcoin.ParseSymbol(s)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Symbols = append(cfg.Symbols, okcoin.NewSymbol(base, quote))
+	}
+	for _, s := range splitList(*periods) {
+		period := okcoin.Period(s)
+		if period.Duration() == 0 {
+			return nil, fmt.Errorf("unknown period %q", s)
+		}
+		cfg.CandlePeriods = append(cfg.CandlePeriods, period)
+	}
+	return &options{listen: *listen, cfg: cfg}, nil
+}
+
+// splitList splits a comma-separated list, where "none" is empty.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" && item != "none" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+const landingPage = `<html>
+<head><title>OKCoin exporter</title></head>
+<body><h1>OKCoin exporter</h1><p><a href="/metrics">Metrics</a></p></body>
+</html>
+`
+
+func newHandler(cfg *collector.Config) (http.Handler, error) {
+	c, err := collector.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Client.SetTracer(c.APIStats())
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", c)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, landingPage)
+	})
+	return mux, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/okcointest"
+)
+
+func TestParseArgs(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseArgs([]string{
+		"-listen", ":9000", "-symbols", "BTC-USD, ltc/usd", "-periods", "none", "-funds=false", "-timeout", "3s",
+	}, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if g, w := opts.listen, ":9000"; g != w {
+		t.Errorf("listen: got=%q want=%q", g, w)
+	}
+	if g, w := opts.cfg.Symbols, []okcoin.Symbol{okcoin.BTCUSD, okcoin.LTCUSD}; !reflect.DeepEqual(g, w) {
+		t.Errorf("symbols: got=%v want=%v", g, w)
+	}
+	if len(opts.cfg.CandlePeriods) != 0 || opts.cfg.Funds || opts.cfg.Timeout != 3*time.Second {
+		t.Errorf("config: got=%+v", opts.cfg)
+	}
+
+	opts, err = parseArgs(nil, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+	if g, w := opts.cfg.CandlePeriods, []okcoin.Period{okcoin.P1Min}; !reflect.DeepEqual(g, w) || !opts.cfg.Funds {
+		t.Errorf("defaults: got=%+v", opts.cfg)
+	}
+
+	for _, args := range [][]string{
+		{"-symbols", "btcusd"},
+		{"-periods", "2min"},
+		{"extra"},
+	} {
+		if _, err := parseArgs(args, ioutil.Discard); err == nil {
+			t.Errorf("%v: want non-nil error", args)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	srv := okcointest.NewServer()
+	defer srv.Close()
+	srv.SetTicker(okcoin.BTCUSD, &okcoin.TickerResponse{Ticker: &okcoin.Ticker{Last: 4592}})
+
+	opts, err := parseArgs([]string{"-symbols", "btc_usd", "-periods", "none"}, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	opts.cfg.Funds = false
+	opts.cfg.Client, _ = okcoin.NewDefaultClient()
+	opts.cfg.Client.SetHTTPRoundTripper(srv.Transport())
+	h, err := newHandler(opts.cfg)
+	if err != nil {
+		t.Fatalf("new handler: %v", err)
+	}
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		want       string
+	}{
+		{"/metrics", http.StatusOK, `okcoin_ticker_last{symbol="btc_usd"} 4592`},
+		{"/metrics", http.StatusOK, `okcoin_api_requests_total{endpoint="ticker.do"}`},
+		{"/", http.StatusOK, `href="/metrics"`},
+		{"/other", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(ts.URL + tt.path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		blob, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status: got=%d want=%d", tt.path, res.StatusCode, tt.wantStatus)
+		}
+		if !strings.Contains(string(blob), tt.want) {
+			t.Errorf("%s: body lacks %q:\n%s", tt.path, tt.want, blob)
+		}
+	}
+}
@@ -0,0 +1,559 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+var (
+	errNoTerminal       = errors.New("the dashboard needs a terminal for its input and output")
+	errNonPositivePoll  = errors.New("expecting a positive -interval")
+	errDashboardLevels  = errors.New("expecting -levels between 1 and 200")
+	errDashboardNTrades = errors.New("expecting -trades between 1 and 60")
+)
+
+var defaultDashboardSymbols = []okcoin.Symbol{okcoin.BTCUSD, okcoin.LTCUSD, okcoin.ETHUSD}
+
+func (c *cli) dashboard(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	interval := fs.Duration("interval", 2*time.Second, "how often to poll the exchange")
+	levels := fs.Int("levels", 10, "the number of price levels shown per side of the order book")
+	nTrades := fs.Int("trades", 15, "the number of recent trades shown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	symbols := defaultDashboardSymbols
+	if fs.NArg() > 0 {
+		symbols = nil
+		for _, arg := range fs.Args() {
+			sym, err := parseSymbol(arg)
+			if err != nil {
+				return err
+			}
+			symbols = append(symbols, sym)
+		}
+	}
+	switch {
+	case *interval <= 0:
+		return errNonPositivePoll
+	case *levels < 1 || *levels > 200:
+		return errDashboardLevels
+	case *nTrades < 1 || *nTrades > 60:
+		return errDashboardNTrades
+	}
+	tty, ok := c.stdin.(*os.File)
+	if !ok || !isTerminal(tty) || !isTerminal(c.stdout) {
+		return errNoTerminal
+	}
+	client, err := c.newClient(false)
+	if err != nil {
+		return err
+	}
+
+	restore, err := makeCbreak(tty)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	fmt.Fprint(c.stdout, ansiAltScreen+ansiHideCursor)
+	defer fmt.Fprint(c.stdout, ansiShowCursor+ansiMainScreen)
+
+	keys := make(chan key, 16)
+	go readKeys(tty, keys)
+
+	d := newDashboard(client, symbols, c.stdout)
+	d.interval, d.levels, d.nTrades = *interval, *levels, *nTrades
+	d.size = func() (int, int) { return terminalSize(tty) }
+	return d.run(ctx, keys)
+}
+
+// dashboard polls the tickers of its symbols, and the order book and
+// recent trades of the selected one, redrawing the screen each time.
+type dashboard struct {
+	client  *okcoin.Client
+	symbols []okcoin.Symbol
+	out     io.Writer
+
+	interval time.Duration
+	levels   int
+	nTrades  int
+
+	// size returns the rows and columns of the screen, or 0 if unknown.
+	size func() (rows, cols int)
+	now  func() time.Time
+
+	selected int
+	tickers  map[okcoin.Symbol]*okcoin.TickerResult
+	lasts    map[okcoin.Symbol]float64
+	moves    map[okcoin.Symbol]int
+
+	// bookSymbol is the symbol that depth and trades are of.
+	bookSymbol okcoin.Symbol
+	depth      *okcoin.Depth
+	trades     []*okcoin.Trade
+	bookErr    error
+	updated    time.Time
+}
+
+func newDashboard(client *okcoin.Client, symbols []okcoin.Symbol, out io.Writer) *dashboard {
+	return &dashboard{
+		client:   client,
+		symbols:  symbols,
+		out:      out,
+		interval: 2 * time.Second,
+		levels:   10,
+		nTrades:  15,
+		size:     func() (int, int) { return 0, 0 },
+		now:      time.Now,
+		tickers:  make(map[okcoin.Symbol]*okcoin.TickerResult),
+		lasts:    make(map[okcoin.Symbol]float64),
+		moves:    make(map[okcoin.Symbol]int),
+	}
+}
+
+// run redraws as keys are pressed and polls arrive until ctx is done
+// or the quit key is pressed. Polls are made by a goroutine of their
+// own so that a slow exchange never holds up the keys.
+func (d *dashboard) run(ctx context.Context, keys <-chan key) error {
+	ctx, cancel := context.WithCancel(ctx)
+	reqs := make(chan pollRequest, 1)
+	snaps := make(chan *snapshot)
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		d.poll(ctx, reqs, snaps)
+	}()
+	defer func() {
+		cancel()
+		<-polled
+	}()
+
+	poll := time.NewTicker(d.interval)
+	defer poll.Stop()
+
+	d.selectBook()
+	request(reqs, pollRequest{symbol: d.bookSymbol, tickers: true})
+	if err := d.draw(); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-poll.C:
+			request(reqs, pollRequest{symbol: d.bookSymbol, tickers: true})
+
+		case snap := <-snaps:
+			d.apply(snap)
+
+		case k, ok := <-keys:
+			if !ok {
+				// Without input, the dashboard runs until interrupted.
+				keys = nil
+				continue
+			}
+			if k == keyQuit {
+				return nil
+			}
+			if d.handle(k) {
+				// The new selection is shown before its book arrives.
+				d.selectBook()
+				request(reqs, pollRequest{symbol: d.bookSymbol})
+			} else if k == keyRefresh {
+				request(reqs, pollRequest{symbol: d.bookSymbol, tickers: true})
+			}
+		}
+		if err := d.draw(); err != nil {
+			return err
+		}
+	}
+}
+
+// pollRequest asks for the order book and recent trades of symbol
+// and, if tickers is set, for the tickers of every symbol.
+type pollRequest struct {
+	symbol  okcoin.Symbol
+	tickers bool
+}
+
+// snapshot is what a pollRequest fetched.
+type snapshot struct {
+	// tickers is nil unless they were asked for.
+	tickers map[okcoin.Symbol]*okcoin.TickerResult
+
+	symbol okcoin.Symbol
+	depth  *okcoin.Depth
+	trades []*okcoin.Trade
+	err    error
+	at     time.Time
+}
+
+// request queues r for the poller, merging it into a request still
+// waiting since only the latest selection's book is of any use.
+// It must only be called by the one goroutine that sends on reqs.
+func request(reqs chan pollRequest, r pollRequest) {
+	select {
+	case queued := <-reqs:
+		r.tickers = r.tickers || queued.tickers
+	default:
+	}
+	reqs <- r
+}
+
+// poll serves the requests on reqs until ctx is done.
+func (d *dashboard) poll(ctx context.Context, reqs <-chan pollRequest, snaps chan<- *snapshot) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-reqs:
+			snap := d.fetch(ctx, r)
+			select {
+			case snaps <- snap:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// handle moves the selection as k asks and reports whether it changed.
+func (d *dashboard) handle(k key) bool {
+	selected := d.selected
+	switch {
+	case k == keyNext:
+		selected = (selected + 1) % len(d.symbols)
+	case k == keyPrev:
+		selected = (selected + len(d.symbols) - 1) % len(d.symbols)
+	case k >= keyDigit1 && int(k-keyDigit1) < len(d.symbols):
+		selected = int(k - keyDigit1)
+	}
+	changed := selected != d.selected
+	d.selected = selected
+	return changed
+}
+
+func (d *dashboard) selectedSymbol() okcoin.Symbol {
+	return d.symbols[d.selected]
+}
+
+// fetch polls what r asks for, giving up on the tickers and
+// on the book after an interval each. It only reads the settings
+// of d so that it can run alongside the drawing.
+func (d *dashboard) fetch(ctx context.Context, r pollRequest) *snapshot {
+	snap := &snapshot{symbol: r.symbol}
+	if r.tickers {
+		pctx, cancel := context.WithTimeout(ctx, d.interval)
+		snap.tickers = d.client.Tickers(pctx, d.symbols...)
+		cancel()
+		snap.at = d.now()
+	}
+
+	pctx, cancel := context.WithTimeout(ctx, d.interval)
+	defer cancel()
+	depth, err := d.client.DepthContext(pctx, &okcoin.DepthRequest{Symbol: r.symbol, Size: d.levels})
+	if err != nil {
+		snap.err = fmt.Errorf("%s depth: %v", r.symbol, err)
+		return snap
+	}
+	ltres, err := d.client.LastTradesContext(pctx, &okcoin.LastTradesRequest{Symbol: r.symbol})
+	if err != nil {
+		snap.err = fmt.Errorf("%s trades: %v", r.symbol, err)
+		return snap
+	}
+	snap.depth, snap.trades = depth, ltres.Trades
+	return snap
+}
+
+// selectBook points the order book and trades at the selected
+// symbol, dropping those of the previous one.
+func (d *dashboard) selectBook() {
+	if sym := d.selectedSymbol(); sym != d.bookSymbol {
+		d.bookSymbol, d.depth, d.trades, d.bookErr = sym, nil, nil, nil
+	}
+}
+
+// apply takes in a snapshot. Its book is dropped if the
+// selection moved on while it was being fetched.
+func (d *dashboard) apply(snap *snapshot) {
+	if snap.tickers != nil {
+		for sym, res := range snap.tickers {
+			if res.Err != nil || res.Ticker == nil || res.Ticker.Ticker == nil {
+				continue
+			}
+			last := res.Ticker.Ticker.Last
+			if prev, ok := d.lasts[sym]; ok && last != prev {
+				d.moves[sym] = 1
+				if last < prev {
+					d.moves[sym] = -1
+				}
+			}
+			d.lasts[sym] = last
+		}
+		d.tickers = snap.tickers
+		d.updated = snap.at
+	}
+	if snap.symbol != d.bookSymbol {
+		return
+	}
+	if snap.err != nil {
+		d.bookErr = snap.err
+		return
+	}
+	d.depth, d.trades, d.bookErr = snap.depth, snap.trades, nil
+}
+
+// draw writes the frame over the previous one, cut to the screen's size.
+func (d *dashboard) draw() error {
+	rows, cols := d.size()
+	lines := d.frame()
+	if rows > 0 && len(lines) > rows {
+		lines = lines[:rows]
+	}
+	var b strings.Builder
+	b.WriteString(ansiHome)
+	for i, l := range lines {
+		l.writeTo(&b, cols)
+		b.WriteString(ansiClearLine)
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString(ansiClearBelow)
+	_, err := io.WriteString(d.out, b.String())
+	return err
+}
+
+// frame lays out the title, the tickers, the order book next to
+// the recent trades and a status line.
+func (d *dashboard) frame() []line {
+	title := fmt.Sprintf("OKCoin  %s  every %v", formatTime(d.updated), d.interval)
+	lines := []line{styled(ansiBold, title), nil}
+	lines = append(lines, d.tickerLines()...)
+	lines = append(lines, nil)
+	lines = append(lines, sideBySide(d.bookLines(), d.tradeLines(), 4)...)
+	lines = append(lines, nil)
+
+	if d.bookErr != nil {
+		lines = append(lines, styled(ansiRed, d.bookErr.Error()))
+	} else {
+		lines = append(lines, styled(ansiDim, "←/→ switch symbol  1-9 jump  r refresh  q quit"))
+	}
+	return lines
+}
+
+func (d *dashboard) tickerLines() []line {
+	rows := [][]string{{"", "symbol", "last", "buy", "sell", "high", "low", "vol"}}
+	for i, sym := range d.symbols {
+		marker := " "
+		if i == d.selected {
+			marker = ">"
+		}
+		res := d.tickers[sym]
+		switch {
+		case res == nil:
+			rows = append(rows, []string{marker, string(sym), "…", "", "", "", "", ""})
+		case res.Err != nil:
+			rows = append(rows, []string{marker, string(sym), "error", "", "", "", "", ""})
+		default:
+			tk := res.Ticker.Ticker
+			if tk == nil {
+				tk = new(okcoin.Ticker)
+			}
+			rows = append(rows, []string{marker, string(sym), tk.LastDecimal().String(), tk.BuyDecimal().String(),
+				tk.SellDecimal().String(), tk.HighDecimal().String(), tk.LowDecimal().String(), tk.VolumeDecimal().String()})
+		}
+	}
+
+	texts := tabulate(rows)
+	lines := []line{styled(ansiDim, texts[0])}
+	for i, sym := range d.symbols {
+		style := ""
+		switch d.moves[sym] {
+		case 1:
+			style = ansiGreen
+		case -1:
+			style = ansiRed
+		}
+		if i == d.selected {
+			style += ansiReverse
+		}
+		lines = append(lines, styled(style, texts[i+1]))
+	}
+	return lines
+}
+
+// bookLines lays out the depth as a ladder: the asks from
+// the worst down to the best, then the bids from the best.
+func (d *dashboard) bookLines() []line {
+	lines := []line{styled(ansiBold, fmt.Sprintf("%s order book", d.bookSymbol))}
+	if d.depth == nil {
+		return append(lines, plain("…"))
+	}
+	asks, bids := d.depth.Asks, d.depth.Bids
+	if len(asks) > d.levels {
+		asks = asks[:d.levels]
+	}
+	if len(bids) > d.levels {
+		bids = bids[:d.levels]
+	}
+
+	rows := [][]string{{"price", "amount"}}
+	for i := len(asks) - 1; i >= 0; i-- {
+		rows = append(rows, []string{formatFloat(asks[i].Price), formatFloat(asks[i].Amount)})
+	}
+	for _, bid := range bids {
+		rows = append(rows, []string{formatFloat(bid.Price), formatFloat(bid.Amount)})
+	}
+	texts := tabulate(rows)
+	lines = append(lines, styled(ansiDim, texts[0]))
+	for i, text := range texts[1:] {
+		if i < len(asks) {
+			lines = append(lines, styled(ansiRed, text))
+		} else {
+			lines = append(lines, styled(ansiGreen, text))
+		}
+	}
+	if ask, bid := d.depth.BestAsk(), d.depth.BestBid(); ask != nil && bid != nil {
+		// Subtracting as decimals keeps 4594.17-4590 from printing as 4.170000000000073.
+		spread := okcoin.NewDecimalFromFloat(ask.Price).Sub(okcoin.NewDecimalFromFloat(bid.Price))
+		lines = append(lines, styled(ansiDim, "spread "+spread.String()))
+	}
+	return lines
+}
+
+// tradeLines lists the most recent trades first.
+func (d *dashboard) tradeLines() []line {
+	lines := []line{styled(ansiBold, fmt.Sprintf("%s recent trades", d.bookSymbol))}
+	if d.trades == nil {
+		return append(lines, plain("…"))
+	}
+	rows := [][]string{{"time", "type", "price", "amount"}}
+	var types []string
+	for i := len(d.trades) - 1; i >= 0 && len(rows) <= d.nTrades; i-- {
+		trade := d.trades[i]
+		rows = append(rows, []string{trade.Time().UTC().Format("15:04:05"), trade.Type,
+			trade.PriceDecimal().String(), trade.AmountDecimal().String()})
+		types = append(types, trade.Type)
+	}
+	texts := tabulate(rows)
+	lines = append(lines, styled(ansiDim, texts[0]))
+	for i, text := range texts[1:] {
+		style := ansiGreen
+		if types[i] == string(okcoin.Sell) {
+			style = ansiRed
+		}
+		lines = append(lines, styled(style, text))
+	}
+	return lines
+}
+
+// tabulate aligns rows into right-aligned columns.
+func tabulate(rows [][]string) []string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 8, 2, ' ', tabwriter.AlignRight)
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t\n", strings.Join(row, "\t"))
+	}
+	tw.Flush()
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+}
+
+// segment is text printed in an ANSI style.
+type segment struct {
+	style string
+	text  string
+}
+
+type line []segment
+
+func plain(text string) line {
+	return line{{text: text}}
+}
+
+func styled(style, text string) line {
+	return line{{style: style, text: text}}
+}
+
+func (l line) width() int {
+	n := 0
+	for _, seg := range l {
+		n += utf8.RuneCountInString(seg.text)
+	}
+	return n
+}
+
+// writeTo writes the line cut to cols characters, unless cols is 0.
+func (l line) writeTo(b *strings.Builder, cols int) {
+	remaining := cols
+	for _, seg := range l {
+		text := seg.text
+		if cols > 0 {
+			if remaining <= 0 {
+				return
+			}
+			if runes := []rune(text); len(runes) > remaining {
+				text = string(runes[:remaining])
+			}
+			remaining -= utf8.RuneCountInString(text)
+		}
+		if seg.style == "" || text == "" {
+			b.WriteString(text)
+		} else {
+			b.WriteString(seg.style + text + ansiReset)
+		}
+	}
+}
+
+// sideBySide places right to the side of left, gap spaces apart.
+func sideBySide(left, right []line, gap int) []line {
+	width := 0
+	for _, l := range left {
+		if w := l.width(); w > width {
+			width = w
+		}
+	}
+	n := len(left)
+	if len(right) > n {
+		n = len(right)
+	}
+	lines := make([]line, n)
+	for i := range lines {
+		var l line
+		if i < len(left) {
+			l = append(l, left[i]...)
+		}
+		if i < len(right) {
+			l = append(l, segment{text: strings.Repeat(" ", width+gap-l.width())})
+			l = append(l, right[i]...)
+		}
+		lines[i] = l
+	}
+	return lines
+}
@@ -0,0 +1,254 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestReadKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := make(chan key, 16)
+	readKeys(strings.NewReader("lhx\x1b[C\x1b[D\x1b[A3rq"), keys)
+	var got []key
+	for k := range keys {
+		got = append(got, k)
+	}
+	want := []key{keyNext, keyPrev, keyNext, keyPrev, keyPrev, keyDigit1 + 2, keyRefresh, keyQuit}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v want=%v", got, want)
+	}
+}
+
+func TestReadKeysLoneEscape(t *testing.T) {
+	t.Parallel()
+
+	// A lone ESC, or a doubled one, must not swallow the key after it.
+	keys := make(chan key, 16)
+	readKeys(strings.NewReader("\x1bl\x1b\x1b[D\x1bq"), keys)
+	var got []key
+	for k := range keys {
+		got = append(got, k)
+	}
+	want := []key{keyNext, keyPrev, keyQuit}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v want=%v", got, want)
+	}
+}
+
+// refresh polls the book of the selected symbol, and the
+// tickers too if asked, the way the dashboard's poller does.
+func refresh(ctx context.Context, d *dashboard, tickers bool) {
+	d.selectBook()
+	d.apply(d.fetch(ctx, pollRequest{symbol: d.bookSymbol, tickers: tickers}))
+}
+
+// frameText returns the frame without its ANSI styles.
+func frameText(lines []line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		for _, seg := range l {
+			b.WriteString(seg.text)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestDashboardFrame(t *testing.T) {
+	t.Parallel()
+
+	c, srv, _, _ := newTestCLI(t)
+	defer srv.Close()
+	srv.SetTicker(okcoin.LTCUSD, &okcoin.TickerResponse{Ticker: &okcoin.Ticker{Last: 55.5}})
+	client, err := c.newClient(false)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	d := newDashboard(client, []okcoin.Symbol{okcoin.BTCUSD, okcoin.LTCUSD}, new(bytes.Buffer))
+	d.now = func() time.Time { return time.Unix(1503960025, 0) }
+	ctx := context.Background()
+	refresh(ctx, d, true)
+
+	text := frameText(d.frame())
+	for _, want := range []string{
+		"2017-08-28T22:40:25Z",
+		"btc_usd order book",
+		"btc_usd recent trades",
+		"55.5",
+		"spread 4.17",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("frame lacks %q:\n%s", want, text)
+		}
+	}
+	// The ladder runs from the worst ask down to the best bid,
+	// next to the trades from the most recent.
+	book := text[strings.Index(text, "order book"):]
+	if i, j := strings.Index(book, "4600"), strings.Index(book, "4594.17"); i < 0 || j < i {
+		t.Errorf("asks are not from the worst:\n%s", book)
+	}
+	if i, j := strings.Index(book, "22:40:02"), strings.Index(book, "22:40:00"); i < 0 || j < i {
+		t.Errorf("trades are not from the most recent:\n%s", book)
+	}
+
+	// A rise since the last poll colors the ticker.
+	srv.SetTicker(okcoin.BTCUSD, &okcoin.TickerResponse{Ticker: &okcoin.Ticker{Last: 4700}})
+	refresh(ctx, d, true)
+	if g, w := d.tickerLines()[1][0].style, ansiGreen+ansiReverse; g != w {
+		t.Errorf("rising ticker style: got=%q want=%q", g, w)
+	}
+
+	// LTC has no order book, which is shown as an error.
+	if !d.handle(keyNext) {
+		t.Fatalf("next: want the selection to change")
+	}
+	refresh(ctx, d, false)
+	text = frameText(d.frame())
+	if !strings.Contains(text, "ltc_usd order book") || !strings.Contains(text, "ltc_usd depth:") {
+		t.Errorf("frame for ltc_usd:\n%s", text)
+	}
+	if d.handle(keyDigit1+5) || d.selected != 1 {
+		t.Errorf("jumping past the symbols: selected=%d want=1", d.selected)
+	}
+	if !d.handle(keyNext) || d.selected != 0 {
+		t.Errorf("next from the last symbol: selected=%d want=0", d.selected)
+	}
+}
+
+func TestDashboardRun(t *testing.T) {
+	t.Parallel()
+
+	c, srv, _, _ := newTestCLI(t)
+	defer srv.Close()
+	srv.SetTicker(okcoin.LTCUSD, &okcoin.TickerResponse{Ticker: &okcoin.Ticker{Last: 55.5}})
+	client, err := c.newClient(false)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	out := new(bytes.Buffer)
+	d := newDashboard(client, []okcoin.Symbol{okcoin.BTCUSD, okcoin.LTCUSD}, out)
+	d.size = func() (int, int) { return 5, 20 }
+
+	keys := make(chan key, 2)
+	keys <- keyNext
+	keys <- keyQuit
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.run(ctx, keys); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("run only stopped at the deadline")
+	}
+	if g, w := d.selectedSymbol(), okcoin.LTCUSD; g != w {
+		t.Errorf("selected: got=%v want=%v", g, w)
+	}
+
+	frames := strings.Split(out.String(), ansiHome)[1:]
+	if len(frames) < 2 {
+		t.Fatalf("got %d frames want at least 2", len(frames))
+	}
+	for i, frame := range frames {
+		if n := strings.Count(frame, "\r\n"); n > 4 {
+			t.Errorf("frame #%d: got %d lines want at most 5", i, n+1)
+		}
+	}
+}
+
+func TestDashboardKeysDuringHungPolls(t *testing.T) {
+	t.Parallel()
+
+	c, srv, _, _ := newTestCLI(t)
+	defer srv.Close()
+	srv.SetLatency(time.Hour)
+	client, err := c.newClient(false)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	d := newDashboard(client, []okcoin.Symbol{okcoin.BTCUSD, okcoin.LTCUSD}, new(bytes.Buffer))
+	d.interval = time.Hour
+	keys := make(chan key, 2)
+	keys <- keyNext
+	keys <- keyQuit
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.run(ctx, keys); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("the keys waited on the polls")
+	}
+	if g, w := d.selectedSymbol(), okcoin.LTCUSD; g != w {
+		t.Errorf("selected: got=%v want=%v", g, w)
+	}
+}
+
+func TestDashboardGivesUpOnHungRequests(t *testing.T) {
+	t.Parallel()
+
+	c, srv, _, _ := newTestCLI(t)
+	defer srv.Close()
+	srv.SetLatency(time.Hour)
+	client, err := c.newClient(false)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	d := newDashboard(client, []okcoin.Symbol{okcoin.BTCUSD}, new(bytes.Buffer))
+	d.interval = 20 * time.Millisecond
+	start := time.Now()
+	refresh(context.Background(), d, true)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("refresh took %v", elapsed)
+	}
+	if d.bookErr == nil || !strings.Contains(d.bookErr.Error(), "depth") {
+		t.Errorf("book error: got=%v want the depth's timeout", d.bookErr)
+	}
+}
+
+func TestDashboardNeedsTerminal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		wantErr error
+	}{
+		{args: []string{"dashboard"}, wantErr: errNoTerminal},
+		{args: []string{"dashboard", "-interval", "0"}, wantErr: errNonPositivePoll},
+		{args: []string{"dashboard", "-levels", "500"}, wantErr: errDashboardLevels},
+		{args: []string{"dashboard", "-trades", "0"}, wantErr: errDashboardNTrades},
+	}
+	for i, tt := range tests {
+		c, srv, _, _ := newTestCLI(t)
+		c.stdin = strings.NewReader("q")
+		err := c.run(context.Background(), tt.args)
+		srv.Close()
+		if err != tt.wantErr {
+			t.Errorf("#%d %v: got=%v want=%v", i, tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// balance is a row of the funds command, with the
+// amounts exactly as the exchange sent them.
+type balance struct {
+	Currency okcoin.Currency `json:"currency"`
+	Free     okcoin.Decimal  `json:"free"`
+	Frozen   okcoin.Decimal  `json:"frozen"`
+	Total    okcoin.Decimal  `json:"total"`
+}
+
+func (c *cli) funds(ctx context.Context, args []string) error {
+	fs, opts := c.flagSet("funds")
+	all := fs.Bool("all", false, "also show the currencies with nothing held")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	client, err := c.newClient(true)
+	if err != nil {
+		return err
+	}
+
+	return c.repeat(ctx, opts, func() error {
+		funds, err := client.FundsContext(ctx)
+		if err != nil {
+			return err
+		}
+		balances := fundsBalances(funds, *all)
+		t := &table{header: []string{"currency", "free", "frozen", "total"}}
+		for _, b := range balances {
+			t.add(string(b.Currency), b.Free.String(), b.Frozen.String(), b.Total.String())
+		}
+		return render(c.stdout, opts.format, balances, t)
+	})
+}
+
+// fundsBalances merges the free and frozen funds by
+// currency, sorted by the name of the currency.
+func fundsBalances(funds *okcoin.Funds, all bool) []*balance {
+	seen := make(map[okcoin.Currency]bool)
+	var currencies []string
+	for _, fund := range []*okcoin.Fund{funds.Free, funds.Frozen} {
+		if fund == nil {
+			continue
+		}
+		for cur := range fund.Balances {
+			if !seen[cur] {
+				seen[cur] = true
+				currencies = append(currencies, string(cur))
+			}
+		}
+	}
+	sort.Strings(currencies)
+
+	var balances []*balance
+	for _, name := range currencies {
+		cur := okcoin.Currency(name)
+		b := &balance{Currency: cur, Free: funds.Free.BalanceDecimal(cur), Frozen: funds.Frozen.BalanceDecimal(cur)}
+		b.Total = b.Free.Add(b.Frozen)
+		if b.Total.IsZero() && !all {
+			continue
+		}
+		balances = append(balances, b)
+	}
+	return balances
+}
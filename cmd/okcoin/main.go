@@ -0,0 +1,213 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command okcoin queries OKCoin market data and account balances,
+// and places and cancels orders.
+//
+// Usage:
+//
+//	okcoin ticker  [flags] [symbol ...]
+//	okcoin trades  [flags]
+//	okcoin candles [flags]
+//	okcoin depth   [flags]
+//	okcoin funds   [flags]
+//	okcoin order place  [flags]
+//	okcoin order cancel [flags] order_id ...
+//	okcoin order list   [flags]
+//	okcoin order status [flags] order_id ...
+//	okcoin dashboard [flags] [symbol ...]
+//
+// Every subcommand accepts -format table|json|csv and -watch <interval>
+// to repeat the query until interrupted. Credentials are read from the
+// OKCOIN_API_KEY and OKCOIN_API_SECRET environment variables; only
+// funds and order require them.
+//
+// order place and order cancel ask for confirmation on stdin unless
+// given -yes, and only print the signed request when given -dry-run.
+// Errors from the exchange are printed to stdout in the requested
+// format with their error_code, and exit with status 1.
+//
+// dashboard takes over the terminal to show the live tickers of several
+// symbols, with the order book and recent trades of the selected one.
+// The arrow keys, h/l or 1-9 select a symbol, r refreshes and q quits.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		<-sigs
+		// A second interrupt kills the process, should
+		// a request ignore the cancellation.
+		signal.Stop(sigs)
+		cancel()
+	}()
+
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, newClient: clientFromEnv}
+	err := c.run(ctx, os.Args[1:])
+	switch err.(type) {
+	case nil:
+	case reportedError:
+		os.Exit(1)
+	default:
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "okcoin: %v\n", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// clientFromEnv returns a client with the credentials from the
+// environment or, unless they are required, one without them.
+func clientFromEnv(needCredentials bool) (*okcoin.Client, error) {
+	client, err := okcoin.NewClientFromEnv()
+	if err == nil || needCredentials {
+		return client, err
+	}
+	return okcoin.NewDefaultClient()
+}
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	newClient func(needCredentials bool) (*okcoin.Client, error)
+}
+
+type command struct {
+	summary string
+	run     func(c *cli, ctx context.Context, args []string) error
+}
+
+var commands = map[string]*command{
+	"ticker":    {"show the tickers of symbols", (*cli).ticker},
+	"trades":    {"show the latest trades of a symbol", (*cli).trades},
+	"candles":   {"show the candle sticks of a symbol", (*cli).candles},
+	"depth":     {"show the order book of a symbol", (*cli).depth},
+	"funds":     {"show the account's free and frozen balances", (*cli).funds},
+	"order":     {"place, cancel, list and show orders", (*cli).order},
+	"dashboard": {"watch tickers, an order book and trades full-screen", (*cli).dashboard},
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		c.usage()
+		return flag.ErrHelp
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		if args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+			fmt.Fprintf(c.stderr, "unknown command %q\n", args[0])
+		}
+		c.usage()
+		return flag.ErrHelp
+	}
+	return cmd.run(c, ctx, args[1:])
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "Usage: okcoin <command> [flags] [args]\n\nCommands:")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(c.stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(c.stderr, "\nRun okcoin <command> -h for the flags of a command.")
+}
+
+// outputOptions are the flags shared by every command.
+type outputOptions struct {
+	format string
+	watch  time.Duration
+}
+
+func (c *cli) flagSet(name string) (*flag.FlagSet, *outputOptions) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	opts := new(outputOptions)
+	fs.StringVar(&opts.format, "format", formatTable, "output format: table, json or csv")
+	fs.DurationVar(&opts.watch, "watch", 0, "repeat the query at this interval until interrupted")
+	return fs, opts
+}
+
+func (opts *outputOptions) validate() error {
+	switch opts.format {
+	case formatTable, formatJSON, formatCSV:
+	default:
+		return fmt.Errorf("unknown format %q", opts.format)
+	}
+	if opts.watch < 0 {
+		return errors.New("expecting a non-negative -watch interval")
+	}
+	return nil
+}
+
+// repeat runs fn once, then at every opts.watch interval until ctx is
+// done. Tables are separated by the time of their refresh.
+func (c *cli) repeat(ctx context.Context, opts *outputOptions, fn func() error) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil || opts.watch == 0 {
+		return err
+	}
+	ticker := time.NewTicker(opts.watch)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case t := <-ticker.C:
+			if opts.format == formatTable {
+				fmt.Fprintf(c.stdout, "\n%s\n", t.UTC().Format(time.RFC3339))
+			}
+			if err := fn(); err != nil {
+				if ctx.Err() != nil {
+					// Interrupted while refreshing.
+					return nil
+				}
+				return err
+			}
+		}
+	}
+}
+
+// parseSymbol accepts symbols like btc_usd, BTC-USD and btc/usd.
+func parseSymbol(s string) (okcoin.Symbol, error) {
+	base, quote, err := okcoin.ParseSymbol(s)
+	if err != nil {
+		return "", err
+	}
+	return okcoin.NewSymbol(base, quote), nil
+}
@@ -0,0 +1,307 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/okcointest"
+)
+
+const (
+	apiKey1    = "key1"
+	apiSecret1 = "secret1"
+)
+
+// newTestCLI returns a cli talking to a fake exchange with a
+// BTC/USD market and an account holding USD and BTC.
+func newTestCLI(t *testing.T) (*cli, *okcointest.Server, *bytes.Buffer, *bytes.Buffer) {
+	srv := okcointest.NewServer()
+	srv.SetTicker(okcoin.BTCUSD, &okcoin.TickerResponse{
+		TimeAtEpoch: 1503960025,
+		Ticker:      &okcoin.Ticker{Buy: 4590, Sell: 4594.17, Last: 4592, High: 4600, Low: 4500, Volume: 120.5},
+	})
+	srv.SetTrades(okcoin.BTCUSD, []*okcoin.Trade{
+		{ID: 1, Type: "buy", Price: 4590, Amount: 0.1, Date: 1503960000},
+		{ID: 2, Type: "sell", Price: 4591, Amount: 0.2, Date: 1503960001},
+		{ID: 3, Type: "buy", Price: 4592, Amount: 0.3, Date: 1503960002},
+	})
+	srv.SetCandleSticks(okcoin.BTCUSD, okcoin.P1Hour, []*okcoin.CandleStick{
+		{TimeStampMs: 1503957600000, Open: 4500, High: 4600, Low: 4490, Close: 4590, Volume: 10},
+	})
+	srv.SetDepth(okcoin.BTCUSD, &okcoin.Depth{
+		Asks: []*okcoin.PriceLevel{{Price: 4594.17, Amount: 1}, {Price: 4600, Amount: 2}},
+		Bids: []*okcoin.PriceLevel{{Price: 4590, Amount: 3}},
+	})
+	balances := map[okcoin.Currency]float64{okcoin.USD: 10000, okcoin.BTC: 0.5}
+	if err := srv.AddAccount(apiKey1, apiSecret1, balances); err != nil {
+		srv.Close()
+		t.Fatalf("add account: %v", err)
+	}
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		stdout: stdout,
+		stderr: stderr,
+		newClient: func(needCredentials bool) (*okcoin.Client, error) {
+			if !needCredentials {
+				return srv.NewClient(nil)
+			}
+			return srv.NewClient(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+		},
+	}
+	return c, srv, stdout, stderr
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"ticker", "BTC-USD"},
+			want: []string{"symbol", "btc_usd", "4592", "4594.17", "120.5", "2017-08-28T22:40:25Z"},
+		},
+		{
+			args: []string{"trades", "-n", "2"},
+			want: []string{"id", "2", "sell", "4591", "3", "0.3"},
+		},
+		{
+			args: []string{"candles", "-period", "1hour"},
+			want: []string{"close", "2017-08-28T22:00:00Z", "4490", "4590"},
+		},
+		{
+			args: []string{"depth", "-format", "csv"},
+			want: []string{"side,price,amount\nask,4600,2\nask,4594.17,1\nbid,4590,3\n"},
+		},
+		{
+			args: []string{"funds", "-format", "csv"},
+			want: []string{"currency,free,frozen,total\nbtc,0.5,0,0.5\nusd,10000,0,10000\n"},
+		},
+	}
+
+	for i, tt := range tests {
+		c, srv, stdout, _ := newTestCLI(t)
+		err := c.run(context.Background(), tt.args)
+		srv.Close()
+		if err != nil {
+			t.Errorf("#%d %v: %v", i, tt.args, err)
+			continue
+		}
+		got := stdout.String()
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("#%d %v: output lacks %q:\n%s", i, tt.args, want, got)
+			}
+		}
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	t.Parallel()
+
+	c, srv, stdout, _ := newTestCLI(t)
+	defer srv.Close()
+
+	if err := c.run(context.Background(), []string{"ticker", "-format", "json", "btc_usd"}); err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	var tickers map[okcoin.Symbol]*okcoin.TickerResponse
+	if err := json.Unmarshal(stdout.Bytes(), &tickers); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	if g, w := tickers[okcoin.BTCUSD].Ticker.Last, 4592.0; g != w {
+		t.Errorf("last: got=%v want=%v", g, w)
+	}
+}
+
+// cancelAfter cancels a watch once its output has n refreshes.
+type cancelAfter struct {
+	bytes.Buffer
+	n      int
+	cancel func()
+}
+
+func (ca *cancelAfter) Write(p []byte) (int, error) {
+	n, err := ca.Buffer.Write(p)
+	if strings.Count(ca.String(), "symbol,") >= ca.n {
+		ca.cancel()
+	}
+	return n, err
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	c, srv, _, _ := newTestCLI(t)
+	defer srv.Close()
+
+	// The timeout is only a safety net: the watch is cancelled
+	// by its output once it has refreshed twice.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	stdout := &cancelAfter{n: 2, cancel: cancel}
+	c.stdout = stdout
+	args := []string{"ticker", "-format", "csv", "-watch", "10ms"}
+	if err := c.run(ctx, args); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	if n := strings.Count(stdout.String(), "symbol,"); n != 2 {
+		t.Errorf("watch: got %d refreshes want 2:\n%s", n, stdout)
+	}
+}
+
+func TestCommandsGiveUpWithContext(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{
+		{"ticker"},
+		{"trades"},
+		{"candles"},
+		{"depth"},
+		{"funds"},
+	} {
+		c, srv, _, _ := newTestCLI(t)
+		srv.SetLatency(time.Hour)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		start := time.Now()
+		err := c.run(ctx, args)
+		cancel()
+		srv.Close()
+		if err == nil {
+			t.Errorf("%v: want non-nil error", args)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%v: took %v", args, elapsed)
+		}
+	}
+}
+
+// TestExactDigits checks that prices and amounts are printed as the
+// exchange sent them rather than through float64.
+func TestExactDigits(t *testing.T) {
+	t.Parallel()
+
+	bodies := map[string]string{
+		"ticker.do":   `{"date":"1503960025","ticker":{"buy":"4590.10","high":"4600","last":"4592.00","low":"4500","sell":"4594.17","vol":"120.50"}}`,
+		"trades.do":   `[{"amount":"0.10","date":1503960000,"price":"4590.10","tid":1,"type":"buy"}]`,
+		"kline.do":    `[[1503957600000,4500.00,4600,4490,4590.10,10.0]]`,
+		"userinfo.do": `{"result":true,"info":{"funds":{"free":{"btc":"0.1","usd":"0"},"freezed":{"btc":"0.2","usd":"0"}}}}`,
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"ticker", "-format", "csv"}, "btc_usd,4592.00,4590.10,4594.17,4600,4500,120.50,"},
+		{[]string{"trades", "-format", "csv"}, ",buy,4590.10,0.10\n"},
+		{[]string{"candles", "-format", "csv"}, ",4500.00,4600,4490,4590.10,10.0\n"},
+		{[]string{"funds", "-format", "csv"}, "btc,0.1,0.2,0.3\n"},
+	}
+	for _, tt := range tests {
+		c, srv, stdout, _ := newTestCLI(t)
+		newClient := c.newClient
+		c.newClient = func(needCredentials bool) (*okcoin.Client, error) {
+			client, err := newClient(needCredentials)
+			if err != nil {
+				return nil, err
+			}
+			client.SetMiddleware(okcoin.MiddlewareFunc(func(ctx context.Context, call *okcoin.Call, next okcoin.Invoker) (*okcoin.Response, error) {
+				if body, ok := bodies[call.Endpoint]; ok {
+					return &okcoin.Response{Body: []byte(body)}, nil
+				}
+				return next(ctx, call)
+			}))
+			return client, nil
+		}
+		err := c.run(context.Background(), tt.args)
+		srv.Close()
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if !strings.Contains(stdout.String(), tt.want) {
+			t.Errorf("%v: output lacks %q:\n%s", tt.args, tt.want, stdout)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		setup   func(*okcointest.Server)
+		wantErr error
+		wantMsg string
+	}{
+		{args: nil, wantErr: flag.ErrHelp},
+		{args: []string{"bogus"}, wantErr: flag.ErrHelp},
+		{args: []string{"ticker", "btcusd"}, wantMsg: "malformed symbol"},
+		{args: []string{"ticker", "-format", "xml"}, wantMsg: "unknown format"},
+		{args: []string{"candles", "-period", "2min"}, wantMsg: "unknown period"},
+		{args: []string{"funds", "-watch", "-1s"}, wantMsg: "non-negative"},
+		{
+			args:    []string{"funds"},
+			setup:   func(srv *okcointest.Server) { srv.FailWithCode("userinfo.do", 10001) },
+			wantErr: &okcoin.APIError{Code: 10001},
+		},
+	}
+
+	for i, tt := range tests {
+		c, srv, _, _ := newTestCLI(t)
+		if tt.setup != nil {
+			tt.setup(srv)
+		}
+		err := c.run(context.Background(), tt.args)
+		srv.Close()
+		if err == nil {
+			t.Errorf("#%d %v: want non-nil error", i, tt.args)
+			continue
+		}
+		if tt.wantErr != nil && err.Error() != tt.wantErr.Error() {
+			t.Errorf("#%d %v: got=%v want=%v", i, tt.args, err, tt.wantErr)
+		}
+		if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+			t.Errorf("#%d %v: got=%v want it to mention %q", i, tt.args, err, tt.wantMsg)
+		}
+	}
+
+	// Credentials are only required by funds.
+	c, srv, _, _ := newTestCLI(t)
+	defer srv.Close()
+	errNoCreds := errors.New("no credentials")
+	c.newClient = func(needCredentials bool) (*okcoin.Client, error) {
+		if needCredentials {
+			return nil, errNoCreds
+		}
+		return srv.NewClient(nil)
+	}
+	if err := c.run(context.Background(), []string{"ticker"}); err != nil {
+		t.Errorf("ticker without credentials: %v", err)
+	}
+	if err := c.run(context.Background(), []string{"funds"}); err != errNoCreds {
+		t.Errorf("funds without credentials: got=%v want=%v", err, errNoCreds)
+	}
+}
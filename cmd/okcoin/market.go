@@ -0,0 +1,175 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func (c *cli) ticker(ctx context.Context, args []string) error {
+	fs, opts := c.flagSet("ticker")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	symbols := []okcoin.Symbol{okcoin.BTCUSD}
+	if fs.NArg() > 0 {
+		symbols = symbols[:0]
+		for _, arg := range fs.Args() {
+			sym, err := parseSymbol(arg)
+			if err != nil {
+				return err
+			}
+			symbols = append(symbols, sym)
+		}
+	}
+	client, err := c.newClient(false)
+	if err != nil {
+		return err
+	}
+
+	return c.repeat(ctx, opts, func() error {
+		results := client.Tickers(ctx, symbols...)
+		tickers := make(map[okcoin.Symbol]*okcoin.TickerResponse, len(results))
+		t := &table{header: []string{"symbol", "last", "buy", "sell", "high", "low", "vol", "time"}}
+		for _, sym := range symbols {
+			res := results[sym]
+			if res.Err != nil {
+				return fmt.Errorf("%s: %v", sym, res.Err)
+			}
+			tickers[sym] = res.Ticker
+			tk := res.Ticker.Ticker
+			if tk == nil {
+				tk = new(okcoin.Ticker)
+			}
+			t.add(string(sym), tk.LastDecimal().String(), tk.BuyDecimal().String(), tk.SellDecimal().String(),
+				tk.HighDecimal().String(), tk.LowDecimal().String(), tk.VolumeDecimal().String(),
+				formatTime(res.Ticker.Time()))
+		}
+		return render(c.stdout, opts.format, tickers, t)
+	})
+}
+
+func (c *cli) trades(ctx context.Context, args []string) error {
+	fs, opts := c.flagSet("trades")
+	symbol := fs.String("symbol", string(okcoin.BTCUSD), "the symbol to show trades of")
+	sinceID := fs.Int("since-id", 0, "only show the trades after this trade ID")
+	n := fs.Int("n", 0, "only show the n most recent trades, if positive")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sym, err := parseSymbol(*symbol)
+	if err != nil {
+		return err
+	}
+	client, err := c.newClient(false)
+	if err != nil {
+		return err
+	}
+
+	return c.repeat(ctx, opts, func() error {
+		ltres, err := client.LastTradesContext(ctx, &okcoin.LastTradesRequest{Symbol: sym, LastTradeID: *sinceID})
+		if err != nil {
+			return err
+		}
+		trades := ltres.Trades
+		if *n > 0 && len(trades) > *n {
+			trades = trades[len(trades)-*n:]
+		}
+		t := &table{header: []string{"id", "time", "type", "price", "amount"}}
+		for _, trade := range trades {
+			t.add(strconv.FormatInt(trade.ID, 10), formatTime(trade.Time()), trade.Type,
+				trade.PriceDecimal().String(), trade.AmountDecimal().String())
+		}
+		return render(c.stdout, opts.format, trades, t)
+	})
+}
+
+func (c *cli) candles(ctx context.Context, args []string) error {
+	fs, opts := c.flagSet("candles")
+	symbol := fs.String("symbol", string(okcoin.BTCUSD), "the symbol to show candle sticks of")
+	period := fs.String("period", string(okcoin.P1Hour), "the period of each candle stick e.g. 1min, 1hour, 1day")
+	n := fs.Int("n", 24, "the number of most recent candle sticks")
+	since := fs.Duration("since", 0, "only show the candle sticks of this recent duration, if set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sym, err := parseSymbol(*symbol)
+	if err != nil {
+		return err
+	}
+	if okcoin.Period(*period).Duration() == 0 {
+		return fmt.Errorf("unknown period %q", *period)
+	}
+	client, err := c.newClient(false)
+	if err != nil {
+		return err
+	}
+
+	return c.repeat(ctx, opts, func() error {
+		csr := &okcoin.CandleStickRequest{Symbol: sym, Period: okcoin.Period(*period), N: *n}
+		if *since > 0 {
+			csr.SinceTime = time.Now().Add(-*since)
+		}
+		cres, err := client.CandleStickContext(ctx, csr)
+		if err != nil {
+			return err
+		}
+		t := &table{header: []string{"time", "open", "high", "low", "close", "volume"}}
+		for _, cs := range cres.CandleSticks {
+			t.add(formatTime(cs.Time()), cs.OpenDecimal().String(), cs.HighDecimal().String(),
+				cs.LowDecimal().String(), cs.CloseDecimal().String(), cs.VolumeDecimal().String())
+		}
+		return render(c.stdout, opts.format, cres.CandleSticks, t)
+	})
+}
+
+func (c *cli) depth(ctx context.Context, args []string) error {
+	fs, opts := c.flagSet("depth")
+	symbol := fs.String("symbol", string(okcoin.BTCUSD), "the symbol to show the order book of")
+	size := fs.Int("size", 10, "the number of price levels per side, at most 200")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sym, err := parseSymbol(*symbol)
+	if err != nil {
+		return err
+	}
+	client, err := c.newClient(false)
+	if err != nil {
+		return err
+	}
+
+	return c.repeat(ctx, opts, func() error {
+		depth, err := client.DepthContext(ctx, &okcoin.DepthRequest{Symbol: sym, Size: *size})
+		if err != nil {
+			return err
+		}
+		// Laid out as a ladder: the asks from the worst down
+		// to the best, followed by the bids from the best.
+		t := &table{header: []string{"side", "price", "amount"}}
+		for i := len(depth.Asks) - 1; i >= 0; i-- {
+			t.add("ask", formatFloat(depth.Asks[i].Price), formatFloat(depth.Asks[i].Amount))
+		}
+		for _, bid := range depth.Bids {
+			t.add("bid", formatFloat(bid.Price), formatFloat(bid.Amount))
+		}
+		return render(c.stdout, opts.format, depth, t)
+	})
+}
@@ -0,0 +1,408 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+var orderCommands = map[string]*command{
+	"place":  {"place an order", (*cli).orderPlace},
+	"cancel": {"cancel orders by ID", (*cli).orderCancel},
+	"list":   {"list the open orders of a symbol", (*cli).orderList},
+	"status": {"show orders by ID", (*cli).orderStatus},
+}
+
+func (c *cli) order(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		if cmd, ok := orderCommands[args[0]]; ok {
+			return cmd.run(c, ctx, args[1:])
+		}
+		if args[0] != "help" && args[0] != "-h" && args[0] != "--help" {
+			fmt.Fprintf(c.stderr, "unknown order command %q\n", args[0])
+		}
+	}
+	fmt.Fprintln(c.stderr, "Usage: okcoin order <command> [flags] [args]\n\nCommands:")
+	var names []string
+	for name := range orderCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(c.stderr, "  %-8s %s\n", name, orderCommands[name].summary)
+	}
+	return flag.ErrHelp
+}
+
+// reportedError is an error already printed to stdout in the
+// requested format, so main only has to set the exit status.
+type reportedError struct {
+	error
+}
+
+// apiErrorResult is the form in which API errors are printed.
+type apiErrorResult struct {
+	Code    int    `json:"error_code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+var apiErrorHeader = []string{"error_code", "message"}
+
+// reportAPIError prints err if it is an *okcoin.APIError and returns
+// it as a reportedError, otherwise it returns err unchanged.
+func (c *cli) reportAPIError(opts *outputOptions, err error) error {
+	ae, ok := err.(*okcoin.APIError)
+	if !ok {
+		return err
+	}
+	res := &apiErrorResult{Code: ae.Code, Message: ae.Message()}
+	t := &table{header: apiErrorHeader}
+	t.add(strconv.Itoa(res.Code), res.Message)
+	if rerr := render(c.stdout, opts.format, res, t); rerr != nil {
+		return rerr
+	}
+	return reportedError{err}
+}
+
+// tradeOptions are the flags of the commands that change orders.
+type tradeOptions struct {
+	dryRun bool
+	yes    bool
+}
+
+func (c *cli) tradeFlagSet(name string) (*flag.FlagSet, *outputOptions, *tradeOptions) {
+	fs, opts := c.flagSet(name)
+	topts := new(tradeOptions)
+	fs.BoolVar(&topts.dryRun, "dry-run", false, "print the signed request instead of sending it")
+	fs.BoolVar(&topts.yes, "yes", false, "send the request without asking for confirmation")
+	return fs, opts, topts
+}
+
+var errWatchTrade = errors.New("-watch does not apply to placing or cancelling orders")
+
+func (opts *outputOptions) validateTrade() error {
+	if opts.watch != 0 {
+		return errWatchTrade
+	}
+	return opts.validate()
+}
+
+var errNotConfirmed = errors.New("not confirmed, nothing was sent")
+
+// confirm asks on stderr whether to go ahead with what
+// and expects a "y" or "yes" answer on stdin.
+func (c *cli) confirm(topts *tradeOptions, what string) error {
+	if topts.yes {
+		return nil
+	}
+	fmt.Fprintf(c.stderr, "%s\nProceed? [y/N] ", what)
+	answer, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(c.stderr)
+		return fmt.Errorf("%v; pass -yes to confirm without a prompt", errNotConfirmed)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errNotConfirmed
+	}
+}
+
+// preview describes a signed request for a dry run.
+type preview struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Params map[string]string `json:"params"`
+}
+
+// printPreview prints req with its API key masked. The signature is
+// shown in full since it is what a dry run is meant to check.
+func (c *cli) printPreview(opts *outputOptions, req *http.Request) error {
+	qv := req.URL.Query()
+	if key := qv.Get("api_key"); key != "" {
+		qv.Set("api_key", maskAPIKey(key))
+	}
+	u := *req.URL
+	u.RawQuery = qv.Encode()
+
+	p := &preview{Method: req.Method, URL: u.String(), Params: make(map[string]string)}
+	var keys []string
+	for key := range qv {
+		keys = append(keys, key)
+		p.Params[key] = qv.Get(key)
+	}
+	sort.Strings(keys)
+	t := &table{header: []string{"param", "value"}}
+	t.add("method", p.Method)
+	t.add("url", p.URL)
+	for _, key := range keys {
+		t.add(key, p.Params[key])
+	}
+	return render(c.stdout, opts.format, p, t)
+}
+
+// maskAPIKey keeps only the last 4 characters of key.
+func maskAPIKey(key string) string {
+	const shown = 4
+	if len(key) <= shown {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", len(key)-shown) + key[len(key)-shown:]
+}
+
+func (c *cli) orderPlace(ctx context.Context, args []string) error {
+	fs, opts, topts := c.tradeFlagSet("order place")
+	symbol := fs.String("symbol", string(okcoin.BTCUSD), "the symbol to trade")
+	typ := fs.String("type", "", "the order type: buy, sell, buy_market or sell_market")
+	price := fs.String("price", "", "the limit price, or the quote amount to spend for buy_market")
+	amount := fs.String("amount", "", "the amount of the base currency, unused for buy_market")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validateTrade(); err != nil {
+		return err
+	}
+	sym, err := parseSymbol(*symbol)
+	if err != nil {
+		return err
+	}
+	oreq := &okcoin.OrderRequest{Symbol: sym, Type: okcoin.OrderType(*typ)}
+	if *price != "" {
+		if oreq.Price, err = okcoin.ParseDecimal(*price); err != nil {
+			return fmt.Errorf("price: %v", err)
+		}
+	}
+	if *amount != "" {
+		if oreq.Amount, err = okcoin.ParseDecimal(*amount); err != nil {
+			return fmt.Errorf("amount: %v", err)
+		}
+	}
+	if err := oreq.Validate(); err != nil {
+		return err
+	}
+	client, err := c.newClient(true)
+	if err != nil {
+		return err
+	}
+
+	if topts.dryRun {
+		req, err := client.PlaceOrderRequest(oreq)
+		if err != nil {
+			return err
+		}
+		return c.printPreview(opts, req)
+	}
+	if err := c.confirm(topts, describeOrder(oreq)); err != nil {
+		return err
+	}
+	ores, err := client.PlaceOrderContext(ctx, oreq)
+	if err != nil {
+		return c.reportAPIError(opts, err)
+	}
+	t := &table{header: []string{"order_id"}}
+	t.add(strconv.FormatInt(ores.OrderID, 10))
+	return render(c.stdout, opts.format, ores, t)
+}
+
+func describeOrder(oreq *okcoin.OrderRequest) string {
+	base, quote := oreq.Symbol.Base(), oreq.Symbol.Quote()
+	switch oreq.Type {
+	case okcoin.BuyMarket:
+		return fmt.Sprintf("Buy %s at market for %s %s.", base, oreq.Price, quote)
+	case okcoin.SellMarket:
+		return fmt.Sprintf("Sell %s %s at market.", oreq.Amount, base)
+	case okcoin.Sell:
+		return fmt.Sprintf("Sell %s %s at %s %s.", oreq.Amount, base, oreq.Price, quote)
+	default:
+		return fmt.Sprintf("Buy %s %s at %s %s.", oreq.Amount, base, oreq.Price, quote)
+	}
+}
+
+// cancelResult is a row of the cancel command.
+type cancelResult struct {
+	OrderID   int64           `json:"order_id"`
+	Cancelled bool            `json:"cancelled"`
+	Error     *apiErrorResult `json:"error,omitempty"`
+}
+
+func (c *cli) orderCancel(ctx context.Context, args []string) error {
+	fs, opts, topts := c.tradeFlagSet("order cancel")
+	symbol := fs.String("symbol", string(okcoin.BTCUSD), "the symbol of the orders")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validateTrade(); err != nil {
+		return err
+	}
+	sym, err := parseSymbol(*symbol)
+	if err != nil {
+		return err
+	}
+	ids, err := parseOrderIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+	client, err := c.newClient(true)
+	if err != nil {
+		return err
+	}
+
+	if topts.dryRun {
+		for _, id := range ids {
+			req, err := client.CancelOrderRequest(sym, id)
+			if err != nil {
+				return err
+			}
+			if err := c.printPreview(opts, req); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	what := fmt.Sprintf("Cancel %d %s order(s): %s.", len(ids), sym, strings.Join(fs.Args(), ", "))
+	if err := c.confirm(topts, what); err != nil {
+		return err
+	}
+
+	// Every order is tried even if some fail, as is
+	// wanted when pulling orders during an incident.
+	var results []*cancelResult
+	var firstErr error
+	failed := 0
+	t := &table{header: []string{"order_id", "cancelled", "error_code", "message"}}
+	for _, id := range ids {
+		res := &cancelResult{OrderID: id}
+		err := client.CancelOrderContext(ctx, sym, id)
+		switch ae, ok := err.(*okcoin.APIError); {
+		case err == nil:
+			res.Cancelled = true
+			t.add(strconv.FormatInt(id, 10), "true", "", "")
+		case ok:
+			res.Error = &apiErrorResult{Code: ae.Code, Message: ae.Message()}
+			t.add(strconv.FormatInt(id, 10), "false", strconv.Itoa(ae.Code), ae.Message())
+		default:
+			// Failures without an error_code, like timeouts, leave it out.
+			res.Error = &apiErrorResult{Message: err.Error()}
+			t.add(strconv.FormatInt(id, 10), "false", "", err.Error())
+		}
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		results = append(results, res)
+	}
+	if err := render(c.stdout, opts.format, results, t); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return reportedError{fmt.Errorf("%d of %d orders not cancelled, first: %v", failed, len(ids), firstErr)}
+	}
+	return nil
+}
+
+func (c *cli) orderList(ctx context.Context, args []string) error {
+	fs, opts := c.flagSet("order list")
+	symbol := fs.String("symbol", string(okcoin.BTCUSD), "the symbol of the orders")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sym, err := parseSymbol(*symbol)
+	if err != nil {
+		return err
+	}
+	client, err := c.newClient(true)
+	if err != nil {
+		return err
+	}
+
+	return c.repeat(ctx, opts, func() error {
+		orders, err := client.OpenOrdersContext(ctx, sym)
+		if err != nil {
+			return c.reportAPIError(opts, err)
+		}
+		return c.printOrders(opts, orders)
+	})
+}
+
+func (c *cli) orderStatus(ctx context.Context, args []string) error {
+	fs, opts := c.flagSet("order status")
+	symbol := fs.String("symbol", string(okcoin.BTCUSD), "the symbol of the orders")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sym, err := parseSymbol(*symbol)
+	if err != nil {
+		return err
+	}
+	ids, err := parseOrderIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+	client, err := c.newClient(true)
+	if err != nil {
+		return err
+	}
+
+	return c.repeat(ctx, opts, func() error {
+		var orders []*okcoin.Order
+		for _, id := range ids {
+			order, err := client.OrderContext(ctx, sym, id)
+			if err != nil {
+				return c.reportAPIError(opts, err)
+			}
+			orders = append(orders, order)
+		}
+		return c.printOrders(opts, orders)
+	})
+}
+
+func (c *cli) printOrders(opts *outputOptions, orders []*okcoin.Order) error {
+	t := &table{header: []string{"order_id", "time", "symbol", "type", "price", "amount", "filled", "avg_price", "status"}}
+	for _, o := range orders {
+		t.add(strconv.FormatInt(o.ID, 10), formatTime(o.CreateDate.Time), string(o.Symbol), string(o.Type),
+			o.Price.String(), o.Amount.String(), o.DealAmount.String(), o.AvgPrice.String(), o.Status.String())
+	}
+	return render(c.stdout, opts.format, orders, t)
+}
+
+var errNoOrderIDs = errors.New("expecting at least one order ID")
+
+func parseOrderIDs(args []string) ([]int64, error) {
+	if len(args) == 0 {
+		return nil, errNoOrderIDs
+	}
+	var ids []int64
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("malformed order ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
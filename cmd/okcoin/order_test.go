@@ -0,0 +1,320 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestOrderDryRun(t *testing.T) {
+	t.Parallel()
+
+	c, srv, stdout, _ := newTestCLI(t)
+	defer srv.Close()
+
+	args := []string{"order", "place", "-dry-run", "-format", "json",
+		"-type", "buy", "-price", "4000", "-amount", "0.1"}
+	if err := c.run(context.Background(), args); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	var p preview
+	if err := json.Unmarshal(stdout.Bytes(), &p); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	if g, w := p.Method, "POST"; g != w {
+		t.Errorf("method: got=%q want=%q", g, w)
+	}
+	if !strings.Contains(p.URL, "/trade.do?") {
+		t.Errorf("url: got=%q want trade.do", p.URL)
+	}
+	if g, w := p.Params["api_key"], "****"; g != w {
+		t.Errorf("api_key: got=%q want=%q", g, w)
+	}
+	if strings.Contains(p.URL, apiKey1) {
+		t.Errorf("url leaks the API key: %q", p.URL)
+	}
+	if len(p.Params["sign"]) != 32 {
+		t.Errorf("sign: got=%q want an MD5 signature", p.Params["sign"])
+	}
+	if g, w := p.Params["price"], "4000"; g != w {
+		t.Errorf("price: got=%q want=%q", g, w)
+	}
+
+	// Nothing must have been sent.
+	client, _ := c.newClient(true)
+	open, err := client.OpenOrders(okcoin.BTCUSD)
+	if err != nil {
+		t.Fatalf("open orders: %v", err)
+	}
+	if len(open) != 0 {
+		t.Errorf("dry run placed orders: %+v", open)
+	}
+}
+
+func TestOrderConfirmation(t *testing.T) {
+	t.Parallel()
+
+	place := []string{"order", "place", "-type", "buy", "-price", "4000", "-amount", "0.1"}
+	tests := []struct {
+		stdin   string
+		args    []string
+		wantErr bool
+		wantN   int
+	}{
+		{stdin: "", args: place, wantErr: true},
+		{stdin: "n\n", args: place, wantErr: true},
+		{stdin: "nope\n", args: place, wantErr: true},
+		{stdin: "y\n", args: place, wantN: 1},
+		{stdin: "YES\n", args: place, wantN: 1},
+		{stdin: "", args: append(place[:len(place):len(place)], "-yes"), wantN: 1},
+	}
+
+	for i, tt := range tests {
+		c, srv, _, stderr := newTestCLI(t)
+		c.stdin = strings.NewReader(tt.stdin)
+		err := c.run(context.Background(), tt.args)
+		if tt.wantErr && err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("#%d: %v", i, err)
+		}
+		prompted := strings.Contains(stderr.String(), "Proceed?")
+		if wantPrompt := tt.args[len(tt.args)-1] != "-yes"; prompted != wantPrompt {
+			t.Errorf("#%d: prompted=%v want=%v", i, prompted, wantPrompt)
+		}
+		client, _ := c.newClient(true)
+		open, err := client.OpenOrders(okcoin.BTCUSD)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("#%d: open orders: %v", i, err)
+		}
+		if g, w := len(open), tt.wantN; g != w {
+			t.Errorf("#%d: open orders: got=%d want=%d", i, g, w)
+		}
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	t.Parallel()
+
+	c, srv, stdout, _ := newTestCLI(t)
+	defer srv.Close()
+	ctx := context.Background()
+
+	args := []string{"order", "place", "-yes", "-format", "json",
+		"-type", "sell", "-price", "5000", "-amount", "0.2"}
+	if err := c.run(ctx, args); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	var ores okcoin.OrderResponse
+	if err := json.Unmarshal(stdout.Bytes(), &ores); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	id := ores.OrderID
+
+	stdout.Reset()
+	if err := c.run(ctx, []string{"order", "list", "-format", "csv"}); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, want := range []string{"order_id,time,symbol", "sell,5000,0.2,0,"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("list: output lacks %q:\n%s", want, stdout)
+		}
+	}
+
+	stdout.Reset()
+	if err := c.run(ctx, []string{"order", "status", "-format", "csv", formatID(id)}); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "unfilled") {
+		t.Errorf("status: want unfilled, got:\n%s", stdout)
+	}
+
+	// One cancel succeeds and the other fails, yet both are tried.
+	stdout.Reset()
+	err := c.run(ctx, []string{"order", "cancel", "-yes", "-format", "csv", "404", formatID(id)})
+	if _, ok := err.(reportedError); !ok {
+		t.Errorf("cancel: got=%v want a reported error", err)
+	}
+	for _, want := range []string{"404,false,10009,order does not exist", formatID(id) + ",true,,"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("cancel: output lacks %q:\n%s", want, stdout)
+		}
+	}
+
+	stdout.Reset()
+	err = c.run(ctx, []string{"order", "status", "-format", "json", "404"})
+	if _, ok := err.(reportedError); !ok {
+		t.Errorf("status of unknown order: got=%v want a reported error", err)
+	}
+	var res apiErrorResult
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	if res.Code != 10009 || res.Message != "order does not exist" {
+		t.Errorf("status of unknown order: got=%+v", res)
+	}
+}
+
+func TestOrderCancelKeepsGoing(t *testing.T) {
+	t.Parallel()
+
+	c, srv, stdout, _ := newTestCLI(t)
+	defer srv.Close()
+	ctx := context.Background()
+
+	var ids []string
+	for _, price := range []string{"5000", "5100"} {
+		stdout.Reset()
+		args := []string{"order", "place", "-yes", "-format", "json",
+			"-type", "sell", "-price", price, "-amount", "0.1"}
+		if err := c.run(ctx, args); err != nil {
+			t.Fatalf("place: %v", err)
+		}
+		var ores okcoin.OrderResponse
+		if err := json.Unmarshal(stdout.Bytes(), &ores); err != nil {
+			t.Fatalf("unmarshal: %v\n%s", err, stdout)
+		}
+		ids = append(ids, formatID(ores.OrderID))
+	}
+
+	// The first order's cancellation never reaches the exchange.
+	newClient := c.newClient
+	c.newClient = func(needCredentials bool) (*okcoin.Client, error) {
+		client, err := newClient(needCredentials)
+		if err != nil {
+			return nil, err
+		}
+		client.SetMiddleware(okcoin.MiddlewareFunc(func(ctx context.Context, call *okcoin.Call, next okcoin.Invoker) (*okcoin.Response, error) {
+			if call.Endpoint == "cancel_order.do" && call.Params.Get("order_id") == ids[0] {
+				return nil, errors.New("connection reset")
+			}
+			return next(ctx, call)
+		}))
+		return client, nil
+	}
+
+	stdout.Reset()
+	err := c.run(ctx, []string{"order", "cancel", "-yes", "-format", "csv", ids[0], "404", ids[1]})
+	if _, ok := err.(reportedError); !ok {
+		t.Fatalf("cancel: got=%v want a reported error", err)
+	}
+	if !strings.Contains(err.Error(), "2 of 3 orders not cancelled") {
+		t.Errorf("cancel: got=%v want it to count the failures", err)
+	}
+	for _, want := range []string{
+		ids[0] + ",false,,connection reset",
+		"404,false,10009,order does not exist",
+		ids[1] + ",true,,",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("cancel: output lacks %q:\n%s", want, stdout)
+		}
+	}
+
+	stdout.Reset()
+	c.run(ctx, []string{"order", "cancel", "-yes", "-format", "json", ids[0]})
+	var results []*cancelResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	if len(results) != 1 || results[0].Cancelled || results[0].Error == nil || results[0].Error.Message != "connection reset" {
+		t.Errorf("cancel: got=%s", stdout)
+	}
+	if strings.Contains(stdout.String(), "error_code") {
+		t.Errorf("cancel: the failure has an error_code:\n%s", stdout)
+	}
+}
+
+func TestOrderCommandsGiveUpWithContext(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{
+		{"order", "place", "-yes", "-type", "buy", "-price", "4000", "-amount", "0.1"},
+		{"order", "cancel", "-yes", "1", "2"},
+		{"order", "list"},
+		{"order", "status", "1"},
+	} {
+		c, srv, _, _ := newTestCLI(t)
+		srv.SetLatency(time.Hour)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		start := time.Now()
+		err := c.run(ctx, args)
+		cancel()
+		srv.Close()
+		if err == nil {
+			t.Errorf("%v: want non-nil error", args)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%v: took %v", args, elapsed)
+		}
+	}
+}
+
+func TestOrderErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args       []string
+		wantMsg    string
+		wantReport bool
+	}{
+		{args: []string{"order"}, wantMsg: flag.ErrHelp.Error()},
+		{args: []string{"order", "bogus"}, wantMsg: flag.ErrHelp.Error()},
+		{args: []string{"order", "place", "-yes", "-price", "1"}, wantMsg: "order type"},
+		{args: []string{"order", "place", "-yes", "-type", "buy", "-price", "x"}, wantMsg: "price"},
+		{args: []string{"order", "place", "-yes", "-type", "buy", "-price", "1", "-amount", "1", "-watch", "1s"}, wantMsg: "-watch"},
+		{args: []string{"order", "cancel", "-yes"}, wantMsg: "order ID"},
+		{args: []string{"order", "status", "abc"}, wantMsg: "malformed order ID"},
+		{
+			args:       []string{"order", "place", "-yes", "-type", "buy", "-price", "4000", "-amount", "100"},
+			wantMsg:    "error_code 10010",
+			wantReport: true,
+		},
+	}
+
+	for i, tt := range tests {
+		c, srv, stdout, _ := newTestCLI(t)
+		err := c.run(context.Background(), tt.args)
+		srv.Close()
+		if err == nil {
+			t.Errorf("#%d %v: want non-nil error", i, tt.args)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantMsg) {
+			t.Errorf("#%d %v: got=%v want it to mention %q", i, tt.args, err, tt.wantMsg)
+		}
+		if _, ok := err.(reportedError); ok != tt.wantReport {
+			t.Errorf("#%d %v: reported=%v want=%v", i, tt.args, ok, tt.wantReport)
+		}
+		if tt.wantReport && !strings.Contains(stdout.String(), "insufficient funds") {
+			t.Errorf("#%d %v: want the API error printed, got:\n%s", i, tt.args, stdout)
+		}
+	}
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ANSI escape sequences, as understood by every terminal emulator
+// in use, so that the dashboard needs no terminal library.
+const (
+	ansiAltScreen  = "\x1b[?1049h"
+	ansiMainScreen = "\x1b[?1049l"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiHome       = "\x1b[H"
+	ansiClearLine  = "\x1b[K"
+	ansiClearBelow = "\x1b[J"
+
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+)
+
+// isTerminal reports whether r is a character device like a terminal.
+func isTerminal(r interface{}) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// makeCbreak turns off the line buffering and echo of tty so that
+// keys are read as they are pressed, while Ctrl-C still interrupts.
+// The returned function restores the previous settings.
+func makeCbreak(tty *os.File) (restore func(), err error) {
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("saving the terminal settings: %v", err)
+	}
+	if _, err := stty(tty, "-icanon", "-echo", "min", "1"); err != nil {
+		return nil, fmt.Errorf("setting up the terminal: %v", err)
+	}
+	return func() { stty(tty, state) }, nil
+}
+
+// terminalSize returns the rows and columns of tty,
+// or 0, 0 if they cannot be determined.
+func terminalSize(tty *os.File) (rows, cols int) {
+	size, err := stty(tty, "size")
+	if err != nil {
+		return 0, 0
+	}
+	fmt.Sscanf(size, "%d %d", &rows, &cols)
+	return rows, cols
+}
+
+type key int
+
+const (
+	keyNone key = iota
+	keyQuit
+	keyRefresh
+	keyNext
+	keyPrev
+	// keyDigit1 to keyDigit1+8 jump to the 1st to 9th symbol.
+	keyDigit1
+)
+
+// readKeys sends the keys read from r until it fails, then closes keys.
+// Unbound keys are dropped.
+func readKeys(r io.Reader, keys chan<- key) {
+	defer close(keys)
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return
+		}
+		k := keyNone
+		switch {
+		case b == 'q' || b == 'Q' || b == 3: // 3 is Ctrl-C.
+			k = keyQuit
+		case b == 'r' || b == 'R':
+			k = keyRefresh
+		case b == 'l' || b == 'j' || b == '\t' || b == 'n':
+			k = keyNext
+		case b == 'h' || b == 'k' || b == 'p':
+			k = keyPrev
+		case b >= '1' && b <= '9':
+			k = keyDigit1 + key(b-'1')
+		case b == 0x1b:
+			// Arrow keys are sent as ESC [ A to ESC [ D. The byte after
+			// a lone ESC is a key of its own and is read again.
+			next, err := br.ReadByte()
+			if err != nil {
+				return
+			}
+			if next != '[' {
+				br.UnreadByte()
+				continue
+			}
+			arrow, err := br.ReadByte()
+			if err != nil {
+				return
+			}
+			switch arrow {
+			case 'B', 'C':
+				k = keyNext
+			case 'A', 'D':
+				k = keyPrev
+			}
+		}
+		if k != keyNone {
+			keys <- k
+		}
+	}
+}
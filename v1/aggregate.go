@@ -0,0 +1,216 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Bar is a candle stick built locally from trades.
+type Bar struct {
+	CandleStick
+
+	// VWAP is the volume weighted average price of the bar's trades.
+	VWAP       float64 `json:"vwap"`
+	TradeCount int     `json:"trade_count"`
+}
+
+// barJSON is the JSON form of a Bar. The candle stick's fields are
+// inlined, as an object unlike the arrays of kline.do, so Bar cannot
+// rely on the UnmarshalJSON that it would otherwise get from CandleStick.
+type barJSON struct {
+	TimeStampMs float64 `json:"timestamp,omitempty"`
+	Open        float64 `json:"open,omitempty"`
+	High        float64 `json:"high,omitempty"`
+	Low         float64 `json:"low,omitempty"`
+	Close       float64 `json:"close,omitempty"`
+	Volume      float64 `json:"volume,omitempty"`
+
+	VWAP       float64 `json:"vwap"`
+	TradeCount int     `json:"trade_count"`
+}
+
+func (b *Bar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&barJSON{
+		TimeStampMs: b.TimeStampMs,
+		Open:        b.Open,
+		High:        b.High,
+		Low:         b.Low,
+		Close:       b.Close,
+		Volume:      b.Volume,
+		VWAP:        b.VWAP,
+		TradeCount:  b.TradeCount,
+	})
+}
+
+func (b *Bar) UnmarshalJSON(data []byte) error {
+	recv := new(barJSON)
+	if err := json.Unmarshal(data, recv); err != nil {
+		return err
+	}
+	*b = Bar{
+		CandleStick: CandleStick{
+			TimeStampMs: recv.TimeStampMs,
+			Open:        recv.Open,
+			High:        recv.High,
+			Low:         recv.Low,
+			Close:       recv.Close,
+			Volume:      recv.Volume,
+		},
+		VWAP:       recv.VWAP,
+		TradeCount: recv.TradeCount,
+	}
+	return nil
+}
+
+// TradeAggregator builds Bars of an arbitrary period from a stream of
+// trades. Bars are aligned to multiples of the period since the zero
+// time in UTC, so for periods that divide a day they start at UTC midnight.
+type TradeAggregator struct {
+	period    time.Duration
+	fillEmpty bool
+
+	open     *Bar
+	notional float64
+}
+
+var (
+	errNonPositivePeriod = errors.New("expecting a positive period")
+	errNilTrade          = errors.New("expecting a non-nil trade")
+)
+
+// NewTradeAggregator returns an aggregator of trades into bars of period.
+// If fillEmpty is set, intervals without trades produce flat bars at the
+// previous close with no volume, otherwise they are skipped.
+func NewTradeAggregator(period time.Duration, fillEmpty bool) (*TradeAggregator, error) {
+	if period <= 0 {
+		return nil, errNonPositivePeriod
+	}
+	return &TradeAggregator{period: period, fillEmpty: fillEmpty}, nil
+}
+
+// Add folds trade into the open bar and returns the bars that it
+// completed, if any. Trades must be added in time order.
+func (ta *TradeAggregator) Add(trade *Trade) ([]*Bar, error) {
+	if trade == nil {
+		return nil, errNilTrade
+	}
+	t := trade.Time()
+	start := t.UTC().Truncate(ta.period)
+
+	var completed []*Bar
+	if ta.open != nil {
+		openStart := ta.open.Time()
+		switch {
+		case start.Before(openStart):
+			return nil, fmt.Errorf("trade %d at %v is before the open bar at %v", trade.ID, t, openStart)
+		case start.After(openStart):
+			prev := ta.Flush()
+			completed = append(completed, prev)
+			if ta.fillEmpty {
+				for gap := openStart.Add(ta.period); gap.Before(start); gap = gap.Add(ta.period) {
+					completed = append(completed, flatBar(gap, prev.Close))
+				}
+			}
+		}
+	}
+
+	if ta.open == nil {
+		ta.open = &Bar{CandleStick: CandleStick{
+			TimeStampMs: TimeToEpochMs(start),
+			Open:        trade.Price,
+			High:        trade.Price,
+			Low:         trade.Price,
+		}}
+		ta.notional = 0
+	}
+	bar := ta.open
+	if trade.Price > bar.High {
+		bar.High = trade.Price
+	}
+	if trade.Price < bar.Low {
+		bar.Low = trade.Price
+	}
+	bar.Close = trade.Price
+	bar.Volume += trade.Amount
+	bar.TradeCount++
+	ta.notional += trade.Price * trade.Amount
+	if bar.Volume > 0 {
+		// Clamp away the float rounding of single priced bars.
+		bar.VWAP = math.Max(bar.Low, math.Min(bar.High, ta.notional/bar.Volume))
+	}
+	return completed, nil
+}
+
+// Flush returns the open bar, possibly nil, and closes it.
+func (ta *TradeAggregator) Flush() *Bar {
+	bar := ta.open
+	ta.open = nil
+	ta.notional = 0
+	return bar
+}
+
+func flatBar(start time.Time, price float64) *Bar {
+	return &Bar{
+		CandleStick: CandleStick{
+			TimeStampMs: TimeToEpochMs(start),
+			Open:        price,
+			High:        price,
+			Low:         price,
+			Close:       price,
+		},
+		VWAP: price,
+	}
+}
+
+// AggregateTrades builds the bars of period for trades,
+// which are first sorted by time and then by ID.
+func AggregateTrades(trades []*Trade, period time.Duration, fillEmpty bool) ([]*Bar, error) {
+	ta, err := NewTradeAggregator(period, fillEmpty)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]*Trade, 0, len(trades))
+	for _, trade := range trades {
+		if trade != nil {
+			sorted = append(sorted, trade)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, tj := sorted[i].Time(), sorted[j].Time()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var bars []*Bar
+	for _, trade := range sorted {
+		completed, err := ta.Add(trade)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, completed...)
+	}
+	if last := ta.Flush(); last != nil {
+		bars = append(bars, last)
+	}
+	return bars, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func tradeAt(id int64, secs float64, price, amount float64) *okcoin.Trade {
+	return &okcoin.Trade{ID: id, DateMs: secs * 1e3, Price: price, Amount: amount}
+}
+
+func TestAggregateTrades(t *testing.T) {
+	t.Parallel()
+
+	// 10 second bars starting at 1503985010.
+	trades := []*okcoin.Trade{
+		tradeAt(3, 1503985015, 101, 1),
+		tradeAt(1, 1503985011, 100, 1),
+		tradeAt(2, 1503985012, 103, 2),
+		tradeAt(4, 1503985019, 99, 1),
+		// Nothing for [1503985020, 1503985040).
+		tradeAt(5, 1503985041, 98, 4),
+	}
+
+	bar := func(secs, open, high, low, close, volume, vwap float64, count int) *okcoin.Bar {
+		return &okcoin.Bar{
+			CandleStick: okcoin.CandleStick{
+				TimeStampMs: secs * 1e3,
+				Open:        open, High: high, Low: low, Close: close, Volume: volume,
+			},
+			VWAP:       vwap,
+			TradeCount: count,
+		}
+	}
+	firstBar := bar(1503985010, 100, 103, 99, 99, 5, (100+206+101+99)/5.0, 4)
+	lastBar := bar(1503985040, 98, 98, 98, 98, 4, 98, 1)
+
+	tests := [...]struct {
+		period    time.Duration
+		fillEmpty bool
+		want      []*okcoin.Bar
+		wantErr   bool
+	}{
+		0: {period: 0, wantErr: true},
+		1: {period: 10 * time.Second, want: []*okcoin.Bar{firstBar, lastBar}},
+		2: {
+			period: 10 * time.Second, fillEmpty: true,
+			want: []*okcoin.Bar{
+				firstBar,
+				bar(1503985020, 99, 99, 99, 99, 0, 99, 0),
+				bar(1503985030, 99, 99, 99, 99, 0, 99, 0),
+				lastBar,
+			},
+		},
+		3: {
+			period: 2 * time.Minute,
+			want:   []*okcoin.Bar{bar(1503984960, 100, 103, 98, 98, 9, (100+206+101+99+392)/9.0, 5)},
+		},
+	}
+
+	for i, tt := range tests {
+		bars, err := okcoin.AggregateTrades(trades, tt.period, tt.fillEmpty)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: got err: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(bars, tt.want) {
+			blob, _ := json.Marshal(bars)
+			t.Errorf("#%d: got=%s", i, blob)
+		}
+	}
+}
+
+func TestTradeAggregatorOutOfOrder(t *testing.T) {
+	ta, err := okcoin.NewTradeAggregator(time.Minute, false)
+	if err != nil {
+		t.Fatalf("new aggregator: %v", err)
+	}
+	if _, err := ta.Add(tradeAt(2, 1503985100, 100, 1)); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := ta.Add(tradeAt(1, 1503985000, 100, 1)); err == nil {
+		t.Errorf("expected an error for a trade before the open bar")
+	}
+	if _, err := ta.Add(nil); err == nil {
+		t.Errorf("expected an error for a nil trade")
+	}
+}
+
+func TestAggregateTradesFromTape(t *testing.T) {
+	blob, err := ioutil.ReadFile("./testdata/trades-btc_usd.json")
+	if err != nil {
+		t.Fatalf("read trades: %v", err)
+	}
+	var trades []*okcoin.Trade
+	if err := json.Unmarshal(blob, &trades); err != nil {
+		t.Fatalf("unmarshal trades: %v", err)
+	}
+	bars, err := okcoin.AggregateTrades(trades, 2*time.Minute, false)
+	if err != nil {
+		t.Fatalf("aggregate: %v", err)
+	}
+
+	var volume float64
+	count := 0
+	for i, bar := range bars {
+		if bar.Low > bar.VWAP || bar.VWAP > bar.High {
+			t.Errorf("#%d: VWAP %f outside [%f, %f]", i, bar.VWAP, bar.Low, bar.High)
+		}
+		if i > 0 && bar.TimeStampMs <= bars[i-1].TimeStampMs {
+			t.Errorf("#%d: bars out of order", i)
+		}
+		volume += bar.Volume
+		count += bar.TradeCount
+	}
+	var wantVolume float64
+	for _, trade := range trades {
+		wantVolume += trade.Amount
+	}
+	if g, w := count, len(trades); g != w {
+		t.Errorf("trade count: got=%d want=%d", g, w)
+	}
+	if math.Abs(volume-wantVolume) > 1e-9 {
+		t.Errorf("volume: got=%f want=%f", volume, wantVolume)
+	}
+}
+
+func TestBarJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	bars, err := okcoin.AggregateTrades([]*okcoin.Trade{
+		tradeAt(1, 1503960000, 100, 1),
+		tradeAt(2, 1503960030, 110, 2),
+		tradeAt(3, 1503960070, 105, 0.5),
+	}, time.Minute, false)
+	if err != nil {
+		t.Fatalf("aggregate: %v", err)
+	}
+	blob, err := json.Marshal(bars)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var recv []*okcoin.Bar
+	if err := json.Unmarshal(blob, &recv); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, blob)
+	}
+	if !reflect.DeepEqual(recv, bars) {
+		t.Errorf("roundtrip:\ngot= %s\nwant=%s", mustJSON(t, recv), blob)
+	}
+
+	var bar okcoin.Bar
+	in := `{"timestamp":1503960060000,"open":105,"high":105,"low":105,"close":105,"volume":0.5,"vwap":105,"trade_count":1}`
+	if err := json.Unmarshal([]byte(in), &bar); err != nil {
+		t.Fatalf("unmarshal bar: %v", err)
+	}
+	if bar.Close != 105 || bar.TradeCount != 1 || !bar.Time().Equal(time.Unix(1503960060, 0)) {
+		t.Errorf("bar: got=%+v", bar)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return blob
+}
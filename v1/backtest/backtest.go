@@ -0,0 +1,395 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backtest replays historical candle sticks or trades of a
+// symbol through a Strategy and simulates the fills of its orders.
+//
+// Orders placed while handling a tick reach the simulated exchange after
+// the delay of the latency model and are matched against the first tick
+// at or after that time, never against the tick that prompted them.
+// Market orders fill at that tick's open. Limit orders fill at the open
+// if it is already through their price, paying the taker fee, or else at
+// their price once a tick trades through it, paying the maker fee.
+package backtest
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/internal/numeric"
+	"github.com/orijtech/okcoin/v1/pnl"
+)
+
+// Tick is one step of a replay. Ticks built from
+// trades have the same open, high, low and close.
+type Tick struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+
+	// CandleStick or Trade is the source of the tick.
+	CandleStick *okcoin.CandleStick `json:"candle_stick,omitempty"`
+	Trade       *okcoin.Trade       `json:"trade,omitempty"`
+}
+
+var errNilSource = errors.New("expecting non-nil candle sticks and trades")
+
+// FromCandleSticks returns a tick per candle stick, in chronological order.
+func FromCandleSticks(csticks []*okcoin.CandleStick) ([]*Tick, error) {
+	ticks := make([]*Tick, 0, len(csticks))
+	for _, cs := range csticks {
+		if cs == nil {
+			return nil, errNilSource
+		}
+		ticks = append(ticks, &Tick{
+			Time: cs.Time(), Open: cs.Open, High: cs.High, Low: cs.Low, Close: cs.Close,
+			Volume: cs.Volume, CandleStick: cs,
+		})
+	}
+	sortTicks(ticks)
+	return ticks, nil
+}
+
+// FromTrades returns a tick per trade, in chronological order.
+func FromTrades(trades []*okcoin.Trade) ([]*Tick, error) {
+	ticks := make([]*Tick, 0, len(trades))
+	for _, trade := range trades {
+		if trade == nil {
+			return nil, errNilSource
+		}
+		p := trade.Price
+		ticks = append(ticks, &Tick{
+			Time: trade.Time(), Open: p, High: p, Low: p, Close: p,
+			Volume: trade.Amount, Trade: trade,
+		})
+	}
+	sortTicks(ticks)
+	return ticks, nil
+}
+
+func sortTicks(ticks []*Tick) {
+	sort.SliceStable(ticks, func(i, j int) bool { return ticks[i].Time.Before(ticks[j].Time) })
+}
+
+// Order is an order of a Strategy. Like on the exchange, the
+// Price of a BuyMarket order is the amount of quote to spend.
+type Order struct {
+	ID     int64            `json:"id"`
+	Type   okcoin.OrderType `json:"type"`
+	Price  float64          `json:"price"`
+	Amount float64          `json:"amount"`
+
+	Status    okcoin.OrderStatus `json:"status"`
+	Submitted time.Time          `json:"submitted"`
+	// Arrives is when the order reaches the exchange.
+	Arrives time.Time `json:"arrives"`
+	// Rejected is set for the orders that were
+	// cancelled for lack of funds when matched.
+	Rejected bool `json:"rejected,omitempty"`
+}
+
+func (o *Order) buying() bool {
+	return o.Type == okcoin.Buy || o.Type == okcoin.BuyMarket
+}
+
+// Fill is an entry of the trade log.
+type Fill struct {
+	OrderID int64            `json:"order_id"`
+	Type    okcoin.OrderType `json:"type"`
+	Time    time.Time        `json:"time"`
+	Price   float64          `json:"price"`
+	Amount  float64          `json:"amount"`
+	Fee     float64          `json:"fee"`
+	Maker   bool             `json:"maker"`
+}
+
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Strategy is handed every tick in turn along with the account
+// through which it places and cancels orders.
+type Strategy interface {
+	OnTick(tick *Tick, acct *Account)
+}
+
+// StrategyFunc adapts a function into a Strategy.
+type StrategyFunc func(tick *Tick, acct *Account)
+
+var _ Strategy = StrategyFunc(nil)
+
+func (sf StrategyFunc) OnTick(tick *Tick, acct *Account) { sf(tick, acct) }
+
+type Config struct {
+	Symbol   okcoin.Symbol
+	Strategy Strategy
+
+	// Cash is the initial balance of the quote currency.
+	Cash float64
+
+	// Fee and Latency default to no fees and no delay.
+	Fee     FeeModel
+	Latency LatencyModel
+
+	// PeriodsPerYear annualizes the Sharpe ratio, e.g. 365
+	// for daily candle sticks. Zero leaves it per tick.
+	PeriodsPerYear float64
+}
+
+// Account is the view a Strategy has of the simulation.
+type Account struct {
+	cfg *Config
+
+	cash     float64
+	position float64
+	last     float64
+	now      time.Time
+
+	nextID int64
+	orders []*Order
+}
+
+func (a *Account) Cash() float64     { return a.cash }
+func (a *Account) Position() float64 { return a.position }
+
+// Equity is the cash plus the position valued at the last close.
+func (a *Account) Equity() float64 { return a.cash + a.position*a.last }
+
+// Submit places an order, returning its ID. Orders are checked
+// for funds only once they reach the exchange.
+func (a *Account) Submit(typ okcoin.OrderType, price, amount float64) int64 {
+	a.nextID++
+	o := &Order{
+		ID:        a.nextID,
+		Type:      typ,
+		Price:     price,
+		Amount:    amount,
+		Status:    okcoin.StatusUnfilled,
+		Submitted: a.now,
+	}
+	o.Arrives = a.now
+	if a.cfg.Latency != nil {
+		o.Arrives = o.Arrives.Add(a.cfg.Latency.Latency(o))
+	}
+	a.orders = append(a.orders, o)
+	return o.ID
+}
+
+// Cancel cancels an open order, reporting whether it was open.
+func (a *Account) Cancel(orderID int64) bool {
+	for _, o := range a.orders {
+		if o.ID == orderID && o.Status == okcoin.StatusUnfilled {
+			o.Status = okcoin.StatusCancelled
+			return true
+		}
+	}
+	return false
+}
+
+// OpenOrders returns copies of the orders yet to fill.
+func (a *Account) OpenOrders() []*Order {
+	var open []*Order
+	for _, o := range a.orders {
+		if o.Status == okcoin.StatusUnfilled {
+			copied := *o
+			open = append(open, &copied)
+		}
+	}
+	return open
+}
+
+type Result struct {
+	Equity []*EquityPoint `json:"equity"`
+	Fills  []*Fill        `json:"fills"`
+	Orders []*Order       `json:"orders"`
+
+	StartEquity float64 `json:"start_equity"`
+	EndEquity   float64 `json:"end_equity"`
+	// Return is the relative change from StartEquity to EndEquity.
+	Return      float64 `json:"return"`
+	MaxDrawdown float64 `json:"max_drawdown"`
+	Sharpe      float64 `json:"sharpe"`
+	Fees        float64 `json:"fees"`
+
+	// RoundTrips counts the sells, each closing part of the position at
+	// its average cost; WinRate is the fraction of them that profited.
+	RoundTrips int     `json:"round_trips"`
+	WinRate    float64 `json:"win_rate"`
+}
+
+var (
+	errNilStrategy = errors.New("expecting a non-nil strategy")
+	errNoTicks     = errors.New("expecting at least one tick")
+)
+
+// Run replays ticks, which must be in chronological order.
+func Run(cfg *Config, ticks []*Tick) (*Result, error) {
+	if cfg == nil || cfg.Strategy == nil {
+		return nil, errNilStrategy
+	}
+	if len(ticks) == 0 {
+		return nil, errNoTicks
+	}
+	symbol := cfg.Symbol
+	if symbol == "" {
+		symbol = okcoin.BTCUSD
+	}
+	book, err := pnl.NewBook(pnl.AverageCost)
+	if err != nil {
+		return nil, err
+	}
+
+	acct := &Account{cfg: cfg, cash: cfg.Cash}
+	res := new(Result)
+	for _, tick := range ticks {
+		acct.now = tick.Time
+		for _, o := range acct.orders {
+			if o.Status != okcoin.StatusUnfilled || o.Arrives.After(tick.Time) {
+				continue
+			}
+			fill := acct.match(o, tick)
+			if fill == nil {
+				continue
+			}
+			res.Fills = append(res.Fills, fill)
+			res.Fees += fill.Fee
+			side, amount := pnl.Buy, okcoin.NewDecimalFromFloat(fill.Amount)
+			if !o.buying() {
+				side = pnl.Sell
+				// The account's float position may stray from the
+				// book's exact lots by dust, which must not oversell.
+				if held := heldAmount(book, symbol); acct.position == 0 || amount.Cmp(held) > 0 {
+					amount = held
+				}
+			}
+			err := book.Add(&pnl.Fill{
+				Symbol: symbol, Side: side,
+				Price:  okcoin.NewDecimalFromFloat(fill.Price),
+				Amount: amount,
+				Fee:    okcoin.NewDecimalFromFloat(fill.Fee),
+				Time:   fill.Time,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		acct.last = tick.Close
+		res.Equity = append(res.Equity, &EquityPoint{Time: tick.Time, Equity: acct.Equity()})
+		cfg.Strategy.OnTick(tick, acct)
+	}
+
+	res.Orders = acct.orders
+	res.StartEquity = cfg.Cash
+	res.EndEquity = res.Equity[len(res.Equity)-1].Equity
+	if res.StartEquity != 0 {
+		res.Return = res.EndEquity/res.StartEquity - 1
+	}
+	res.MaxDrawdown = MaxDrawdown(res.Equity)
+	res.Sharpe = Sharpe(res.Equity, cfg.PeriodsPerYear)
+
+	var wins int
+	for _, r := range book.Realizations() {
+		res.RoundTrips++
+		if r.PnL.Sign() > 0 {
+			wins++
+		}
+	}
+	if res.RoundTrips > 0 {
+		res.WinRate = float64(wins) / float64(res.RoundTrips)
+	}
+	return res, nil
+}
+
+func heldAmount(book *pnl.Book, symbol okcoin.Symbol) okcoin.Decimal {
+	var held okcoin.Decimal
+	for _, lot := range book.Lots(symbol) {
+		held = held.Add(lot.Amount)
+	}
+	return held
+}
+
+// match fills o in full against tick if it trades through its price
+// and the account can afford it, returning nil if o did not fill.
+func (a *Account) match(o *Order, tick *Tick) *Fill {
+	price, maker := tick.Open, false
+	switch o.Type {
+	case okcoin.Buy:
+		if tick.Open > o.Price {
+			if tick.Low > o.Price {
+				return nil
+			}
+			price, maker = o.Price, true
+		}
+	case okcoin.Sell:
+		if tick.Open < o.Price {
+			if tick.High < o.Price {
+				return nil
+			}
+			price, maker = o.Price, true
+		}
+	case okcoin.BuyMarket, okcoin.SellMarket:
+	default:
+		o.Status, o.Rejected = okcoin.StatusCancelled, true
+		return nil
+	}
+	if price <= 0 {
+		return nil
+	}
+
+	fee := func(notional float64) float64 {
+		if a.cfg.Fee == nil {
+			return 0
+		}
+		return a.cfg.Fee.Fee(notional, maker)
+	}
+	fill := &Fill{OrderID: o.ID, Type: o.Type, Time: tick.Time, Price: price, Maker: maker}
+	if o.Type == okcoin.BuyMarket {
+		// The fee comes out of the amount to spend.
+		spend := o.Price
+		fill.Fee = fee(spend)
+		fill.Amount = (spend - fill.Fee) / price
+	} else {
+		fill.Amount = o.Amount
+		fill.Fee = fee(price * o.Amount)
+	}
+
+	notional := price * fill.Amount
+	switch {
+	case fill.Amount <= 0,
+		o.buying() && notional+fill.Fee > a.cash+numeric.Dust,
+		!o.buying() && fill.Amount > a.position+numeric.Dust:
+		o.Status, o.Rejected = okcoin.StatusCancelled, true
+		return nil
+	}
+	if o.buying() {
+		a.cash -= notional + fill.Fee
+		a.position += fill.Amount
+	} else {
+		a.cash += notional - fill.Fee
+		a.position -= fill.Amount
+	}
+	if math.Abs(a.position) <= numeric.Dust {
+		a.position = 0
+	}
+	o.Status = okcoin.StatusFilled
+	return fill
+}
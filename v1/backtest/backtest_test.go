@@ -0,0 +1,220 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backtest_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/backtest"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+var t0 = time.Date(2017, 8, 28, 0, 0, 0, 0, time.UTC)
+
+// hourly returns hourly candle sticks from quadruples of open, high, low and close.
+func hourly(values ...float64) []*okcoin.CandleStick {
+	var csticks []*okcoin.CandleStick
+	for i := 0; i+3 < len(values); i += 4 {
+		csticks = append(csticks, &okcoin.CandleStick{
+			TimeStampMs: okcoin.TimeToEpochMs(t0.Add(time.Duration(i/4) * time.Hour)),
+			Open:        values[i],
+			High:        values[i+1],
+			Low:         values[i+2],
+			Close:       values[i+3],
+		})
+	}
+	return csticks
+}
+
+var series = hourly(
+	100, 100, 100, 100,
+	110, 120, 110, 120,
+	120, 125, 85, 90,
+	95, 100, 95, 100,
+)
+
+// scripted places the orders given for the tick of each index.
+func scripted(script map[int]func(*backtest.Account)) backtest.Strategy {
+	i := 0
+	return backtest.StrategyFunc(func(tick *backtest.Tick, acct *backtest.Account) {
+		if fn := script[i]; fn != nil {
+			fn(acct)
+		}
+		i++
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	ticks, err := backtest.FromCandleSticks(series)
+	if err != nil {
+		t.Fatalf("ticks: %v", err)
+	}
+	res, err := backtest.Run(&backtest.Config{
+		Strategy: scripted(map[int]func(*backtest.Account){
+			0: func(acct *backtest.Account) { acct.Submit(okcoin.Buy, 1000, 1) },
+			1: func(acct *backtest.Account) { acct.Submit(okcoin.Sell, 124, 1) },
+		}),
+		Cash: 1000,
+		Fee:  &backtest.PercentFee{Maker: 0.001, Taker: 0.002},
+	}, ticks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if g, w := len(res.Fills), 2; g != w {
+		t.Fatalf("fills: got=%d want=%d", g, w)
+	}
+	// The buy crosses at the next open, the sell rests until the high reaches it.
+	buy, sell := res.Fills[0], res.Fills[1]
+	if buy.Price != 110 || buy.Maker || !approx(buy.Fee, 0.22) {
+		t.Errorf("buy: got=%+v", buy)
+	}
+	if sell.Price != 124 || !sell.Maker || !approx(sell.Fee, 0.124) {
+		t.Errorf("sell: got=%+v", sell)
+	}
+
+	wantEquity := []float64{1000, 1009.78, 1013.656, 1013.656}
+	for i, w := range wantEquity {
+		if g := res.Equity[i].Equity; !approx(g, w) {
+			t.Errorf("equity #%d: got=%v want=%v", i, g, w)
+		}
+	}
+	if !approx(res.Return, 0.013656) || res.MaxDrawdown != 0 || !approx(res.Fees, 0.344) {
+		t.Errorf("summary: got return=%v drawdown=%v fees=%v", res.Return, res.MaxDrawdown, res.Fees)
+	}
+	if res.RoundTrips != 1 || res.WinRate != 1 {
+		t.Errorf("round trips: got=%d win rate=%v want 1 won", res.RoundTrips, res.WinRate)
+	}
+}
+
+func TestLatencyAndRejections(t *testing.T) {
+	t.Parallel()
+
+	ticks, err := backtest.FromCandleSticks(series)
+	if err != nil {
+		t.Fatalf("ticks: %v", err)
+	}
+	res, err := backtest.Run(&backtest.Config{
+		Strategy: scripted(map[int]func(*backtest.Account){
+			0: func(acct *backtest.Account) {
+				acct.Submit(okcoin.BuyMarket, 240, 0)
+				acct.Submit(okcoin.Buy, 1000, 100)
+				acct.Submit(okcoin.SellMarket, 0, 5)
+			},
+		}),
+		Cash:    1000,
+		Latency: backtest.FixedLatency(90 * time.Minute),
+	}, ticks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// Delayed past the second tick, the buy fills at the third's open.
+	if len(res.Fills) != 1 || res.Fills[0].Price != 120 || !approx(res.Fills[0].Amount, 2) {
+		t.Fatalf("fills: got=%+v", res.Fills)
+	}
+	// Neither the unaffordable buy nor the oversized sell went through.
+	for _, o := range res.Orders[1:] {
+		if !o.Rejected || o.Status != okcoin.StatusCancelled {
+			t.Errorf("order #%d: got=%+v want rejected", o.ID, o)
+		}
+	}
+	if g, w := res.MaxDrawdown, (1000-(760+2*90.0))/1000; !approx(g, w) {
+		t.Errorf("max drawdown: got=%v want=%v", g, w)
+	}
+}
+
+func TestRunSellsDustyPosition(t *testing.T) {
+	t.Parallel()
+
+	ticks, err := backtest.FromCandleSticks(series)
+	if err != nil {
+		t.Fatalf("ticks: %v", err)
+	}
+	// 0.1+0.2 is 0.30000000000000004 in float64, more than the book's
+	// exact lots of 0.1 and 0.2 hold, yet selling it all must go through.
+	res, err := backtest.Run(&backtest.Config{
+		Strategy: scripted(map[int]func(*backtest.Account){
+			0: func(acct *backtest.Account) {
+				acct.Submit(okcoin.Buy, 1000, 0.1)
+				acct.Submit(okcoin.Buy, 1000, 0.2)
+			},
+			1: func(acct *backtest.Account) { acct.Submit(okcoin.SellMarket, 0, acct.Position()) },
+		}),
+		Cash: 1000,
+	}, ticks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if g, w := len(res.Fills), 3; g != w {
+		t.Fatalf("fills: got=%d want=%d", g, w)
+	}
+	// The book pools lots at their average cost.
+	if res.RoundTrips != 1 || res.WinRate != 1 {
+		t.Errorf("round trips: got=%d win rate=%v want 1 won", res.RoundTrips, res.WinRate)
+	}
+}
+
+func TestFromTrades(t *testing.T) {
+	t.Parallel()
+
+	ticks, err := backtest.FromTrades([]*okcoin.Trade{
+		{ID: 2, Price: 4600, Amount: 1, DateMs: 1503960001000},
+		{ID: 1, Price: 4590, Amount: 2, DateMs: 1503960000000},
+	})
+	if err != nil {
+		t.Fatalf("ticks: %v", err)
+	}
+	if len(ticks) != 2 || ticks[0].Trade.ID != 1 || ticks[0].Open != 4590 || ticks[0].Low != 4590 || ticks[0].Volume != 2 {
+		t.Errorf("ticks: got=%+v, %+v", ticks[0], ticks[1])
+	}
+	if _, err := backtest.FromTrades([]*okcoin.Trade{nil}); err == nil {
+		t.Errorf("nil trade: want non-nil error")
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	curve := func(equities ...float64) []*backtest.EquityPoint {
+		var pts []*backtest.EquityPoint
+		for _, e := range equities {
+			pts = append(pts, &backtest.EquityPoint{Equity: e})
+		}
+		return pts
+	}
+
+	if g, w := backtest.MaxDrawdown(curve(100, 120, 90, 130, 110)), 0.25; !approx(g, w) {
+		t.Errorf("max drawdown: got=%v want=%v", g, w)
+	}
+	// Steady growth has no deviation to divide by.
+	if g := backtest.Sharpe(curve(100, 110, 121), 0); g != 0 {
+		t.Errorf("steady sharpe: got=%v want=0", g)
+	}
+	// Returns of 10%, -10% and 10%: mean 1/30, stddev sqrt(0.04/3).
+	perTick := backtest.Sharpe(curve(100, 110, 99, 108.9), 0)
+	if w := (1.0 / 30) / math.Sqrt(0.04/3); !approx(perTick, w) {
+		t.Errorf("sharpe: got=%v want=%v", perTick, w)
+	}
+	if g, w := backtest.Sharpe(curve(100, 110, 99, 108.9), 365), perTick*math.Sqrt(365); !approx(g, w) {
+		t.Errorf("annualized sharpe: got=%v want=%v", g, w)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backtest
+
+import "time"
+
+// FeeModel returns the fee, in the quote currency, of a fill
+// of the given notional value. maker is set for fills of
+// limit orders that rested before being matched.
+type FeeModel interface {
+	Fee(notional float64, maker bool) float64
+}
+
+// PercentFee charges a fraction of the notional value.
+type PercentFee struct {
+	Maker float64
+	Taker float64
+}
+
+var _ FeeModel = (*PercentFee)(nil)
+
+func (pf *PercentFee) Fee(notional float64, maker bool) float64 {
+	if maker {
+		return notional * pf.Maker
+	}
+	return notional * pf.Taker
+}
+
+// LatencyModel returns how long after its submission
+// an order reaches the simulated exchange.
+type LatencyModel interface {
+	Latency(o *Order) time.Duration
+}
+
+// FixedLatency delays every order by the same duration.
+type FixedLatency time.Duration
+
+var _ LatencyModel = FixedLatency(0)
+
+func (fl FixedLatency) Latency(*Order) time.Duration { return time.Duration(fl) }
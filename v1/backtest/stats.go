@@ -0,0 +1,67 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backtest
+
+import "math"
+
+// MaxDrawdown returns the largest fall of equity from
+// a previous peak, as a fraction of that peak.
+func MaxDrawdown(curve []*EquityPoint) float64 {
+	var peak, maxDD float64
+	for _, pt := range curve {
+		if pt.Equity > peak {
+			peak = pt.Equity
+		}
+		if peak > 0 {
+			maxDD = math.Max(maxDD, (peak-pt.Equity)/peak)
+		}
+	}
+	return maxDD
+}
+
+// Sharpe returns the mean of the returns between consecutive points of
+// the curve over their standard deviation, scaled by the square root of
+// periodsPerYear if positive. The risk-free rate is taken as 0.
+func Sharpe(curve []*EquityPoint, periodsPerYear float64) float64 {
+	var returns []float64
+	for i := 1; i < len(curve); i++ {
+		if prev := curve[i-1].Equity; prev != 0 {
+			returns = append(returns, curve[i].Equity/prev-1)
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var sum float64
+	for _, r := range returns {
+		sum += r
+	}
+	mean := sum / float64(len(returns))
+	var sqDiffs float64
+	for _, r := range returns {
+		sqDiffs += (r - mean) * (r - mean)
+	}
+	stddev := math.Sqrt(sqDiffs / float64(len(returns)-1))
+	if stddev == 0 {
+		return 0
+	}
+
+	sharpe := mean / stddev
+	if periodsPerYear > 0 {
+		sharpe *= math.Sqrt(periodsPerYear)
+	}
+	return sharpe
+}
@@ -15,11 +15,12 @@
 package okcoin
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"reflect"
+	"strconv"
 )
 
 type Funds struct {
@@ -33,10 +34,86 @@ type Funds struct {
 type Fund struct {
 	Net   float64 `json:"net,string,omitempty"`
 	Total float64 `json:"total,string,omitempty"`
-	BTC   float64 `json:"btc,string,omitempty"`
-	ETH   float64 `json:"eth,string,omitempty"`
-	LTC   float64 `json:"ltc,string,omitempty"`
-	USD   float64 `json:"usd,string,omitempty"`
+
+	// Balances holds the amount of every currency reported,
+	// including those that this package has no constant for.
+	Balances map[Currency]float64 `json:"-"`
+
+	// raw is the JSON that the fund was decoded from, if any.
+	raw json.RawMessage
+}
+
+// Balance returns the amount held of currency c.
+func (f *Fund) Balance(c Currency) float64 {
+	if f == nil {
+		return 0
+	}
+	return f.Balances[c]
+}
+
+// BalanceDecimal returns the amount of currency c sent by the exchange,
+// read from the fund's JSON, or converts the Balance of funds that were
+// not decoded from JSON.
+func (f *Fund) BalanceDecimal(c Currency) Decimal {
+	if f == nil {
+		return Decimal{}
+	}
+	return objectDecimal(f.raw, string(c), f.Balances[c])
+}
+
+// NetDecimal is like BalanceDecimal for Net.
+func (f *Fund) NetDecimal() Decimal { return objectDecimal(f.raw, fundNetKey, f.Net) }
+
+// TotalDecimal is like BalanceDecimal for Total.
+func (f *Fund) TotalDecimal() Decimal { return objectDecimal(f.raw, fundTotalKey, f.Total) }
+
+const (
+	fundNetKey   = "net"
+	fundTotalKey = "total"
+)
+
+func (f *Fund) UnmarshalJSON(b []byte) error {
+	// Expecting a datum of the form:
+	// {"btc":"0.01","bcc":"0","usd":"0", ...}
+	// where "net" and "total" are only set for the "asset" fund.
+	recv := make(map[string]string)
+	if err := json.Unmarshal(b, &recv); err != nil {
+		return err
+	}
+	fund := Fund{raw: append(json.RawMessage(nil), b...)}
+	for key, str := range recv {
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return fmt.Errorf("%q: %v", key, err)
+		}
+		switch key {
+		case fundNetKey:
+			fund.Net = value
+		case fundTotalKey:
+			fund.Total = value
+		default:
+			if fund.Balances == nil {
+				fund.Balances = make(map[Currency]float64)
+			}
+			fund.Balances[Currency(key)] = value
+		}
+	}
+	*f = fund
+	return nil
+}
+
+func (f *Fund) MarshalJSON() ([]byte, error) {
+	send := make(map[string]string, len(f.Balances)+2)
+	for currency, value := range f.Balances {
+		send[string(currency)] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	if f.Net != 0 {
+		send[fundNetKey] = strconv.FormatFloat(f.Net, 'f', -1, 64)
+	}
+	if f.Total != 0 {
+		send[fundTotalKey] = strconv.FormatFloat(f.Total, 'f', -1, 64)
+	}
+	return json.Marshal(send)
 }
 
 type fundsIntermediate struct {
@@ -48,24 +125,20 @@ var errNoFundsReturned = errors.New("no funds information returned")
 var blankFunds = new(Funds)
 
 func (c *Client) Funds() (*Funds, error) {
-	fullURL := fmt.Sprintf("%s/userinfo.do", baseURL)
-	req, err := http.NewRequest("POST", fullURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	qv := req.URL.Query()
-	qv.Set("api_key", c.apiKey())
-	qv, err = c.prepareSignedAuthBody(qv)
+	return c.FundsContext(context.Background())
+}
+
+// FundsContext is like Funds but gives up once ctx is done.
+func (c *Client) FundsContext(ctx context.Context) (*Funds, error) {
+	res, err := c.call(ctx, &Call{Endpoint: "userinfo.do", Signed: true})
 	if err != nil {
 		return nil, err
 	}
-	req.URL.RawQuery = qv.Encode()
-	blob, _, err := c.doHTTPReq(req)
-	if err != nil {
-		return nil, err
+	if ae, ok := resultError(res.Body).(*APIError); ok {
+		return nil, ae
 	}
 	fi := new(fundsIntermediate)
-	if err := json.Unmarshal(blob, fi); err != nil {
+	if err := json.Unmarshal(res.Body, fi); err != nil {
 		return nil, err
 	}
 	if !fi.Result || len(fi.Funds) == 0 {
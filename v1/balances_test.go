@@ -72,6 +72,51 @@ func TestFunds(t *testing.T) {
 	}
 }
 
+func TestFundKeepsAllCurrencies(t *testing.T) {
+	funds := fundsFromFile("funds1.json")
+	if funds == nil {
+		t.Fatal("failed to load funds1.json")
+	}
+
+	wantFree := map[okcoin.Currency]float64{
+		okcoin.BTC: 0.01, okcoin.BCC: 0, okcoin.ETC: 0,
+		okcoin.USD: 0, okcoin.ETH: 0, okcoin.LTC: 0,
+	}
+	if g, w := funds.Free.Balances, wantFree; !reflect.DeepEqual(g, w) {
+		t.Errorf("free:\ngot= %v\nwant=%v", g, w)
+	}
+	if g, w := funds.Frozen.Balance(okcoin.ETH), 20.0; g != w {
+		t.Errorf("frozen eth: got=%v want=%v", g, w)
+	}
+	if g, w := funds.Asset.Net, 948.6823; g != w {
+		t.Errorf("asset net: got=%v want=%v", g, w)
+	}
+	if g := funds.UnionFund.Balance(okcoin.BTC); g != 0 {
+		t.Errorf("nil fund balance: got=%v want=0", g)
+	}
+
+	// Encoding then decoding should be lossless.
+	blob, err := json.Marshal(funds)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	recv := new(okcoin.Funds)
+	if err := json.Unmarshal(blob, recv); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for name, pair := range map[string][2]*okcoin.Fund{
+		"asset":  {recv.Asset, funds.Asset},
+		"borrow": {recv.Borrow, funds.Borrow},
+		"free":   {recv.Free, funds.Free},
+		"frozen": {recv.Frozen, funds.Frozen},
+	} {
+		g, w := pair[0], pair[1]
+		if !reflect.DeepEqual(g.Balances, w.Balances) || g.Net != w.Net || g.Total != w.Total {
+			t.Errorf("roundtrip %s:\ngot= %+v\nwant=%+v", name, g, w)
+		}
+	}
+}
+
 var knownAPIKeyToSecrets = map[string]string{
 	apiKey1: apiSecret1,
 }
@@ -15,9 +15,12 @@
 package okcoin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/orijtech/otils"
 )
@@ -25,9 +28,13 @@ import (
 type CandleStickRequest struct {
 	N int `json:"n,omitempty"`
 
+	// Since is an epoch in either seconds or milliseconds.
 	Since  float64 `json:"since,omitempty"`
 	Symbol Symbol  `json:"sym,omitempty"`
 
+	// SinceTime if set takes precedence over Since.
+	SinceTime time.Time `json:"-"`
+
 	Period Period `json:"period,omitempty"`
 }
 
@@ -49,16 +56,42 @@ const (
 	P12Hour Period = "12hour"
 )
 
+var periodDurations = map[Period]time.Duration{
+	P1Min:   1 * time.Minute,
+	P3Min:   3 * time.Minute,
+	P5Min:   5 * time.Minute,
+	P15Min:  15 * time.Minute,
+	P30Min:  30 * time.Minute,
+	P1Hour:  1 * time.Hour,
+	P2Hour:  2 * time.Hour,
+	P4Hour:  4 * time.Hour,
+	P6Hour:  6 * time.Hour,
+	P12Hour: 12 * time.Hour,
+	P1Day:   24 * time.Hour,
+	P3Day:   3 * 24 * time.Hour,
+	P1Week:  7 * 24 * time.Hour,
+}
+
+// Duration returns the length of the period, or 0 if the period is unknown.
+func (p Period) Duration() time.Duration {
+	return periodDurations[p]
+}
+
 type candleStickRequest struct {
 	Symbol Symbol  `json:"symbol,omitempty"`
 	Period Period  `json:"type,omitempty"`
-	Since  float64 `json:"since,omitempty"`
+	Since  float64 `json:"-"`
 	N      int     `json:"size,omitempty"`
 }
 
 const defaultPeriod = P1Hour
 
 func (c *Client) CandleStick(csr *CandleStickRequest) (*CandleStickResponse, error) {
+	return c.CandleStickContext(context.Background(), csr)
+}
+
+// CandleStickContext is like CandleStick but gives up once ctx is done.
+func (c *Client) CandleStickContext(ctx context.Context, csr *CandleStickRequest) (*CandleStickResponse, error) {
 	if csr == nil {
 		csr = new(CandleStickRequest)
 	}
@@ -72,8 +105,12 @@ func (c *Client) CandleStick(csr *CandleStickRequest) (*CandleStickResponse, err
 	}
 
 	since := float64(0)
-	if csr.Since <= 0 {
-		since = 0
+	if csr.Since > 0 {
+		// kline.do expects milliseconds.
+		since = TimeToEpochMs(EpochToTime(csr.Since))
+	}
+	if !csr.SinceTime.IsZero() {
+		since = TimeToEpochMs(csr.SinceTime)
 	}
 
 	creq := &candleStickRequest{
@@ -86,20 +123,19 @@ func (c *Client) CandleStick(csr *CandleStickRequest) (*CandleStickResponse, err
 	if err != nil {
 		return nil, err
 	}
-	fullURL := fmt.Sprintf("%s/kline.do", baseURL)
-	if len(qv) > 0 {
-		fullURL = fmt.Sprintf("%s?%s", fullURL, qv.Encode())
+	if creq.Since > 0 {
+		// Formatted by hand lest the milliseconds be rendered as 1.5e+12.
+		if qv == nil {
+			qv = make(url.Values)
+		}
+		qv.Set("since", strconv.FormatFloat(creq.Since, 'f', 0, 64))
 	}
-	req, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	blob, _, err := c.doHTTPReq(req)
+	res, err := c.call(ctx, &Call{Endpoint: "kline.do", Params: qv})
 	if err != nil {
 		return nil, err
 	}
 	var recv []*CandleStick
-	if err := json.Unmarshal(blob, &recv); err != nil {
+	if err := json.Unmarshal(res.Body, &recv); err != nil {
 		return nil, err
 	}
 	cres := &CandleStickResponse{
@@ -127,6 +163,23 @@ type CandleStick struct {
 	Low         float64 `json:"low,omitempty"`
 	Close       float64 `json:"close,omitempty"`
 	Volume      float64 `json:"volume,omitempty"`
+
+	// raw is the JSON that the candle stick was decoded from, if any.
+	raw json.RawMessage
+}
+
+// The Decimal accessors return the digits sent by the exchange, read
+// from the candle stick's JSON, or convert the float64 fields of
+// candle sticks that were not decoded from JSON.
+
+func (cs *CandleStick) OpenDecimal() Decimal   { return arrayDecimal(cs.raw, 1, cs.Open) }
+func (cs *CandleStick) HighDecimal() Decimal   { return arrayDecimal(cs.raw, 2, cs.High) }
+func (cs *CandleStick) LowDecimal() Decimal    { return arrayDecimal(cs.raw, 3, cs.Low) }
+func (cs *CandleStick) CloseDecimal() Decimal  { return arrayDecimal(cs.raw, 4, cs.Close) }
+func (cs *CandleStick) VolumeDecimal() Decimal { return arrayDecimal(cs.raw, 5, cs.Volume) }
+
+func (cs *CandleStick) Time() time.Time {
+	return EpochToTime(cs.TimeStampMs)
 }
 
 const (
@@ -158,5 +211,6 @@ func (cs *CandleStick) UnmarshalJSON(b []byte) error {
 	cs.Low = recv[3]
 	cs.Close = recv[4]
 	cs.Volume = recv[5]
+	cs.raw = append(json.RawMessage(nil), b...)
 	return nil
 }
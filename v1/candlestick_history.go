@@ -0,0 +1,138 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// kline.do returns at most this many candle sticks per call.
+const maxCandleSticksPerRequest = 2000
+
+// Gap is a span of time in [Start, End) for which
+// the exchange returned no candle sticks.
+type Gap struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type CandleStickHistoryResponse struct {
+	Symbol Symbol    `json:"symbol,omitempty"`
+	Period Period    `json:"period,omitempty"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+
+	// CandleSticks are sorted by time and unique per timestamp.
+	CandleSticks []*CandleStick `json:"candle_sticks,omitempty"`
+	Gaps         []*Gap         `json:"gaps,omitempty"`
+}
+
+var errInvalidTimeRange = errors.New("expecting from to be before to")
+
+// CandleStickHistory retrieves every candle stick in [from, to) by issuing
+// successive kline.do calls, each starting after the last candle stick
+// received, or after the window if it had none, until to. The windows
+// are stitched and de-duplicated by timestamp and spans without any
+// candle sticks are reported as Gaps. It gives up once ctx is done.
+func (c *Client) CandleStickHistory(ctx context.Context, symbol Symbol, period Period, from, to time.Time) (*CandleStickHistoryResponse, error) {
+	if symbol == "" {
+		symbol = defaultSymbol
+	}
+	if period == "" {
+		period = defaultPeriod
+	}
+	step := period.Duration()
+	if step <= 0 {
+		return nil, fmt.Errorf("unknown period %q", period)
+	}
+	if !from.Before(to) {
+		return nil, errInvalidTimeRange
+	}
+
+	window := step * maxCandleSticksPerRequest
+	byTimestamp := make(map[float64]*CandleStick)
+	for cursor := from; cursor.Before(to); {
+		cres, err := c.CandleStickContext(ctx, &CandleStickRequest{
+			Symbol:    symbol,
+			Period:    period,
+			SinceTime: cursor,
+			N:         maxCandleSticksPerRequest,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var latest time.Time
+		for _, cs := range cres.CandleSticks {
+			t := cs.Time()
+			if t.Before(from) || !t.Before(to) {
+				continue
+			}
+			if t.After(latest) {
+				latest = t
+			}
+			if _, seen := byTimestamp[cs.TimeStampMs]; !seen {
+				byTimestamp[cs.TimeStampMs] = cs
+			}
+		}
+		// A window with nothing newer than the cursor is a pause
+		// in trading, or an exchange that ignores "since", so the
+		// next one starts after it rather than at the same cursor.
+		if latest.IsZero() || latest.Before(cursor) {
+			cursor = cursor.Add(window)
+			continue
+		}
+		cursor = latest.Add(step)
+	}
+
+	csticks := make([]*CandleStick, 0, len(byTimestamp))
+	for _, cs := range byTimestamp {
+		csticks = append(csticks, cs)
+	}
+	sort.Slice(csticks, func(i, j int) bool {
+		return csticks[i].TimeStampMs < csticks[j].TimeStampMs
+	})
+
+	return &CandleStickHistoryResponse{
+		Symbol:       symbol,
+		Period:       period,
+		From:         from,
+		To:           to,
+		CandleSticks: csticks,
+		Gaps:         findGaps(csticks, step, from, to),
+	}, nil
+}
+
+// findGaps reports the spans of at least one period, between from,
+// the sorted candle sticks and to, that have no candle sticks.
+func findGaps(csticks []*CandleStick, step time.Duration, from, to time.Time) []*Gap {
+	var gaps []*Gap
+	expected := from
+	for _, cs := range csticks {
+		t := cs.Time()
+		if t.Sub(expected) >= step {
+			gaps = append(gaps, &Gap{Start: expected, End: t})
+		}
+		expected = t.Add(step)
+	}
+	if to.Sub(expected) >= step {
+		gaps = append(gaps, &Gap{Start: expected, End: to})
+	}
+	return gaps
+}
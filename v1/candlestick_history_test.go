@@ -0,0 +1,186 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// The synthetic history has hourly candle sticks for hours
+// 0 through 11 after historyStart, except for hours 4 and 5.
+var (
+	historyStart   = time.Date(2017, 8, 30, 0, 0, 0, 0, time.UTC)
+	historyMissing = map[int]bool{4: true, 5: true}
+)
+
+const (
+	historyHours = 12
+	// historyPageSize forces CandleStickHistory to use several windows.
+	historyPageSize = 3
+)
+
+func TestCandleStickHistory(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&backend{route: candleStickHistoryRoute})
+
+	if _, err := client.CandleStickHistory(context.Background(), okcoin.BTCUSD, okcoin.P1Hour, historyStart, historyStart); err == nil {
+		t.Errorf("expected an error for an empty time range")
+	}
+	if _, err := client.CandleStickHistory(context.Background(), okcoin.BTCUSD, "2min", historyStart, historyStart.Add(time.Hour)); err == nil {
+		t.Errorf("expected an error for an unknown period")
+	}
+
+	from := historyStart.Add(1 * time.Hour)
+	to := historyStart.Add(14 * time.Hour)
+	hres, err := client.CandleStickHistory(context.Background(), okcoin.BTCUSD, okcoin.P1Hour, from, to)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+
+	var gotHours []int
+	for _, cs := range hres.CandleSticks {
+		gotHours = append(gotHours, int(cs.Time().Sub(historyStart)/time.Hour))
+	}
+	if g, w := fmt.Sprint(gotHours), "[1 2 3 6 7 8 9 10 11]"; g != w {
+		t.Errorf("hours: got=%s want=%s", g, w)
+	}
+
+	wantGaps := []*okcoin.Gap{
+		{Start: historyStart.Add(4 * time.Hour), End: historyStart.Add(6 * time.Hour)},
+		{Start: historyStart.Add(12 * time.Hour), End: to},
+	}
+	if g, w := len(hres.Gaps), len(wantGaps); g != w {
+		t.Fatalf("gaps: got=%d want=%d", g, w)
+	}
+	for i, gap := range hres.Gaps {
+		if want := wantGaps[i]; !gap.Start.Equal(want.Start) || !gap.End.Equal(want.End) {
+			t.Errorf("gap #%d: got=[%v, %v) want=[%v, %v)", i, gap.Start, gap.End, want.Start, want.End)
+		}
+	}
+}
+
+// The sparse history has a minute candle stick at minutes 0 through 2
+// and 5000 through 5002 after historyStart, with no trades in between.
+var sparseMinutes = []int{0, 1, 2, 5000, 5001, 5002}
+
+func TestCandleStickHistoryAcrossPauses(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&backend{route: sparseHistoryRoute})
+
+	to := historyStart.Add(6000 * time.Minute)
+	hres, err := client.CandleStickHistory(context.Background(), okcoin.BTCUSD, okcoin.P1Min, historyStart, to)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	var gotMinutes []int
+	for _, cs := range hres.CandleSticks {
+		gotMinutes = append(gotMinutes, int(cs.Time().Sub(historyStart)/time.Minute))
+	}
+	if g, w := fmt.Sprint(gotMinutes), fmt.Sprint(sparseMinutes); g != w {
+		t.Errorf("minutes: got=%s want=%s", g, w)
+	}
+	if g, w := len(hres.Gaps), 2; g != w {
+		t.Errorf("gaps: got=%d want=%d", g, w)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.SetHTTPRoundTripper(&ctxChecker{base: &backend{route: sparseHistoryRoute}})
+	if _, err := client.CandleStickHistory(ctx, okcoin.BTCUSD, okcoin.P1Min, historyStart, to); err == nil {
+		t.Errorf("cancelled: want non-nil error")
+	}
+}
+
+// sparseHistoryRoundTrip answers like kline.do with the candle sticks
+// in the size periods after since, which may be none.
+func (b *backend) sparseHistoryRoundTrip(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	sinceMs, err := strconv.ParseInt(query.Get("since"), 10, 64)
+	if err != nil {
+		return makeResp(fmt.Sprintf(`"since": %v`, err), http.StatusBadRequest, nil)
+	}
+	size, err := strconv.Atoi(query.Get("size"))
+	if err != nil {
+		return makeResp(fmt.Sprintf(`"size": %v`, err), http.StatusBadRequest, nil)
+	}
+	since := time.Unix(0, sinceMs*int64(time.Millisecond))
+	until := since.Add(time.Duration(size) * time.Minute)
+
+	recv := [][]float64{}
+	for _, minute := range sparseMinutes {
+		t := historyStart.Add(time.Duration(minute) * time.Minute)
+		if t.Before(since) || !t.Before(until) {
+			continue
+		}
+		recv = append(recv, []float64{okcoin.TimeToEpochMs(t), 4000, 4001, 3999, 4000, 1})
+	}
+	blob, err := json.Marshal(recv)
+	if err != nil {
+		return makeResp(err.Error(), http.StatusInternalServerError, nil)
+	}
+	return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(string(blob))))
+}
+
+func (b *backend) candleStickHistoryRoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, "/api/v1/kline.do") {
+		return makeResp(fmt.Sprintf("unexpected path %q", req.URL.Path), http.StatusBadRequest, nil)
+	}
+	query := req.URL.Query()
+	sinceMs, err := strconv.ParseInt(query.Get("since"), 10, 64)
+	if err != nil {
+		return makeResp(fmt.Sprintf(`"since": %v`, err), http.StatusBadRequest, nil)
+	}
+	since := time.Unix(0, sinceMs*int64(time.Millisecond))
+
+	var recv [][]float64
+	for hour := 0; hour < historyHours && len(recv) < historyPageSize; hour++ {
+		t := historyStart.Add(time.Duration(hour) * time.Hour)
+		if historyMissing[hour] || t.Before(since) {
+			continue
+		}
+		price := float64(4000 + hour)
+		recv = append(recv, []float64{okcoin.TimeToEpochMs(t), price, price + 1, price - 1, price, 10})
+	}
+	blob, err := json.Marshal(recv)
+	if err != nil {
+		return makeResp(err.Error(), http.StatusInternalServerError, nil)
+	}
+	return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(string(blob))))
+}
+
+const (
+	candleStickHistoryRoute = "/candle-stick-history"
+	sparseHistoryRoute      = "/sparse-history"
+)
@@ -22,6 +22,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/orijtech/okcoin/v1"
 )
@@ -42,11 +43,14 @@ func TestCandleStick(t *testing.T) {
 	tests := [...]struct {
 		req         *okcoin.CandleStickRequest
 		wantAtLeast int
+		wantSince   float64
 		wantErr     bool
 	}{
 		0: {req: nil, wantAtLeast: 2},
 		1: {req: &okcoin.CandleStickRequest{Since: -1}, wantAtLeast: 2},
-		2: {req: &okcoin.CandleStickRequest{Since: 1504068205.797958}, wantAtLeast: 2},
+		2: {req: &okcoin.CandleStickRequest{Since: 1504068205.797958}, wantAtLeast: 2, wantSince: 1504068205797},
+		3: {req: &okcoin.CandleStickRequest{SinceTime: time.Unix(1504068205, 0)}, wantAtLeast: 2, wantSince: 1504068205000},
+		4: {req: &okcoin.CandleStickRequest{Since: 1504068205797}, wantAtLeast: 2, wantSince: 1504068205797},
 	}
 
 	client.SetHTTPRoundTripper(&backend{route: candleStickRoute})
@@ -67,6 +71,9 @@ func TestCandleStick(t *testing.T) {
 			t.Errorf("#%d: got blankCandleStickResponse", i)
 			continue
 		}
+		if g, w := cres.Since, tt.wantSince; g != w {
+			t.Errorf("#%d: since: got=%f want=%f", i, g, w)
+		}
 		validNonBlankCandleSticks := 0
 		for _, cstick := range cres.CandleSticks {
 			if !reflect.DeepEqual(cstick, blankCandleStick) {
@@ -97,6 +104,10 @@ func (b *backend) candleStickRoundTrip(req *http.Request) (*http.Response, error
 		if since < 0.0 {
 			return makeResp(fmt.Sprintf(`"since": got=%f wantAtLeast 0`, since), http.StatusBadRequest, nil)
 		}
+		// kline.do expects milliseconds.
+		if since > 0 && since < 1e11 {
+			return makeResp(fmt.Sprintf(`"since": got=%f want milliseconds`, since), http.StatusBadRequest, nil)
+		}
 	}
 	symbol := query.Get("symbol")
 	outPath := fmt.Sprintf("./testdata/candlestick-%s.json", symbol)
@@ -0,0 +1,144 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// LatencyBuckets are the upper bounds, in seconds,
+// of the API request duration histogram.
+var LatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// APIStats counts the requests to each endpoint of the API, such as
+// "ticker.do", with their latencies and errors. Errors are labelled
+// with the exchange's error_code, the HTTP status or "transport" for
+// requests that got no response. It counts the requests of the
+// clients that it is set as the Tracer of.
+type APIStats struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointStats
+}
+
+type endpointStats struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+	errors  map[string]uint64
+}
+
+func NewAPIStats() *APIStats {
+	return &APIStats{endpoints: make(map[string]*endpointStats)}
+}
+
+func (as *APIStats) observe(endpoint string, d time.Duration, errCode string) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	es, ok := as.endpoints[endpoint]
+	if !ok {
+		es = &endpointStats{buckets: make([]uint64, len(LatencyBuckets)), errors: make(map[string]uint64)}
+		as.endpoints[endpoint] = es
+	}
+	secs := d.Seconds()
+	es.count++
+	es.sum += secs
+	for i, le := range LatencyBuckets {
+		if secs <= le {
+			es.buckets[i]++
+		}
+	}
+	if errCode != "" {
+		es.errors[errCode]++
+	}
+}
+
+// Families returns the request, error and latency metric families.
+func (as *APIStats) Families() []*Family {
+	requests := &Family{Name: "okcoin_api_requests_total", Type: Counter,
+		Help: "Requests made to the OKCoin API by endpoint."}
+	errs := &Family{Name: "okcoin_api_errors_total", Type: Counter,
+		Help: "Failed requests to the OKCoin API by endpoint and error_code, HTTP status or transport failure."}
+	latency := &Family{Name: "okcoin_api_request_duration_seconds", Type: Histogram,
+		Help: "Latency of the requests to the OKCoin API by endpoint."}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	var endpoints []string
+	for endpoint := range as.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		es := as.endpoints[endpoint]
+		requests.Add(float64(es.count), "endpoint", endpoint)
+
+		var codes []string
+		for code := range es.errors {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			errs.Add(float64(es.errors[code]), "endpoint", endpoint, "code", code)
+		}
+
+		for i, le := range LatencyBuckets {
+			latency.Samples = append(latency.Samples, &Sample{
+				Suffix: "_bucket",
+				Labels: labels("endpoint", endpoint, "le", formatValue(le)),
+				Value:  float64(es.buckets[i]),
+			})
+		}
+		latency.Samples = append(latency.Samples,
+			&Sample{Suffix: "_bucket", Labels: labels("endpoint", endpoint, "le", "+Inf"), Value: float64(es.count)},
+			&Sample{Suffix: "_sum", Labels: labels("endpoint", endpoint), Value: es.sum},
+			&Sample{Suffix: "_count", Labels: labels("endpoint", endpoint), Value: float64(es.count)},
+		)
+	}
+	return []*Family{requests, errs, latency}
+}
+
+var _ okcoin.Tracer = (*APIStats)(nil)
+
+// StartRequest records every request that a client sends once it is
+// done, which makes APIStats a Tracer for okcoin.Client.SetTracer.
+// Only the endpoint is recorded, never the parameters, keys or signatures.
+func (as *APIStats) StartRequest(ctx context.Context, info *okcoin.RequestInfo) (context.Context, func(*okcoin.RequestResult)) {
+	endpoint := info.Endpoint
+	return ctx, func(res *okcoin.RequestResult) {
+		as.observe(endpoint, res.Latency, errorCode(res))
+	}
+}
+
+// errorCode returns the label of a failed request, or "" if it succeeded.
+// Failures are mostly reported as {"result":false,"error_code":N}
+// with a 200 status, so the error_code takes precedence.
+func errorCode(res *okcoin.RequestResult) string {
+	switch {
+	case res.ErrorCode != 0:
+		return strconv.Itoa(res.ErrorCode)
+	case res.StatusCode != 0 && (res.StatusCode < 200 || res.StatusCode > 299):
+		return strconv.Itoa(res.StatusCode)
+	case res.Err != nil:
+		return "transport"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collector exposes OKCoin market data, account balances and
+// API call statistics as metrics in the Prometheus text format.
+//
+// A Collector queries the exchange on every scrape, so the metrics are
+// as fresh as the scrape interval, and serves them over HTTP:
+//
+//	c, err := collector.New(&collector.Config{
+//		Client:  client,
+//		Symbols: []okcoin.Symbol{okcoin.BTCUSD, okcoin.LTCUSD},
+//		Funds:   true,
+//	})
+//	client.SetTracer(c.APIStats())
+//	http.Handle("/metrics", c)
+//
+// The API statistics only count the requests of clients that report
+// to the collector's APIStats, which is left for the caller to set up
+// so that the client keeps whatever transport and tracer it has.
+//
+// Values that could not be fetched during a scrape are left out of it
+// rather than reported as zeros, while the failure shows up in the
+// okcoin_api_errors_total counter.
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+type Config struct {
+	// Client is the client that queries the exchange.
+	Client *okcoin.Client
+
+	Symbols []okcoin.Symbol
+
+	// CandlePeriods are the periods of the candle sticks
+	// whose latest close is collected for every symbol.
+	CandlePeriods []okcoin.Period
+
+	// Funds collects the free and frozen balances of the
+	// account, which requires the client to have credentials.
+	Funds bool
+
+	// Timeout bounds each scrape, defaulting to DefaultTimeout.
+	Timeout time.Duration
+
+	// ErrorLog receives the failures to answer scrapes,
+	// the log package's standard logger if nil.
+	ErrorLog *log.Logger
+}
+
+const DefaultTimeout = 10 * time.Second
+
+var (
+	errNilClient = errors.New("expecting a non-nil client")
+	errNoSymbols = errors.New("expecting at least one symbol, or funds to be collected")
+)
+
+// Collector gathers metrics from the exchange on every scrape.
+type Collector struct {
+	cfg   Config
+	stats *APIStats
+}
+
+var _ http.Handler = (*Collector)(nil)
+
+// New returns a collector for cfg. It leaves the client untouched.
+func New(cfg *Config) (*Collector, error) {
+	if cfg == nil || cfg.Client == nil {
+		return nil, errNilClient
+	}
+	if len(cfg.Symbols) == 0 && !cfg.Funds {
+		return nil, errNoSymbols
+	}
+	c := &Collector{cfg: *cfg, stats: NewAPIStats()}
+	if c.cfg.Timeout <= 0 {
+		c.cfg.Timeout = DefaultTimeout
+	}
+	return c, nil
+}
+
+// APIStats returns the statistics of the requests of the clients
+// that it is set as the Tracer of.
+func (c *Collector) APIStats() *APIStats {
+	return c.stats
+}
+
+// Collect queries the exchange and returns every metric family,
+// including the API statistics up to the end of the queries.
+func (c *Collector) Collect(ctx context.Context) []*Family {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	last := &Family{Name: "okcoin_ticker_last", Type: Gauge, Help: "Price of the last trade by symbol."}
+	bid := &Family{Name: "okcoin_ticker_bid", Type: Gauge, Help: "Best bid price by symbol."}
+	ask := &Family{Name: "okcoin_ticker_ask", Type: Gauge, Help: "Best ask price by symbol."}
+	volume := &Family{Name: "okcoin_ticker_volume", Type: Gauge, Help: "Traded volume over the last 24 hours by symbol."}
+	closes := &Family{Name: "okcoin_candle_close", Type: Gauge, Help: "Close of the latest candle stick by symbol and period."}
+	free := &Family{Name: "okcoin_funds_free", Type: Gauge, Help: "Free balance of the account by currency."}
+	frozen := &Family{Name: "okcoin_funds_frozen", Type: Gauge, Help: "Balance of the account frozen in open orders by currency."}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	if len(c.cfg.Symbols) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results := c.cfg.Client.Tickers(ctx, c.cfg.Symbols...)
+			mu.Lock()
+			defer mu.Unlock()
+			for _, sym := range c.cfg.Symbols {
+				res := results[sym]
+				if res == nil || res.Err != nil || res.Ticker == nil || res.Ticker.Ticker == nil {
+					continue
+				}
+				tk := res.Ticker.Ticker
+				last.Add(tk.Last, "symbol", string(sym))
+				bid.Add(tk.Buy, "symbol", string(sym))
+				ask.Add(tk.Sell, "symbol", string(sym))
+				volume.Add(tk.Volume, "symbol", string(sym))
+			}
+		}()
+	}
+
+	for _, sym := range c.cfg.Symbols {
+		for _, period := range c.cfg.CandlePeriods {
+			sym, period := sym, period
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cres, err := c.cfg.Client.CandleStickContext(ctx, &okcoin.CandleStickRequest{Symbol: sym, Period: period, N: 1})
+				if err != nil || len(cres.CandleSticks) == 0 {
+					return
+				}
+				cs := cres.CandleSticks[len(cres.CandleSticks)-1]
+				mu.Lock()
+				closes.Add(cs.Close, "symbol", string(sym), "period", string(period))
+				mu.Unlock()
+			}()
+		}
+	}
+
+	if c.cfg.Funds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			funds, err := c.cfg.Client.FundsContext(ctx)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			addBalances(free, frozen, funds)
+		}()
+	}
+
+	wg.Wait()
+	// The goroutines finish in any order.
+	sortSamples(closes)
+
+	families := []*Family{last, bid, ask, volume, closes, free, frozen}
+	return append(families, c.stats.Families()...)
+}
+
+// addBalances reports the free and frozen balance of every currency
+// in either fund, so that each has both series even when one is 0.
+func addBalances(free, frozen *Family, funds *okcoin.Funds) {
+	seen := make(map[okcoin.Currency]bool)
+	var currencies []string
+	for _, fund := range []*okcoin.Fund{funds.Free, funds.Frozen} {
+		if fund == nil {
+			continue
+		}
+		for cur := range fund.Balances {
+			if !seen[cur] {
+				seen[cur] = true
+				currencies = append(currencies, string(cur))
+			}
+		}
+	}
+	sort.Strings(currencies)
+	for _, cur := range currencies {
+		free.Add(funds.Free.Balance(okcoin.Currency(cur)), "currency", cur)
+		frozen.Add(funds.Frozen.Balance(okcoin.Currency(cur)), "currency", cur)
+	}
+}
+
+func sortSamples(f *Family) {
+	key := func(s *Sample) string {
+		k := ""
+		for _, l := range s.Labels {
+			k += l.Value + "\x00"
+		}
+		return k
+	}
+	sort.SliceStable(f.Samples, func(i, j int) bool { return key(f.Samples[i]) < key(f.Samples[j]) })
+}
+
+// WriteMetrics collects the metrics and writes them in the text format.
+func (c *Collector) WriteMetrics(ctx context.Context, w io.Writer) error {
+	return WriteText(w, c.Collect(ctx))
+}
+
+// ServeHTTP answers scrapes with freshly collected metrics. Only
+// writing the response can fail, by which time the status is sent,
+// so failures are logged to ErrorLog instead.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := c.WriteMetrics(r.Context(), w); err != nil {
+		c.logf("collector: writing metrics to %s: %v", r.RemoteAddr, err)
+	}
+}
+
+func (c *Collector) logf(format string, args ...interface{}) {
+	if c.cfg.ErrorLog != nil {
+		c.cfg.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/collector"
+	"github.com/orijtech/okcoin/v1/okcointest"
+)
+
+const (
+	apiKey1    = "key1"
+	apiSecret1 = "secret1"
+)
+
+func newCollector(t *testing.T, srv *okcointest.Server, funds bool) *collector.Collector {
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(srv.Transport())
+	c, err := collector.New(&collector.Config{
+		Client:        client,
+		Symbols:       []okcoin.Symbol{okcoin.BTCUSD, okcoin.LTCUSD},
+		CandlePeriods: []okcoin.Period{okcoin.P1Min},
+		Funds:         funds,
+	})
+	if err != nil {
+		t.Fatalf("new collector: %v", err)
+	}
+	client.SetTracer(c.APIStats())
+	return c
+}
+
+func newServer(t *testing.T) *okcointest.Server {
+	srv := okcointest.NewServer()
+	srv.SetTicker(okcoin.BTCUSD, &okcoin.TickerResponse{
+		Ticker: &okcoin.Ticker{Buy: 4590, Sell: 4594.17, Last: 4592, Volume: 120.5},
+	})
+	srv.SetCandleSticks(okcoin.BTCUSD, okcoin.P1Min, []*okcoin.CandleStick{
+		{TimeStampMs: 1503960000000, Close: 4580},
+		{TimeStampMs: 1503960060000, Close: 4591},
+	})
+	balances := map[okcoin.Currency]float64{okcoin.USD: 10000, okcoin.BTC: 0.5}
+	if err := srv.AddAccount(apiKey1, apiSecret1, balances); err != nil {
+		srv.Close()
+		t.Fatalf("add account: %v", err)
+	}
+	return srv
+}
+
+func scrape(t *testing.T, h http.Handler) string {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if g, w := rec.Header().Get("Content-Type"), collector.ContentType; g != w {
+		t.Errorf("content type: got=%q want=%q", g, w)
+	}
+	blob, _ := ioutil.ReadAll(rec.Body)
+	return string(blob)
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+	c := newCollector(t, srv, true)
+
+	got := scrape(t, c)
+	for _, want := range []string{
+		"# TYPE okcoin_ticker_last gauge\n",
+		`okcoin_ticker_last{symbol="btc_usd"} 4592` + "\n",
+		`okcoin_ticker_bid{symbol="btc_usd"} 4590` + "\n",
+		`okcoin_ticker_ask{symbol="btc_usd"} 4594.17` + "\n",
+		`okcoin_ticker_volume{symbol="btc_usd"} 120.5` + "\n",
+		`okcoin_candle_close{symbol="btc_usd",period="1min"} 4591` + "\n",
+		`okcoin_funds_free{currency="btc"} 0.5` + "\n",
+		`okcoin_funds_free{currency="usd"} 10000` + "\n",
+		`okcoin_funds_frozen{currency="usd"} 0` + "\n",
+		`okcoin_api_requests_total{endpoint="ticker.do"} 2` + "\n",
+		`okcoin_api_requests_total{endpoint="userinfo.do"} 1` + "\n",
+		`okcoin_api_request_duration_seconds_bucket{endpoint="ticker.do",le="+Inf"} 2` + "\n",
+		`okcoin_api_request_duration_seconds_count{endpoint="kline.do"} 2` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("scrape lacks %q:\n%s", want, got)
+		}
+	}
+	// LTC has no ticker on the server, so it is left out rather than zero.
+	if strings.Contains(got, `okcoin_ticker_last{symbol="ltc_usd"}`) {
+		t.Errorf("scrape reports the missing ltc_usd ticker:\n%s", got)
+	}
+	if !strings.Contains(got, `okcoin_api_errors_total{endpoint="ticker.do",code="10008"} 1`) {
+		t.Errorf("scrape lacks the ltc_usd ticker error:\n%s", got)
+	}
+	// Nothing secret may be exposed.
+	for _, secret := range []string{apiKey1, apiSecret1, "sign"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("scrape leaks %q:\n%s", secret, got)
+		}
+	}
+
+	// The counters accumulate across scrapes.
+	got = scrape(t, c)
+	if want := `okcoin_api_requests_total{endpoint="ticker.do"} 4`; !strings.Contains(got, want) {
+		t.Errorf("second scrape lacks %q:\n%s", want, got)
+	}
+}
+
+func TestCollectorErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+	c := newCollector(t, srv, true)
+
+	srv.FailWithCode("userinfo.do", 10001)
+	srv.FailWithStatus("kline.do", http.StatusServiceUnavailable)
+	got := scrape(t, c)
+	for _, want := range []string{
+		`okcoin_api_errors_total{endpoint="userinfo.do",code="10001"} 1`,
+		`okcoin_api_errors_total{endpoint="kline.do",code="503"} 2`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("scrape lacks %q:\n%s", want, got)
+		}
+	}
+	for _, missing := range []string{"okcoin_funds_free", "okcoin_candle_close"} {
+		if strings.Contains(got, missing) {
+			t.Errorf("scrape has %s despite the failures:\n%s", missing, got)
+		}
+	}
+
+	srv.Close()
+	got = scrape(t, c)
+	if want := `okcoin_api_errors_total{endpoint="ticker.do",code="transport"}`; !strings.Contains(got, want) {
+		t.Errorf("scrape of a closed server lacks %q:\n%s", want, got)
+	}
+
+	if _, err := collector.New(nil); err == nil {
+		t.Errorf("nil config: want non-nil error")
+	}
+	client, _ := okcoin.NewDefaultClient()
+	if _, err := collector.New(&collector.Config{Client: client}); err == nil {
+		t.Errorf("nothing to collect: want non-nil error")
+	}
+}
+
+type countingTransport struct {
+	base http.RoundTripper
+	n    int32
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&ct.n, 1)
+	return ct.base.RoundTrip(req)
+}
+
+func TestCollectorLeavesClientAlone(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+	client, _ := okcoin.NewDefaultClient()
+	ct := &countingTransport{base: srv.Transport()}
+	client.SetHTTPRoundTripper(ct)
+	c, err := collector.New(&collector.Config{Client: client, Symbols: []okcoin.Symbol{okcoin.BTCUSD}})
+	if err != nil {
+		t.Fatalf("new collector: %v", err)
+	}
+
+	// Without the APIStats as the client's tracer, nothing is counted.
+	got := scrape(t, c)
+	if want := `okcoin_ticker_last{symbol="btc_usd"} 4592`; !strings.Contains(got, want) {
+		t.Errorf("scrape lacks %q:\n%s", want, got)
+	}
+	if strings.Contains(got, "okcoin_api_requests_total") {
+		t.Errorf("scrape counts requests without a tracer:\n%s", got)
+	}
+	if g, w := atomic.LoadInt32(&ct.n), int32(1); g != w {
+		t.Errorf("requests through the client's transport: got=%d want=%d", g, w)
+	}
+}
+
+func TestCollectorTimeout(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+	srv.SetLatency(time.Hour)
+	client, _ := okcoin.NewDefaultClient()
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(srv.Transport())
+	c, err := collector.New(&collector.Config{
+		Client:        client,
+		Symbols:       []okcoin.Symbol{okcoin.BTCUSD},
+		CandlePeriods: []okcoin.Period{okcoin.P1Min},
+		Funds:         true,
+		Timeout:       20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("new collector: %v", err)
+	}
+	client.SetTracer(c.APIStats())
+
+	start := time.Now()
+	got := scrape(t, c)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("scrape took %v", elapsed)
+	}
+	for _, want := range []string{
+		`okcoin_api_errors_total{endpoint="kline.do",code="transport"} 1`,
+		`okcoin_api_errors_total{endpoint="userinfo.do",code="transport"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("scrape lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (fw *failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestServeHTTPLogsWriteErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+	client, _ := okcoin.NewDefaultClient()
+	client.SetHTTPRoundTripper(srv.Transport())
+	logs := new(bytes.Buffer)
+	c, err := collector.New(&collector.Config{
+		Client:   client,
+		Symbols:  []okcoin.Symbol{okcoin.BTCUSD},
+		ErrorLog: log.New(logs, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("new collector: %v", err)
+	}
+
+	fw := &failingWriter{httptest.NewRecorder()}
+	c.ServeHTTP(fw, httptest.NewRequest("GET", "/metrics", nil))
+	if got := logs.String(); !strings.Contains(got, "connection reset") {
+		t.Errorf("log lacks the write error: %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types of the text format.
+const (
+	Counter   = "counter"
+	Gauge     = "gauge"
+	Histogram = "histogram"
+)
+
+// Label is a name and value qualifying a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value of a metric family. Suffix is appended to the
+// family's name, e.g. "_bucket", "_sum" or "_count" for histograms.
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a metric with its help text, type and samples.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []*Sample
+}
+
+// Add appends a sample with the labels given as name, value pairs.
+func (f *Family) Add(value float64, labelPairs ...string) {
+	f.Samples = append(f.Samples, &Sample{Labels: labels(labelPairs...), Value: value})
+}
+
+func labels(pairs ...string) []Label {
+	var ls []Label
+	for i := 0; i+1 < len(pairs); i += 2 {
+		ls = append(ls, Label{Name: pairs[i], Value: pairs[i+1]})
+	}
+	return ls
+}
+
+// WriteText writes the families in the Prometheus text format, sorted by
+// name so that scrapes are stable. Families without samples are left out.
+func WriteText(w io.Writer, families []*Family) error {
+	sorted := append([]*Family(nil), families...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range sorted {
+		if len(f.Samples) == 0 {
+			continue
+		}
+		if f.Help != "" {
+			bw.WriteString("# HELP " + f.Name + " " + helpEscaper.Replace(f.Help) + "\n")
+		}
+		if f.Type != "" {
+			bw.WriteString("# TYPE " + f.Name + " " + f.Type + "\n")
+		}
+		for _, s := range f.Samples {
+			bw.WriteString(f.Name + s.Suffix)
+			if len(s.Labels) > 0 {
+				bw.WriteByte('{')
+				for i, l := range s.Labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					bw.WriteString(l.Name + `="` + labelEscaper.Replace(l.Value) + `"`)
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteString(" " + formatValue(s.Value) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1/collector"
+)
+
+func TestWriteText(t *testing.T) {
+	t.Parallel()
+
+	gauge := &collector.Family{Name: "b_gauge", Type: collector.Gauge, Help: "A gauge\nwith a \\ backslash."}
+	gauge.Add(1.5, "symbol", "btc_usd")
+	gauge.Add(1e-8, "symbol", `a "quoted"`+"\nvalue")
+	gauge.Add(math.Inf(1))
+	empty := &collector.Family{Name: "c_empty", Type: collector.Counter, Help: "Never written."}
+	counter := &collector.Family{Name: "a_total", Type: collector.Counter}
+	counter.Add(3)
+
+	buf := new(bytes.Buffer)
+	if err := collector.WriteText(buf, []*collector.Family{gauge, empty, counter}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	want := `# TYPE a_total counter
+a_total 3
+# HELP b_gauge A gauge\nwith a \\ backslash.
+# TYPE b_gauge gauge
+b_gauge{symbol="btc_usd"} 1.5
+b_gauge{symbol="a \"quoted\"\nvalue"} 1e-08
+b_gauge +Inf
+`
+	if g := buf.String(); g != want {
+		t.Errorf("got:\n%s\nwant:\n%s", g, want)
+	}
+}
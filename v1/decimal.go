@@ -0,0 +1,348 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact base 10 number, stored as an arbitrary precision
+// integer and the count of digits after the decimal point. It keeps the
+// digits exactly as OKCoin sends them, trailing zeros included, and is
+// JSON encoded as a string so that prices and amounts never pass through
+// float64. The zero value is 0. Decimals are immutable.
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+// NewDecimal returns value * 10^-scale.
+func NewDecimal(value int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{value: new(big.Int).Mul(big.NewInt(value), pow10(-scale))}
+	}
+	return Decimal{value: big.NewInt(value), scale: scale}
+}
+
+// NewDecimalFromFloat returns the shortest decimal that converts back
+// to f. Values decoded from strings of up to 15 significant digits, as
+// every price and amount from the exchange is, are thus recovered exactly.
+// NaN and infinities convert to 0.
+func NewDecimalFromFloat(f float64) Decimal {
+	d, err := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
+
+// MaxDecimalScale bounds the exponent and the count of digits after
+// the point of parsed decimals, far beyond any price or amount, lest
+// inputs like "1e-3000000000" overflow the scale or "1e50000000" take
+// seconds to expand.
+const MaxDecimalScale = 1000
+
+// ParseDecimal parses strings such as "384.47", "-0.001" and "1e-8".
+// Their exponent and scale must be within ±MaxDecimalScale.
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.Atoi(str[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("decimal %q: invalid exponent: %v", s, err)
+		}
+		if e < -MaxDecimalScale || e > MaxDecimalScale {
+			return Decimal{}, fmt.Errorf("decimal %q: exponent out of range", s)
+		}
+		exp, str = e, str[:i]
+	}
+	sign := ""
+	if str != "" && (str[0] == '-' || str[0] == '+') {
+		if str[0] == '-' {
+			sign = "-"
+		}
+		str = str[1:]
+	}
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	digits := intPart + fracPart
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("decimal %q: invalid syntax", s)
+	}
+	value, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("decimal %q: invalid syntax", s)
+	}
+	scale := len(fracPart) - exp
+	if scale < -MaxDecimalScale || scale > MaxDecimalScale {
+		return Decimal{}, fmt.Errorf("decimal %q: scale out of range", s)
+	}
+	if scale < 0 {
+		value.Mul(value, pow10(int32(-scale)))
+		scale = 0
+	}
+	return Decimal{value: value, scale: int32(scale)}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on malformed input.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) unscaled() *big.Int {
+	if d.value == nil {
+		return new(big.Int)
+	}
+	return d.value
+}
+
+// rescale returns d's unscaled value expressed with scale digits after
+// the point, scale must be at least d.scale.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.unscaled()
+	}
+	return new(big.Int).Mul(d.unscaled(), pow10(scale-d.scale))
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+func (d Decimal) String() string {
+	v := d.unscaled()
+	if d.scale == 0 {
+		return v.String()
+	}
+	digits := new(big.Int).Abs(v).String()
+	scale := int(d.scale)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	str := digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	if v.Sign() < 0 {
+		str = "-" + str
+	}
+	return str
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d Decimal) Sign() int    { return d.unscaled().Sign() }
+func (d Decimal) IsZero() bool { return d.Sign() == 0 }
+
+// Cmp returns -1, 0 or +1 depending on whether d is less than,
+// equal to or greater than d2. Trailing zeros are insignificant.
+func (d Decimal) Cmp(d2 Decimal) int {
+	scale := maxScale(d, d2)
+	return d.rescale(scale).Cmp(d2.rescale(scale))
+}
+
+func (d Decimal) Equal(d2 Decimal) bool { return d.Cmp(d2) == 0 }
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.unscaled()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.unscaled()), scale: d.scale}
+}
+
+func (d Decimal) Add(d2 Decimal) Decimal {
+	scale := maxScale(d, d2)
+	return Decimal{value: new(big.Int).Add(d.rescale(scale), d2.rescale(scale)), scale: scale}
+}
+
+func (d Decimal) Sub(d2 Decimal) Decimal {
+	scale := maxScale(d, d2)
+	return Decimal{value: new(big.Int).Sub(d.rescale(scale), d2.rescale(scale)), scale: scale}
+}
+
+func (d Decimal) Mul(d2 Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.unscaled(), d2.unscaled()), scale: d.scale + d2.scale}
+}
+
+type roundingMode int
+
+const (
+	roundHalfUp roundingMode = iota // half away from zero
+	roundDown                       // towards zero
+	roundFloor                      // towards negative infinity
+)
+
+// quo returns num/den rounded to an integer according to mode.
+func quo(num, den *big.Int, mode roundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	negative := (num.Sign() < 0) != (den.Sign() < 0)
+	switch mode {
+	case roundHalfUp:
+		twiceR := new(big.Int).Abs(r)
+		twiceR.Lsh(twiceR, 1)
+		if twiceR.Cmp(new(big.Int).Abs(den)) >= 0 {
+			if negative {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+	case roundFloor:
+		if negative {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Div returns d/d2 rounded half away from zero to places digits after the point.
+// It panics if d2 is zero.
+func (d Decimal) Div(d2 Decimal, places int32) Decimal {
+	if d2.IsZero() {
+		panic("okcoin: decimal division by zero")
+	}
+	// d/d2 * 10^places = d.value * 10^(places+d2.scale-d.scale) / d2.value
+	num, den := d.unscaled(), d2.unscaled()
+	if shift := places + d2.scale - d.scale; shift >= 0 {
+		num = new(big.Int).Mul(num, pow10(shift))
+	} else {
+		den = new(big.Int).Mul(den, pow10(-shift))
+	}
+	return Decimal{value: quo(num, den, roundHalfUp), scale: places}
+}
+
+func (d Decimal) round(places int32, mode roundingMode) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return d
+	}
+	return Decimal{value: quo(d.unscaled(), pow10(d.scale-places), mode), scale: places}
+}
+
+// Round rounds d half away from zero to at most places digits after the point.
+func (d Decimal) Round(places int32) Decimal { return d.round(places, roundHalfUp) }
+
+// Truncate drops all but places digits after the point.
+func (d Decimal) Truncate(places int32) Decimal { return d.round(places, roundDown) }
+
+func (d Decimal) toIncrement(increment Decimal, mode roundingMode) Decimal {
+	if increment.Sign() <= 0 {
+		return d
+	}
+	scale := maxScale(d, increment)
+	steps := quo(d.rescale(scale), increment.rescale(scale), mode)
+	return Decimal{value: steps, scale: 0}.Mul(increment)
+}
+
+// RoundToIncrement rounds d to the nearest multiple of increment,
+// such as an instrument's tick size.
+func (d Decimal) RoundToIncrement(increment Decimal) Decimal {
+	return d.toIncrement(increment, roundHalfUp)
+}
+
+// FloorToIncrement rounds d down to a multiple of increment,
+// such as an instrument's lot size.
+func (d Decimal) FloorToIncrement(increment Decimal) Decimal {
+	return d.toIncrement(increment, roundFloor)
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON accepts both quoted strings and bare numbers,
+// keeping every digit of either.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"' {
+		b = b[1 : len(b)-1]
+	}
+	if len(b) == 0 {
+		*d = Decimal{}
+		return nil
+	}
+	parsed, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(b []byte) error {
+	parsed, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// objectDecimal returns the decimal at key of the JSON object raw,
+// or converts value if raw is empty or has no decimal there.
+func objectDecimal(raw json.RawMessage, key string, value float64) Decimal {
+	var recv map[string]json.RawMessage
+	if len(raw) > 0 && json.Unmarshal(raw, &recv) == nil {
+		var d Decimal
+		if field, ok := recv[key]; ok && json.Unmarshal(field, &d) == nil {
+			return d
+		}
+	}
+	return NewDecimalFromFloat(value)
+}
+
+// arrayDecimal is like objectDecimal for the element at i of a JSON array.
+func arrayDecimal(raw json.RawMessage, i int, value float64) Decimal {
+	var recv []json.RawMessage
+	if len(raw) > 0 && json.Unmarshal(raw, &recv) == nil && i < len(recv) {
+		var d Decimal
+		if json.Unmarshal(recv[i], &d) == nil {
+			return d
+		}
+	}
+	return NewDecimalFromFloat(value)
+}
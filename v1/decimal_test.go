@@ -0,0 +1,215 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := [...]struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		0:  {in: "384.47", want: "384.47"},
+		1:  {in: "0.0100", want: "0.0100"},
+		2:  {in: "-0.001", want: "-0.001"},
+		3:  {in: "+12", want: "12"},
+		4:  {in: "1e-8", want: "0.00000001"},
+		5:  {in: "1.5E3", want: "1500"},
+		6:  {in: ".5", want: "0.5"},
+		7:  {in: "5.", want: "5"},
+		8:  {in: "123456789012345678901234567890.123456789", want: "123456789012345678901234567890.123456789"},
+		9:  {in: "", wantErr: true},
+		10: {in: ".", wantErr: true},
+		11: {in: "1.2.3", wantErr: true},
+		12: {in: "abc", wantErr: true},
+		13: {in: "1e", wantErr: true},
+		14: {in: "--1", wantErr: true},
+		15: {in: "1e1000", want: "1" + strings.Repeat("0", 1000)},
+		16: {in: "1e-1000", want: "0." + strings.Repeat("0", 999) + "1"},
+		17: {in: "1e1001", wantErr: true},
+		18: {in: "1e-1001", wantErr: true},
+		19: {in: "1e-3000000000", wantErr: true},
+		20: {in: "1e50000000", wantErr: true},
+		21: {in: "1e99999999999999999999", wantErr: true},
+		22: {in: "0." + strings.Repeat("0", 1000) + "1", wantErr: true},
+		23: {in: "0.1e-1000", wantErr: true},
+		24: {in: "10e1000", want: "1" + strings.Repeat("0", 1001)},
+	}
+
+	for i, tt := range tests {
+		d, err := okcoin.ParseDecimal(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error; got %v", i, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: got err: %v", i, err)
+			continue
+		}
+		if g, w := d.String(), tt.want; g != w {
+			t.Errorf("#%d: got=%q want=%q", i, g, w)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := okcoin.MustParseDecimal
+	// Non-constant operands so that the sum is computed in float64.
+	pointOne, pointTwo := 0.1, 0.2
+
+	tests := [...]struct {
+		got  okcoin.Decimal
+		want string
+	}{
+		0:  {got: d("0.1").Add(d("0.2")), want: "0.3"},
+		1:  {got: d("1").Sub(d("0.001")), want: "0.999"},
+		2:  {got: d("384.47").Mul(d("0.5")), want: "192.235"},
+		3:  {got: d("10").Div(d("3"), 4), want: "3.3333"},
+		4:  {got: d("2").Div(d("3"), 2), want: "0.67"},
+		5:  {got: d("-2").Div(d("3"), 2), want: "-0.67"},
+		6:  {got: d("1.005").Round(2), want: "1.01"},
+		7:  {got: d("-1.005").Round(2), want: "-1.01"},
+		8:  {got: d("1.009").Truncate(2), want: "1.00"},
+		9:  {got: d("1.5").Round(3), want: "1.5"},
+		10: {got: d("384.4749").RoundToIncrement(d("0.01")), want: "384.47"},
+		11: {got: d("384.475").RoundToIncrement(d("0.01")), want: "384.48"},
+		12: {got: d("0.3579").FloorToIncrement(d("0.001")), want: "0.357"},
+		13: {got: d("-0.3579").FloorToIncrement(d("0.001")), want: "-0.358"},
+		14: {got: d("7").RoundToIncrement(d("5")), want: "5"},
+		15: {got: d("1.23").RoundToIncrement(okcoin.Decimal{}), want: "1.23"},
+		16: {got: okcoin.Decimal{}, want: "0"},
+		17: {got: okcoin.NewDecimal(-5, 3), want: "-0.005"},
+		18: {got: okcoin.NewDecimalFromFloat(pointOne + pointTwo), want: "0.30000000000000004"},
+		19: {got: okcoin.NewDecimalFromFloat(948.6823), want: "948.6823"},
+		20: {got: d("-1.5").Abs().Neg(), want: "-1.5"},
+	}
+
+	for i, tt := range tests {
+		if g, w := tt.got.String(), tt.want; g != w {
+			t.Errorf("#%d: got=%q want=%q", i, g, w)
+		}
+	}
+
+	if g := d("1.50").Cmp(d("1.5")); g != 0 {
+		t.Errorf("Cmp with trailing zeros: got=%d want=0", g)
+	}
+	if g := d("-0.01").Cmp(d("0")); g != -1 {
+		t.Errorf("Cmp negative: got=%d want=-1", g)
+	}
+	if g, w := d("384.47").Float64(), 384.47; g != w {
+		t.Errorf("Float64: got=%v want=%v", g, w)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	type priced struct {
+		Price  okcoin.Decimal  `json:"price"`
+		Amount okcoin.Decimal  `json:"amount"`
+		Fee    *okcoin.Decimal `json:"fee"`
+	}
+
+	recv := new(priced)
+	if err := json.Unmarshal([]byte(`{"price":"384.470","amount":0.00000001,"fee":null}`), recv); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if g, w := recv.Price.String(), "384.470"; g != w {
+		t.Errorf("price: got=%q want=%q", g, w)
+	}
+	if g, w := recv.Amount.String(), "0.00000001"; g != w {
+		t.Errorf("amount: got=%q want=%q", g, w)
+	}
+
+	blob, err := json.Marshal(recv)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if g, w := string(blob), `{"price":"384.470","amount":"0.00000001","fee":null}`; g != w {
+		t.Errorf("marshal: got=%s want=%s", g, w)
+	}
+
+	if err := json.Unmarshal([]byte(`{"price":"1.2.3"}`), recv); err == nil {
+		t.Errorf("expected an error for a malformed decimal")
+	}
+}
+
+func TestDecimalUnmarshalJSONOutOfRange(t *testing.T) {
+	for i, in := range []string{`"1e-3000000000"`, `1e-3000000000`, `"1e50000000"`} {
+		var d okcoin.Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("#%d %s: want non-nil error; got %v", i, in, d)
+		}
+	}
+}
+
+func TestResponseDecimals(t *testing.T) {
+	// More digits than a float64 holds.
+	const long = "12345678.123456789012"
+
+	var ticker okcoin.Ticker
+	if err := json.Unmarshal([]byte(`{"buy":"`+long+`","last":"0.10","vol":"7"}`), &ticker); err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	if g, w := ticker.BuyDecimal().String(), long; g != w {
+		t.Errorf("ticker buy: got=%q want=%q", g, w)
+	}
+	if g, w := ticker.LastDecimal().String(), "0.10"; g != w {
+		t.Errorf("ticker last: got=%q want=%q", g, w)
+	}
+	if g, w := ticker.Last, 0.1; g != w {
+		t.Errorf("ticker last float: got=%v want=%v", g, w)
+	}
+
+	var trade okcoin.Trade
+	if err := json.Unmarshal([]byte(`{"amount":"0.00000001","price":"`+long+`","tid":1,"type":"buy"}`), &trade); err != nil {
+		t.Fatalf("trade: %v", err)
+	}
+	if p, a := trade.PriceDecimal(), trade.AmountDecimal(); p.String() != long || a.String() != "0.00000001" {
+		t.Errorf("trade: got=%v %v", p, a)
+	}
+
+	var cs okcoin.CandleStick
+	if err := json.Unmarshal([]byte(`[1417564800000,384.47,`+long+`,383.5,387.130,1062.04]`), &cs); err != nil {
+		t.Fatalf("candle stick: %v", err)
+	}
+	if h, c := cs.HighDecimal(), cs.CloseDecimal(); h.String() != long || c.String() != "387.130" {
+		t.Errorf("candle stick: got=%v %v", h, c)
+	}
+
+	var fund okcoin.Fund
+	if err := json.Unmarshal([]byte(`{"btc":"`+long+`","usd":"0.50","net":"1"}`), &fund); err != nil {
+		t.Fatalf("fund: %v", err)
+	}
+	if b, u, n := fund.BalanceDecimal(okcoin.BTC), fund.BalanceDecimal(okcoin.USD), fund.NetDecimal(); b.String() != long || u.String() != "0.50" || n.String() != "1" {
+		t.Errorf("fund: got=%v %v %v", b, u, n)
+	}
+	if !fund.BalanceDecimal(okcoin.LTC).IsZero() || !fund.TotalDecimal().IsZero() {
+		t.Errorf("fund: absent amounts are not 0")
+	}
+
+	// Values that were not decoded are converted from their floats.
+	built := &okcoin.Ticker{Last: 4592.01}
+	if g, w := built.LastDecimal().String(), "4592.01"; g != w {
+		t.Errorf("built ticker: got=%q want=%q", g, w)
+	}
+}
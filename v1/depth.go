@@ -0,0 +1,125 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+type DepthRequest struct {
+	Symbol Symbol `json:"symbol"`
+
+	// Size is the number of price levels per side,
+	// between 1 and 200, defaulting to 200.
+	Size int `json:"size,omitempty"`
+}
+
+// PriceLevel is the amount offered at a price,
+// encoded by the exchange as a [price, amount] pair.
+type PriceLevel struct {
+	Price  float64 `json:"price"`
+	Amount float64 `json:"amount"`
+}
+
+func (pl *PriceLevel) UnmarshalJSON(b []byte) error {
+	var pair []float64
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("expecting a [price, amount] pair, got %d values", len(pair))
+	}
+	pl.Price, pl.Amount = pair[0], pair[1]
+	return nil
+}
+
+func (pl *PriceLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]float64{pl.Price, pl.Amount})
+}
+
+// Depth is a snapshot of the order book with both sides
+// sorted best price first: asks ascending, bids descending.
+type Depth struct {
+	Symbol Symbol        `json:"symbol"`
+	Asks   []*PriceLevel `json:"asks"`
+	Bids   []*PriceLevel `json:"bids"`
+}
+
+// BestAsk returns the lowest ask or nil if there are none.
+func (d *Depth) BestAsk() *PriceLevel {
+	if d == nil || len(d.Asks) == 0 {
+		return nil
+	}
+	return d.Asks[0]
+}
+
+// BestBid returns the highest bid or nil if there are none.
+func (d *Depth) BestBid() *PriceLevel {
+	if d == nil || len(d.Bids) == 0 {
+		return nil
+	}
+	return d.Bids[0]
+}
+
+type depthResponse struct {
+	Asks      []*PriceLevel `json:"asks"`
+	Bids      []*PriceLevel `json:"bids"`
+	ErrorCode int           `json:"error_code"`
+}
+
+const (
+	defaultDepthSize = 200
+	maxDepthSize     = 200
+)
+
+func (c *Client) Depth(dr *DepthRequest) (*Depth, error) {
+	return c.DepthContext(context.Background(), dr)
+}
+
+// DepthContext is like Depth but gives up once ctx is done.
+func (c *Client) DepthContext(ctx context.Context, dr *DepthRequest) (*Depth, error) {
+	if dr == nil || dr.Symbol == "" {
+		return nil, errBlankSymbol
+	}
+	size := dr.Size
+	if size <= 0 || size > maxDepthSize {
+		size = defaultDepthSize
+	}
+	qv := make(url.Values)
+	qv.Set("symbol", string(dr.Symbol))
+	qv.Set("size", strconv.Itoa(size))
+	res, err := c.call(ctx, &Call{Endpoint: "depth.do", Params: qv})
+	if err != nil {
+		return nil, err
+	}
+	dres := new(depthResponse)
+	if err := json.Unmarshal(res.Body, dres); err != nil {
+		return nil, err
+	}
+	// Successful responses carry no "result" field, only failures do.
+	if dres.ErrorCode != 0 {
+		return nil, &APIError{Code: dres.ErrorCode}
+	}
+	depth := &Depth{Symbol: dr.Symbol, Asks: dres.Asks, Bids: dres.Bids}
+	// The exchange lists asks from the highest price down.
+	sort.SliceStable(depth.Asks, func(i, j int) bool { return depth.Asks[i].Price < depth.Asks[j].Price })
+	sort.SliceStable(depth.Bids, func(i, j int) bool { return depth.Bids[i].Price > depth.Bids[j].Price })
+	return depth, nil
+}
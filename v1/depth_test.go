@@ -0,0 +1,83 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestDepth(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&backend{route: depthRoute})
+
+	depth, err := client.Depth(&okcoin.DepthRequest{Symbol: okcoin.BTCUSD, Size: 4})
+	if err != nil {
+		t.Fatalf("depth: %v", err)
+	}
+	if g, w := len(depth.Asks), 4; g != w {
+		t.Fatalf("asks: got=%d want=%d", g, w)
+	}
+	// Asks are flipped to put the best price first.
+	if g, w := *depth.BestAsk(), (okcoin.PriceLevel{Price: 4594.17, Amount: 0.25}); g != w {
+		t.Errorf("best ask: got=%+v want=%+v", g, w)
+	}
+	if g, w := *depth.BestBid(), (okcoin.PriceLevel{Price: 4592.1, Amount: 0.4}); g != w {
+		t.Errorf("best bid: got=%+v want=%+v", g, w)
+	}
+	if g, w := depth.Asks[3].Price, 4610.5; g != w {
+		t.Errorf("worst ask: got=%v want=%v", g, w)
+	}
+
+	if _, err := client.Depth(&okcoin.DepthRequest{Symbol: "fugazi_usd"}); err == nil {
+		t.Errorf("unknown symbol: want non-nil error")
+	} else if ae, ok := err.(*okcoin.APIError); !ok || ae.Code != 10008 {
+		t.Errorf("unknown symbol: got=%v want error_code 10008", err)
+	}
+	if _, err := client.Depth(nil); err == nil {
+		t.Errorf("nil request: want non-nil error")
+	}
+}
+
+func (b *backend) depthRoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return makeResp(fmt.Sprintf(`got method %q want "GET"`, req.Method), http.StatusMethodNotAllowed, nil)
+	}
+	if !strings.HasSuffix(req.URL.Path, "/api/v1/depth.do") {
+		return makeResp(fmt.Sprintf("unknown path %q", req.URL.Path), http.StatusNotFound, nil)
+	}
+	query := req.URL.Query()
+	if size := query.Get("size"); size != "4" && size != "200" {
+		return makeResp(fmt.Sprintf("unexpected size %q", size), http.StatusBadRequest, nil)
+	}
+	symbol := query.Get("symbol")
+	if symbol != "btc_usd" {
+		return jsonResp(`{"result":false,"error_code":10008}`)
+	}
+	return respFromFile(fmt.Sprintf("./testdata/depth-%s.json", symbol))
+}
+
+const (
+	depthRoute = "/depth"
+)
@@ -0,0 +1,86 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// APIError is returned when the exchange answers with
+// {"result":false,"error_code":<Code>}.
+type APIError struct {
+	Code int `json:"error_code"`
+}
+
+var _ error = (*APIError)(nil)
+
+func (ae *APIError) Error() string {
+	if msg := ae.Message(); msg != "" {
+		return fmt.Sprintf("error_code %d: %s", ae.Code, msg)
+	}
+	return fmt.Sprintf("error_code %d", ae.Code)
+}
+
+// Message returns the documented meaning of the error
+// code, or "" if the code is not documented.
+func (ae *APIError) Message() string {
+	return errorCodeMessages[ae.Code]
+}
+
+// As listed at https://www.okcoin.com/rest_request.html
+var errorCodeMessages = map[int]string{
+	10000: "required field can not be null",
+	10001: "request frequency too high",
+	10002: "system error",
+	10004: "request failed",
+	10005: "secret key does not exist",
+	10006: "api key does not exist",
+	10007: "signature does not match",
+	10008: "illegal parameter",
+	10009: "order does not exist",
+	10010: "insufficient funds",
+	10011: "amount too low",
+	10012: "only btc_usd and ltc_usd are supported",
+	10013: "only https requests are supported",
+	10014: "order price must be between 0 and 1,000,000",
+	10015: "order price differs from current market price too much",
+	10016: "insufficient coins balance",
+	10017: "API authorization error",
+	10216: "non-public API",
+}
+
+type apiResult struct {
+	Result    bool `json:"result"`
+	ErrorCode int  `json:"error_code"`
+}
+
+var errUnsuccessfulResult = errors.New("unsuccessful result without an error_code")
+
+func resultError(blob []byte) error {
+	res := new(apiResult)
+	if err := json.Unmarshal(blob, res); err != nil {
+		return err
+	}
+	switch {
+	case res.ErrorCode != 0:
+		return &APIError{Code: res.ErrorCode}
+	case !res.Result:
+		return errUnsuccessfulResult
+	default:
+		return nil
+	}
+}
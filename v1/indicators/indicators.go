@@ -0,0 +1,102 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package indicators computes technical indicators over okcoin candle sticks.
+//
+// Every indicator comes as a batch function over a []*okcoin.CandleStick
+// and as a streaming calculator that is fed one candle stick at a time.
+// Batch results are aligned with their input: the value at index i is the
+// indicator as of candle stick i, and NaN until enough candle sticks have
+// been seen. Streaming calculators likewise return NaN while warming up.
+package indicators
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+var errNilCandleStick = errors.New("expecting non-nil candle sticks")
+
+func checkPeriod(name string, n int) error {
+	if n <= 0 {
+		return fmt.Errorf("%s: expecting a positive period, got %d", name, n)
+	}
+	return nil
+}
+
+func nans(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+// valueStream is implemented by the calculators of indicators
+// that only depend on a single value per candle stick.
+type valueStream interface {
+	AddValue(v float64) float64
+}
+
+// closes runs the candle sticks' closing prices through vs.
+func closes(csticks []*okcoin.CandleStick, vs valueStream) ([]float64, error) {
+	out := make([]float64, len(csticks))
+	for i, cs := range csticks {
+		if cs == nil {
+			return nil, errNilCandleStick
+		}
+		out[i] = vs.AddValue(cs.Close)
+	}
+	return out, nil
+}
+
+// window is a fixed size ring buffer of the most recent values.
+type window struct {
+	values []float64
+	next   int
+	count  int
+}
+
+func newWindow(n int) *window {
+	return &window{values: make([]float64, n)}
+}
+
+// push adds v, returning the value it evicted and whether there was one.
+func (w *window) push(v float64) (evicted float64, ok bool) {
+	if w.full() {
+		evicted, ok = w.values[w.next], true
+	}
+	w.values[w.next] = v
+	w.next = (w.next + 1) % len(w.values)
+	if w.count < len(w.values) {
+		w.count++
+	}
+	return evicted, ok
+}
+
+func (w *window) full() bool { return w.count == len(w.values) }
+
+// each calls fn with the values from oldest to newest.
+func (w *window) each(fn func(i int, v float64)) {
+	start := 0
+	if w.full() {
+		start = w.next
+	}
+	for i := 0; i < w.count; i++ {
+		fn(i, w.values[(start+i)%len(w.values)])
+	}
+}
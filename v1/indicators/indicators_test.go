@@ -0,0 +1,207 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indicators_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/indicators"
+)
+
+var nan = math.NaN()
+
+func fromCloses(closes ...float64) []*okcoin.CandleStick {
+	var csticks []*okcoin.CandleStick
+	for _, c := range closes {
+		csticks = append(csticks, &okcoin.CandleStick{Open: c, High: c, Low: c, Close: c, Volume: 1})
+	}
+	return csticks
+}
+
+// hlc returns candle sticks from triples of high, low and close.
+func hlc(values ...float64) []*okcoin.CandleStick {
+	var csticks []*okcoin.CandleStick
+	for i := 0; i+2 < len(values); i += 3 {
+		csticks = append(csticks, &okcoin.CandleStick{High: values[i], Low: values[i+1], Close: values[i+2]})
+	}
+	return csticks
+}
+
+func closeEnough(got, want float64) bool {
+	if math.IsNaN(want) {
+		return math.IsNaN(got)
+	}
+	return math.Abs(got-want) < 1e-9
+}
+
+func checkSeries(t *testing.T, name string, got []float64, want ...float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d values want %d", name, len(got), len(want))
+		return
+	}
+	for i := range want {
+		if !closeEnough(got[i], want[i]) {
+			t.Errorf("%s: #%d: got=%v want=%v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestMovingAverages(t *testing.T) {
+	csticks := fromCloses(1, 2, 3, 4, 5)
+
+	sma, err := indicators.SMA(csticks, 3)
+	if err != nil {
+		t.Fatalf("SMA: %v", err)
+	}
+	checkSeries(t, "SMA", sma, nan, nan, 2, 3, 4)
+
+	ema, err := indicators.EMA(csticks, 3)
+	if err != nil {
+		t.Fatalf("EMA: %v", err)
+	}
+	checkSeries(t, "EMA", ema, nan, nan, 2, 3, 4)
+
+	wma, err := indicators.WMA(csticks, 3)
+	if err != nil {
+		t.Fatalf("WMA: %v", err)
+	}
+	checkSeries(t, "WMA", wma, nan, nan, 14.0/6, 20.0/6, 26.0/6)
+
+	// A jump tells the averages apart.
+	ema, _ = indicators.EMA(fromCloses(1, 1, 1, 9), 3)
+	checkSeries(t, "EMA jump", ema, nan, nan, 1, 5)
+}
+
+func TestRSI(t *testing.T) {
+	rsi, err := indicators.RSI(fromCloses(1, 2, 1, 2, 3), 2)
+	if err != nil {
+		t.Fatalf("RSI: %v", err)
+	}
+	checkSeries(t, "RSI", rsi, nan, nan, 50, 75, 87.5)
+
+	flat, _ := indicators.RSI(fromCloses(1, 1, 1), 2)
+	checkSeries(t, "RSI flat", flat, nan, nan, 50)
+	rising, _ := indicators.RSI(fromCloses(1, 2, 3), 2)
+	checkSeries(t, "RSI rising", rising, nan, nan, 100)
+}
+
+func TestMACD(t *testing.T) {
+	macd, err := indicators.MACD(fromCloses(1, 2, 3, 4, 5, 6), 2, 3, 2)
+	if err != nil {
+		t.Fatalf("MACD: %v", err)
+	}
+	var lines, signals, hists []float64
+	for _, mv := range macd {
+		lines = append(lines, mv.MACD)
+		signals = append(signals, mv.Signal)
+		hists = append(hists, mv.Histogram)
+	}
+	checkSeries(t, "MACD", lines, nan, nan, 0.5, 0.5, 0.5, 0.5)
+	checkSeries(t, "MACD signal", signals, nan, nan, nan, 0.5, 0.5, 0.5)
+	checkSeries(t, "MACD histogram", hists, nan, nan, nan, 0, 0, 0)
+
+	if _, err := indicators.MACD(nil, 26, 12, 9); err == nil {
+		t.Errorf("expected an error for a fast period above the slow one")
+	}
+}
+
+func TestStochastic(t *testing.T) {
+	stoch, err := indicators.Stochastic(hlc(10, 8, 9, 11, 9, 10, 12, 10, 11, 12, 6, 7), 3, 2)
+	if err != nil {
+		t.Fatalf("Stochastic: %v", err)
+	}
+	var ks, ds []float64
+	for _, sv := range stoch {
+		ks = append(ks, sv.K)
+		ds = append(ds, sv.D)
+	}
+	checkSeries(t, "%K", ks, nan, nan, 75, 100.0/6)
+	checkSeries(t, "%D", ds, nan, nan, nan, (75+100.0/6)/2)
+}
+
+func TestBollinger(t *testing.T) {
+	bands, err := indicators.Bollinger(fromCloses(1, 2, 3), 3, 2)
+	if err != nil {
+		t.Fatalf("Bollinger: %v", err)
+	}
+	band := 2 * math.Sqrt(2.0/3)
+	var uppers, middles, lowers []float64
+	for _, bv := range bands {
+		uppers = append(uppers, bv.Upper)
+		middles = append(middles, bv.Middle)
+		lowers = append(lowers, bv.Lower)
+	}
+	checkSeries(t, "upper", uppers, nan, nan, 2+band)
+	checkSeries(t, "middle", middles, nan, nan, 2)
+	checkSeries(t, "lower", lowers, nan, nan, 2-band)
+}
+
+func TestATR(t *testing.T) {
+	atr, err := indicators.ATR(hlc(10, 8, 9, 11, 9, 10, 15, 12, 14), 2)
+	if err != nil {
+		t.Fatalf("ATR: %v", err)
+	}
+	checkSeries(t, "ATR", atr, nan, 2, 3.5)
+}
+
+func TestOBV(t *testing.T) {
+	csticks := fromCloses(1, 2, 2, 1)
+	for i, cs := range csticks {
+		cs.Volume = float64(10 * (i + 1))
+	}
+	obv, err := indicators.OBV(csticks)
+	if err != nil {
+		t.Fatalf("OBV: %v", err)
+	}
+	checkSeries(t, "OBV", obv, 0, 20, 20, -20)
+}
+
+func TestStreamingMatchesBatch(t *testing.T) {
+	csticks := fromCloses(4, 8, 6, 5, 9, 12, 11, 7, 6, 10)
+	batch, err := indicators.EMA(csticks, 4)
+	if err != nil {
+		t.Fatalf("EMA: %v", err)
+	}
+	stream, err := indicators.NewStreamingEMA(4)
+	if err != nil {
+		t.Fatalf("NewStreamingEMA: %v", err)
+	}
+	for i, cs := range csticks {
+		if g, w := stream.Add(cs), batch[i]; !closeEnough(g, w) {
+			t.Errorf("#%d: streaming=%v batch=%v", i, g, w)
+		}
+	}
+}
+
+func TestInvalidInput(t *testing.T) {
+	if _, err := indicators.SMA(nil, 0); err == nil {
+		t.Errorf("SMA: expected an error for a zero period")
+	}
+	if _, err := indicators.NewStreamingRSI(-1); err == nil {
+		t.Errorf("RSI: expected an error for a negative period")
+	}
+	if _, err := indicators.Stochastic(nil, 14, 0); err == nil {
+		t.Errorf("Stochastic: expected an error for a zero %%D period")
+	}
+	if _, err := indicators.ATR([]*okcoin.CandleStick{nil}, 14); err == nil {
+		t.Errorf("ATR: expected an error for a nil candle stick")
+	}
+	if _, err := indicators.WMA([]*okcoin.CandleStick{nil}, 14); err == nil {
+		t.Errorf("WMA: expected an error for a nil candle stick")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indicators
+
+import (
+	"math"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// StreamingSMA is the simple moving average of the last n values.
+type StreamingSMA struct {
+	w   *window
+	sum float64
+}
+
+func NewStreamingSMA(n int) (*StreamingSMA, error) {
+	if err := checkPeriod("SMA", n); err != nil {
+		return nil, err
+	}
+	return &StreamingSMA{w: newWindow(n)}, nil
+}
+
+func (s *StreamingSMA) Add(cs *okcoin.CandleStick) float64 { return s.AddValue(cs.Close) }
+
+func (s *StreamingSMA) AddValue(v float64) float64 {
+	if evicted, ok := s.w.push(v); ok {
+		s.sum -= evicted
+	}
+	s.sum += v
+	if !s.w.full() {
+		return math.NaN()
+	}
+	return s.sum / float64(s.w.count)
+}
+
+// SMA returns the n period simple moving average of the closing prices.
+func SMA(csticks []*okcoin.CandleStick, n int) ([]float64, error) {
+	s, err := NewStreamingSMA(n)
+	if err != nil {
+		return nil, err
+	}
+	return closes(csticks, s)
+}
+
+// StreamingEMA is the exponential moving average with a smoothing
+// factor of 2/(n+1), seeded with the simple average of the first n values.
+type StreamingEMA struct {
+	alpha float64
+	seed  *StreamingSMA
+	value float64
+	ready bool
+}
+
+func NewStreamingEMA(n int) (*StreamingEMA, error) {
+	seed, err := NewStreamingSMA(n)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamingEMA{alpha: 2 / float64(n+1), seed: seed}, nil
+}
+
+func (e *StreamingEMA) Add(cs *okcoin.CandleStick) float64 { return e.AddValue(cs.Close) }
+
+func (e *StreamingEMA) AddValue(v float64) float64 {
+	if !e.ready {
+		e.value = e.seed.AddValue(v)
+		e.ready = !math.IsNaN(e.value)
+		return e.value
+	}
+	e.value += e.alpha * (v - e.value)
+	return e.value
+}
+
+// EMA returns the n period exponential moving average of the closing prices.
+func EMA(csticks []*okcoin.CandleStick, n int) ([]float64, error) {
+	e, err := NewStreamingEMA(n)
+	if err != nil {
+		return nil, err
+	}
+	return closes(csticks, e)
+}
+
+// StreamingWMA is the linearly weighted moving average of the last n
+// values, the newest weighing n and the oldest 1.
+type StreamingWMA struct {
+	w *window
+}
+
+func NewStreamingWMA(n int) (*StreamingWMA, error) {
+	if err := checkPeriod("WMA", n); err != nil {
+		return nil, err
+	}
+	return &StreamingWMA{w: newWindow(n)}, nil
+}
+
+func (wm *StreamingWMA) Add(cs *okcoin.CandleStick) float64 { return wm.AddValue(cs.Close) }
+
+func (wm *StreamingWMA) AddValue(v float64) float64 {
+	wm.w.push(v)
+	if !wm.w.full() {
+		return math.NaN()
+	}
+	var sum float64
+	wm.w.each(func(i int, v float64) {
+		sum += float64(i+1) * v
+	})
+	n := float64(wm.w.count)
+	return sum / (n * (n + 1) / 2)
+}
+
+// WMA returns the n period weighted moving average of the closing prices.
+func WMA(csticks []*okcoin.CandleStick, n int) ([]float64, error) {
+	wm, err := NewStreamingWMA(n)
+	if err != nil {
+		return nil, err
+	}
+	return closes(csticks, wm)
+}
@@ -0,0 +1,213 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indicators
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// StreamingRSI is the relative strength index using Wilder's smoothing
+// of the average gains and losses. The first value needs n+1 values.
+type StreamingRSI struct {
+	n int
+
+	prev     float64
+	count    int
+	avgGain  float64
+	avgLoss  float64
+	warmGain float64
+	warmLoss float64
+}
+
+func NewStreamingRSI(n int) (*StreamingRSI, error) {
+	if err := checkPeriod("RSI", n); err != nil {
+		return nil, err
+	}
+	return &StreamingRSI{n: n}, nil
+}
+
+func (r *StreamingRSI) Add(cs *okcoin.CandleStick) float64 { return r.AddValue(cs.Close) }
+
+func (r *StreamingRSI) AddValue(v float64) float64 {
+	r.count++
+	if r.count == 1 {
+		r.prev = v
+		return math.NaN()
+	}
+	change := v - r.prev
+	r.prev = v
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	n := float64(r.n)
+	switch {
+	case r.count <= r.n:
+		r.warmGain += gain
+		r.warmLoss += loss
+		return math.NaN()
+	case r.count == r.n+1:
+		r.avgGain = (r.warmGain + gain) / n
+		r.avgLoss = (r.warmLoss + loss) / n
+	default:
+		r.avgGain = (r.avgGain*(n-1) + gain) / n
+		r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+	}
+
+	switch {
+	case r.avgLoss == 0 && r.avgGain == 0:
+		return 50
+	case r.avgLoss == 0:
+		return 100
+	}
+	return 100 - 100/(1+r.avgGain/r.avgLoss)
+}
+
+// RSI returns the n period relative strength index of the closing prices.
+func RSI(csticks []*okcoin.CandleStick, n int) ([]float64, error) {
+	r, err := NewStreamingRSI(n)
+	if err != nil {
+		return nil, err
+	}
+	return closes(csticks, r)
+}
+
+type MACDValue struct {
+	MACD      float64 `json:"macd"`
+	Signal    float64 `json:"signal"`
+	Histogram float64 `json:"histogram"`
+}
+
+// StreamingMACD is the moving average convergence divergence: the
+// difference between the fast and slow EMAs, its signal line EMA
+// and the histogram of their difference.
+type StreamingMACD struct {
+	fast, slow, signal *StreamingEMA
+}
+
+func NewStreamingMACD(fast, slow, signal int) (*StreamingMACD, error) {
+	if fast >= slow {
+		return nil, fmt.Errorf("MACD: expecting the fast period %d to be less than the slow period %d", fast, slow)
+	}
+	fastEMA, err := NewStreamingEMA(fast)
+	if err != nil {
+		return nil, err
+	}
+	slowEMA, err := NewStreamingEMA(slow)
+	if err != nil {
+		return nil, err
+	}
+	signalEMA, err := NewStreamingEMA(signal)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamingMACD{fast: fastEMA, slow: slowEMA, signal: signalEMA}, nil
+}
+
+func (m *StreamingMACD) Add(cs *okcoin.CandleStick) MACDValue { return m.AddValue(cs.Close) }
+
+func (m *StreamingMACD) AddValue(v float64) MACDValue {
+	fast, slow := m.fast.AddValue(v), m.slow.AddValue(v)
+	mv := MACDValue{MACD: math.NaN(), Signal: math.NaN(), Histogram: math.NaN()}
+	if math.IsNaN(fast) || math.IsNaN(slow) {
+		return mv
+	}
+	mv.MACD = fast - slow
+	mv.Signal = m.signal.AddValue(mv.MACD)
+	if !math.IsNaN(mv.Signal) {
+		mv.Histogram = mv.MACD - mv.Signal
+	}
+	return mv
+}
+
+// MACD returns the MACD of the closing prices, conventionally with
+// periods of 12, 26 and 9.
+func MACD(csticks []*okcoin.CandleStick, fast, slow, signal int) ([]MACDValue, error) {
+	m, err := NewStreamingMACD(fast, slow, signal)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MACDValue, len(csticks))
+	for i, cs := range csticks {
+		if cs == nil {
+			return nil, errNilCandleStick
+		}
+		out[i] = m.Add(cs)
+	}
+	return out, nil
+}
+
+type StochasticValue struct {
+	K float64 `json:"k"`
+	D float64 `json:"d"`
+}
+
+// StreamingStochastic is the stochastic oscillator: %K locates the close
+// within the range of the last kPeriod candle sticks and %D is the simple
+// average of the last dPeriod %K values.
+type StreamingStochastic struct {
+	highs, lows *window
+	d           *StreamingSMA
+}
+
+func NewStreamingStochastic(kPeriod, dPeriod int) (*StreamingStochastic, error) {
+	if err := checkPeriod("Stochastic %K", kPeriod); err != nil {
+		return nil, err
+	}
+	d, err := NewStreamingSMA(dPeriod)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamingStochastic{highs: newWindow(kPeriod), lows: newWindow(kPeriod), d: d}, nil
+}
+
+func (s *StreamingStochastic) Add(cs *okcoin.CandleStick) StochasticValue {
+	s.highs.push(cs.High)
+	s.lows.push(cs.Low)
+	sv := StochasticValue{K: math.NaN(), D: math.NaN()}
+	if !s.highs.full() {
+		return sv
+	}
+
+	highest, lowest := math.Inf(-1), math.Inf(1)
+	s.highs.each(func(_ int, v float64) { highest = math.Max(highest, v) })
+	s.lows.each(func(_ int, v float64) { lowest = math.Min(lowest, v) })
+	if highest == lowest {
+		// A flat range leaves the close in the middle.
+		sv.K = 50
+	} else {
+		sv.K = 100 * (cs.Close - lowest) / (highest - lowest)
+	}
+	sv.D = s.d.AddValue(sv.K)
+	return sv
+}
+
+// Stochastic returns the stochastic oscillator, conventionally with
+// periods of 14 and 3.
+func Stochastic(csticks []*okcoin.CandleStick, kPeriod, dPeriod int) ([]StochasticValue, error) {
+	s, err := NewStreamingStochastic(kPeriod, dPeriod)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StochasticValue, len(csticks))
+	for i, cs := range csticks {
+		if cs == nil {
+			return nil, errNilCandleStick
+		}
+		out[i] = s.Add(cs)
+	}
+	return out, nil
+}
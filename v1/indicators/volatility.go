@@ -0,0 +1,130 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indicators
+
+import (
+	"math"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+type BollingerValue struct {
+	Upper  float64 `json:"upper"`
+	Middle float64 `json:"middle"`
+	Lower  float64 `json:"lower"`
+}
+
+// StreamingBollinger computes Bollinger Bands: the n period simple moving
+// average with bands k population standard deviations above and below it.
+type StreamingBollinger struct {
+	k float64
+	w *window
+}
+
+func NewStreamingBollinger(n int, k float64) (*StreamingBollinger, error) {
+	if err := checkPeriod("Bollinger", n); err != nil {
+		return nil, err
+	}
+	return &StreamingBollinger{k: k, w: newWindow(n)}, nil
+}
+
+func (b *StreamingBollinger) Add(cs *okcoin.CandleStick) BollingerValue { return b.AddValue(cs.Close) }
+
+func (b *StreamingBollinger) AddValue(v float64) BollingerValue {
+	b.w.push(v)
+	if !b.w.full() {
+		return BollingerValue{Upper: math.NaN(), Middle: math.NaN(), Lower: math.NaN()}
+	}
+	n := float64(b.w.count)
+	var sum float64
+	b.w.each(func(_ int, v float64) { sum += v })
+	mean := sum / n
+	var sqDiffs float64
+	b.w.each(func(_ int, v float64) { sqDiffs += (v - mean) * (v - mean) })
+	band := b.k * math.Sqrt(sqDiffs/n)
+	return BollingerValue{Upper: mean + band, Middle: mean, Lower: mean - band}
+}
+
+// Bollinger returns the Bollinger Bands of the closing prices,
+// conventionally with n=20 and k=2.
+func Bollinger(csticks []*okcoin.CandleStick, n int, k float64) ([]BollingerValue, error) {
+	b, err := NewStreamingBollinger(n, k)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]BollingerValue, len(csticks))
+	for i, cs := range csticks {
+		if cs == nil {
+			return nil, errNilCandleStick
+		}
+		out[i] = b.Add(cs)
+	}
+	return out, nil
+}
+
+// StreamingATR is the average true range using Wilder's smoothing,
+// seeded with the simple average of the first n true ranges.
+type StreamingATR struct {
+	n int
+
+	prevClose float64
+	count     int
+	warmSum   float64
+	value     float64
+}
+
+func NewStreamingATR(n int) (*StreamingATR, error) {
+	if err := checkPeriod("ATR", n); err != nil {
+		return nil, err
+	}
+	return &StreamingATR{n: n}, nil
+}
+
+func (a *StreamingATR) Add(cs *okcoin.CandleStick) float64 {
+	tr := cs.High - cs.Low
+	if a.count > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(cs.High-a.prevClose), math.Abs(cs.Low-a.prevClose)))
+	}
+	a.prevClose = cs.Close
+	a.count++
+
+	n := float64(a.n)
+	switch {
+	case a.count < a.n:
+		a.warmSum += tr
+		return math.NaN()
+	case a.count == a.n:
+		a.value = (a.warmSum + tr) / n
+	default:
+		a.value = (a.value*(n-1) + tr) / n
+	}
+	return a.value
+}
+
+// ATR returns the n period average true range.
+func ATR(csticks []*okcoin.CandleStick, n int) ([]float64, error) {
+	a, err := NewStreamingATR(n)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, len(csticks))
+	for i, cs := range csticks {
+		if cs == nil {
+			return nil, errNilCandleStick
+		}
+		out[i] = a.Add(cs)
+	}
+	return out, nil
+}
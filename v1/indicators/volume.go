@@ -0,0 +1,57 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indicators
+
+import "github.com/orijtech/okcoin/v1"
+
+// StreamingOBV is the on-balance volume: a running total that adds the
+// volume of candle sticks closing higher than the previous one and
+// subtracts that of those closing lower. It starts at 0.
+type StreamingOBV struct {
+	prevClose float64
+	started   bool
+	value     float64
+}
+
+func NewStreamingOBV() *StreamingOBV {
+	return new(StreamingOBV)
+}
+
+func (o *StreamingOBV) Add(cs *okcoin.CandleStick) float64 {
+	if o.started {
+		switch {
+		case cs.Close > o.prevClose:
+			o.value += cs.Volume
+		case cs.Close < o.prevClose:
+			o.value -= cs.Volume
+		}
+	}
+	o.started = true
+	o.prevClose = cs.Close
+	return o.value
+}
+
+// OBV returns the on-balance volume.
+func OBV(csticks []*okcoin.CandleStick) ([]float64, error) {
+	o := NewStreamingOBV()
+	out := make([]float64, len(csticks))
+	for i, cs := range csticks {
+		if cs == nil {
+			return nil, errNilCandleStick
+		}
+		out[i] = o.Add(cs)
+	}
+	return out, nil
+}
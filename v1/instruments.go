@@ -0,0 +1,203 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Instrument struct {
+	ID            string  `json:"instrument_id"`
+	BaseCurrency  string  `json:"base_currency"`
+	QuoteCurrency string  `json:"quote_currency"`
+	TickSize      Decimal `json:"tick_size"`
+	LotSize       Decimal `json:"size_increment"`
+	MinAmount     Decimal `json:"min_size"`
+}
+
+// Symbol returns the v1 symbol of the instrument e.g. "btc_usd" for "BTC-USD".
+func (in *Instrument) Symbol() Symbol {
+	return Symbol(strings.ToLower(strings.Replace(in.ID, "-", "_", -1)))
+}
+
+var errNoInstrumentsReturned = errors.New("no instruments returned")
+
+func (c *Client) Instruments() ([]*Instrument, error) {
+	// The v1 API has no listing endpoint so the instrument
+	// metadata is retrieved from the spot v3 API which is public.
+	res, err := c.call(context.Background(), &Call{BaseURL: SpotV3BaseURL, Endpoint: "instruments"})
+	if err != nil {
+		return nil, err
+	}
+	var instruments []*Instrument
+	if err := json.Unmarshal(res.Body, &instruments); err != nil {
+		return nil, err
+	}
+	if len(instruments) == 0 {
+		return nil, errNoInstrumentsReturned
+	}
+	return instruments, nil
+}
+
+// RoundPrice rounds price to the nearest multiple of the tick size.
+func (in *Instrument) RoundPrice(price Decimal) Decimal {
+	return price.RoundToIncrement(in.TickSize)
+}
+
+// RoundAmount rounds amount down to a multiple of the lot size so
+// that the rounded amount never exceeds what was asked for.
+func (in *Instrument) RoundAmount(amount Decimal) Decimal {
+	return amount.FloorToIncrement(in.LotSize)
+}
+
+// Validate reports whether price and amount are acceptable for an
+// order on the instrument i.e. on the tick and lot increments and with
+// the amount at least the minimum order amount.
+func (in *Instrument) Validate(price, amount Decimal) error {
+	if price.Sign() <= 0 {
+		return fmt.Errorf("%s: price must be positive, got %v", in.ID, price)
+	}
+	if err := in.validateAmount(amount); err != nil {
+		return err
+	}
+	if rounded := in.RoundPrice(price); !rounded.Equal(price) {
+		return fmt.Errorf("%s: price %v is not a multiple of the tick size %v", in.ID, price, in.TickSize)
+	}
+	return nil
+}
+
+func (in *Instrument) validateAmount(amount Decimal) error {
+	if amount.Cmp(in.MinAmount) < 0 {
+		return fmt.Errorf("%s: amount %v is below the minimum of %v", in.ID, amount, in.MinAmount)
+	}
+	if rounded := in.RoundAmount(amount); !rounded.Equal(amount) {
+		return fmt.Errorf("%s: amount %v is not a multiple of the lot size %v", in.ID, amount, in.LotSize)
+	}
+	return nil
+}
+
+// RoundOrder returns a copy of oreq with its price rounded to the tick
+// size and its amount rounded down to the lot size, or an error if the
+// rounded order is not valid for the instrument. The price of a market
+// buy is an amount of the quote currency to spend and is left as is.
+func (in *Instrument) RoundOrder(oreq *OrderRequest) (*OrderRequest, error) {
+	if err := oreq.Validate(); err != nil {
+		return nil, err
+	}
+	if oreq.Symbol != in.Symbol() {
+		return nil, fmt.Errorf("%s: order is for %q", in.ID, oreq.Symbol)
+	}
+	rounded := *oreq
+	switch oreq.Type {
+	case Buy, Sell:
+		rounded.Price = in.RoundPrice(oreq.Price)
+		rounded.Amount = in.RoundAmount(oreq.Amount)
+		if err := in.Validate(rounded.Price, rounded.Amount); err != nil {
+			return nil, err
+		}
+	case SellMarket:
+		rounded.Amount = in.RoundAmount(oreq.Amount)
+		if err := in.validateAmount(rounded.Amount); err != nil {
+			return nil, err
+		}
+	}
+	return &rounded, nil
+}
+
+// InstrumentRegistry caches the instruments listed by the exchange,
+// refreshing them once they are older than the configured TTL.
+type InstrumentRegistry struct {
+	client *Client
+	ttl    time.Duration
+
+	mu          sync.RWMutex
+	lastFetch   time.Time
+	instruments map[Symbol]*Instrument
+}
+
+const defaultInstrumentsTTL = 1 * time.Hour
+
+func NewInstrumentRegistry(c *Client, ttl time.Duration) *InstrumentRegistry {
+	if ttl <= 0 {
+		ttl = defaultInstrumentsTTL
+	}
+	return &InstrumentRegistry{client: c, ttl: ttl}
+}
+
+// Refresh unconditionally reloads the instruments from the exchange.
+func (r *InstrumentRegistry) Refresh() error {
+	instruments, err := r.client.Instruments()
+	if err != nil {
+		return err
+	}
+	bySymbol := make(map[Symbol]*Instrument, len(instruments))
+	for _, in := range instruments {
+		bySymbol[in.Symbol()] = in
+	}
+
+	r.mu.Lock()
+	r.instruments = bySymbol
+	r.lastFetch = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *InstrumentRegistry) refreshIfStale() error {
+	r.mu.RLock()
+	stale := r.instruments == nil || time.Since(r.lastFetch) >= r.ttl
+	r.mu.RUnlock()
+	if !stale {
+		return nil
+	}
+	return r.Refresh()
+}
+
+func (r *InstrumentRegistry) Lookup(sym Symbol) (*Instrument, error) {
+	if sym == "" {
+		return nil, errBlankSymbol
+	}
+	if err := r.refreshIfStale(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	in, ok := r.instruments[sym]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown symbol %q", sym)
+	}
+	return in, nil
+}
+
+// Symbols returns the sorted symbols of all the known instruments.
+func (r *InstrumentRegistry) Symbols() ([]Symbol, error) {
+	if err := r.refreshIfStale(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	symbols := make([]Symbol, 0, len(r.instruments))
+	for sym := range r.instruments {
+		symbols = append(symbols, sym)
+	}
+	r.mu.RUnlock()
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i] < symbols[j] })
+	return symbols, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestInstrumentRegistry(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&backend{route: instrumentsRoute})
+
+	registry := okcoin.NewInstrumentRegistry(client, 0)
+	symbols, err := registry.Symbols()
+	if err != nil {
+		t.Fatalf("symbols: %v", err)
+	}
+	wantSymbols := []okcoin.Symbol{"btc_usd", "eth_btc", "eth_usd", "ltc_usd"}
+	if !reflect.DeepEqual(symbols, wantSymbols) {
+		t.Errorf("symbols: got=%v want=%v", symbols, wantSymbols)
+	}
+
+	tests := [...]struct {
+		symbol  okcoin.Symbol
+		want    *okcoin.Instrument
+		wantErr bool
+	}{
+		0: {symbol: "", wantErr: true},
+		1: {symbol: okcoin.BCCUSD, wantErr: true},
+		2: {
+			symbol: okcoin.BTCUSD,
+			want: &okcoin.Instrument{
+				ID: "BTC-USD", BaseCurrency: "BTC", QuoteCurrency: "USD",
+				TickSize:  okcoin.MustParseDecimal("0.01"),
+				LotSize:   okcoin.MustParseDecimal("0.0001"),
+				MinAmount: okcoin.MustParseDecimal("0.001"),
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		in, err := registry.Lookup(tt.symbol)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error; got %#v", i, in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: got err: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(in, tt.want) {
+			t.Errorf("#%d:\ngot= %#v\nwant=%#v", i, in, tt.want)
+		}
+	}
+}
+
+func TestInstrumentRounding(t *testing.T) {
+	in := &okcoin.Instrument{
+		ID:        "LTC-USD",
+		TickSize:  okcoin.MustParseDecimal("0.001"),
+		LotSize:   okcoin.MustParseDecimal("0.1"),
+		MinAmount: okcoin.MustParseDecimal("0.1"),
+	}
+
+	tests := [...]struct {
+		price, amount         string
+		wantPrice, wantAmount string
+		wantErr               bool
+	}{
+		0: {price: "50.1234", amount: "0.35", wantPrice: "50.123", wantAmount: "0.3", wantErr: true},
+		1: {price: "50.1236", amount: "0.3", wantPrice: "50.124", wantAmount: "0.3", wantErr: true},
+		2: {price: "50.123", amount: "0.3", wantPrice: "50.123", wantAmount: "0.3"},
+		3: {price: "50.123", amount: "0.05", wantPrice: "50.123", wantAmount: "0", wantErr: true},
+		4: {price: "0", amount: "1", wantPrice: "0", wantAmount: "1", wantErr: true},
+	}
+
+	for i, tt := range tests {
+		price, amount := okcoin.MustParseDecimal(tt.price), okcoin.MustParseDecimal(tt.amount)
+		if g, w := in.RoundPrice(price), okcoin.MustParseDecimal(tt.wantPrice); !g.Equal(w) {
+			t.Errorf("#%d: price: got=%v want=%v", i, g, w)
+		}
+		if g, w := in.RoundAmount(amount), okcoin.MustParseDecimal(tt.wantAmount); !g.Equal(w) {
+			t.Errorf("#%d: amount: got=%v want=%v", i, g, w)
+		}
+		err := in.Validate(price, amount)
+		if tt.wantErr && err == nil {
+			t.Errorf("#%d: expected a validation error", i)
+		} else if !tt.wantErr && err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+		}
+	}
+}
+
+func TestInstrumentRoundOrder(t *testing.T) {
+	// 0.0001 has no exact float64, so 0.3 / 0.0001 is 2999.9999999999995
+	// in floating point and flooring it would lose a whole lot.
+	in := &okcoin.Instrument{
+		ID:        "BTC-USD",
+		TickSize:  okcoin.MustParseDecimal("0.01"),
+		LotSize:   okcoin.MustParseDecimal("0.0001"),
+		MinAmount: okcoin.MustParseDecimal("0.001"),
+	}
+
+	tests := [...]struct {
+		req        *okcoin.OrderRequest
+		wantPrice  string
+		wantAmount string
+		wantErr    string
+	}{
+		0: {
+			req:        &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.Buy, Price: okcoin.MustParseDecimal("4594.176"), Amount: okcoin.MustParseDecimal("0.3")},
+			wantPrice:  "4594.18",
+			wantAmount: "0.3",
+		},
+		1: {
+			req:        &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.Sell, Price: okcoin.MustParseDecimal("4594.17"), Amount: okcoin.MustParseDecimal("0.30009")},
+			wantPrice:  "4594.17",
+			wantAmount: "0.3",
+		},
+		2: {
+			req:        &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.SellMarket, Amount: okcoin.MustParseDecimal("0.12345")},
+			wantPrice:  "0",
+			wantAmount: "0.1234",
+		},
+		3: {
+			// The quote amount of a market buy has no tick size.
+			req:        &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.BuyMarket, Price: okcoin.MustParseDecimal("100.005")},
+			wantPrice:  "100.005",
+			wantAmount: "0",
+		},
+		4: {
+			req:     &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.Buy, Price: okcoin.MustParseDecimal("4594.17"), Amount: okcoin.MustParseDecimal("0.00099")},
+			wantErr: "below the minimum",
+		},
+		5: {
+			req:     &okcoin.OrderRequest{Symbol: okcoin.LTCUSD, Type: okcoin.Buy, Price: okcoin.MustParseDecimal("50"), Amount: okcoin.MustParseDecimal("1")},
+			wantErr: "order is for",
+		},
+		6: {
+			req:     &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.Buy, Amount: okcoin.MustParseDecimal("1")},
+			wantErr: "positive price",
+		},
+	}
+
+	for i, tt := range tests {
+		got, err := in.RoundOrder(tt.req)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("#%d: got err=%v want it to mention %q", i, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		if g, w := got.Price, okcoin.MustParseDecimal(tt.wantPrice); !g.Equal(w) {
+			t.Errorf("#%d: price: got=%v want=%v", i, g, w)
+		}
+		if g, w := got.Amount, okcoin.MustParseDecimal(tt.wantAmount); !g.Equal(w) {
+			t.Errorf("#%d: amount: got=%v want=%v", i, g, w)
+		}
+		if got == tt.req {
+			t.Errorf("#%d: the request was rounded in place", i)
+		}
+	}
+}
+
+func (b *backend) instrumentsRoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return makeResp(fmt.Sprintf(`got method %q want "GET"`, req.Method), http.StatusMethodNotAllowed, nil)
+	}
+	wantPathSuffix := "/api/spot/v3/instruments"
+	gotPath := req.URL.Path
+	if !strings.HasSuffix(gotPath, wantPathSuffix) {
+		return makeResp(fmt.Sprintf(`got suffix %q want %q`, gotPath, wantPathSuffix), http.StatusBadRequest, nil)
+	}
+	return respFromFile("./testdata/instruments.json")
+}
+
+const (
+	instrumentsRoute = "/instruments"
+)
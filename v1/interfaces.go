@@ -0,0 +1,40 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+// MarketData is the public market data of a venue. Code that depends
+// on it rather than on *Client can be handed a mock or another venue.
+type MarketData interface {
+	Ticker(Symbol) (*TickerResponse, error)
+	LastTrades(*LastTradesRequest) (*LastTradesResponse, error)
+	CandleStick(*CandleStickRequest) (*CandleStickResponse, error)
+	Depth(*DepthRequest) (*Depth, error)
+}
+
+// Trader places, cancels and queries the orders of an account.
+// Besides *Client, it is implemented by the simulated
+// exchange of package paper.
+type Trader interface {
+	PlaceOrder(*OrderRequest) (*OrderResponse, error)
+	CancelOrder(sym Symbol, orderID int64) error
+	Order(sym Symbol, orderID int64) (*Order, error)
+	OpenOrders(Symbol) ([]*Order, error)
+	Funds() (*Funds, error)
+}
+
+var (
+	_ MarketData = (*Client)(nil)
+	_ Trader     = (*Client)(nil)
+)
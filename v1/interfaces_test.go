@@ -0,0 +1,70 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/paper"
+)
+
+// placeBuy is strategy code that only depends on the interfaces.
+func placeBuy(md okcoin.MarketData, tr okcoin.Trader) (*okcoin.Order, error) {
+	tres, err := md.Ticker(okcoin.BTCUSD)
+	if err != nil {
+		return nil, err
+	}
+	ores, err := tr.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD,
+		Type:   okcoin.Buy,
+		Price:  okcoin.NewDecimalFromFloat(tres.Ticker.Sell),
+		Amount: okcoin.MustParseDecimal("0.1"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tr.Order(okcoin.BTCUSD, ores.OrderID)
+}
+
+func TestInterfacesAreSwappable(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&backend{route: tickerRoute})
+
+	ex, err := paper.New(&paper.Config{
+		Market:   client,
+		Balances: map[okcoin.Currency]float64{okcoin.USD: 1000},
+	})
+	if err != nil {
+		t.Fatalf("paper: %v", err)
+	}
+
+	order, err := placeBuy(client, ex)
+	if err != nil {
+		t.Fatalf("place buy: %v", err)
+	}
+	if g, w := order.Status, okcoin.StatusFilled; g != w {
+		t.Errorf("status: got=%v want=%v", g, w)
+	}
+	// The price comes from testdata/ticker-btc_usd.json.
+	if g, w := order.AvgPrice.String(), "4594.17"; g != w {
+		t.Errorf("avg price: got=%q want=%q", g, w)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The account listings below are only served by the spot v3 API,
+// whose list endpoints are paginated with the OK-BEFORE and OK-AFTER
+// cursors. Their records are returned most recent first.
+
+// Fill is a trade of one of the account's orders.
+type Fill struct {
+	LedgerID     string    `json:"ledger_id"`
+	TradeID      string    `json:"trade_id"`
+	OrderID      string    `json:"order_id"`
+	InstrumentID string    `json:"instrument_id"`
+	Side         string    `json:"side"`
+	Price        Decimal   `json:"price"`
+	Size         Decimal   `json:"size"`
+	Fee          Decimal   `json:"fee"`
+	Currency     string    `json:"currency"`
+	ExecType     string    `json:"exec_type"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// SpotOrder is an order as listed by the v3 API.
+type SpotOrder struct {
+	OrderID        string    `json:"order_id"`
+	ClientOID      string    `json:"client_oid"`
+	InstrumentID   string    `json:"instrument_id"`
+	Side           string    `json:"side"`
+	Type           string    `json:"type"`
+	Price          Decimal   `json:"price"`
+	Size           Decimal   `json:"size"`
+	Notional       Decimal   `json:"notional"`
+	FilledSize     Decimal   `json:"filled_size"`
+	FilledNotional Decimal   `json:"filled_notional"`
+	PriceAvg       Decimal   `json:"price_avg"`
+	State          string    `json:"state"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// LedgerEntry is a change of the balance of one of the account's currencies.
+type LedgerEntry struct {
+	LedgerID  string    `json:"ledger_id"`
+	Currency  string    `json:"currency"`
+	Type      string    `json:"type"`
+	Amount    Decimal   `json:"amount"`
+	Balance   Decimal   `json:"balance"`
+	Fee       Decimal   `json:"fee"`
+	Timestamp time.Time `json:"timestamp"`
+	Details   struct {
+		OrderID      string `json:"order_id"`
+		InstrumentID string `json:"instrument_id"`
+	} `json:"details"`
+}
+
+type FillsRequest struct {
+	Symbol Symbol
+	// OrderID restricts the fills to those of an order.
+	OrderID string
+	// Limit is the number of fills per page, at most 100.
+	Limit int
+}
+
+type SpotOrdersRequest struct {
+	Symbol Symbol
+	// State is the v3 state code that the orders are filtered by,
+	// "7" for all completed orders if blank.
+	State string
+	// Limit is the number of orders per page, at most 100.
+	Limit int
+}
+
+type LedgerRequest struct {
+	Currency Currency
+	// Limit is the number of entries per page, at most 100.
+	Limit int
+}
+
+var errBlankCurrency = errors.New("expecting a non-blank currency")
+
+// FillsPager walks the account's fills of a symbol.
+type FillsPager struct {
+	*Pager
+
+	mu    sync.Mutex
+	fills []*Fill
+}
+
+// Fills returns the fills of the page that was fetched last.
+func (fp *FillsPager) Fills() []*Fill {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	return fp.fills
+}
+
+// FillsPager returns a pager over the fills described by freq. Each call to
+// its Next or Prev fetches a page, with ctx bounding every request.
+func (c *Client) FillsPager(ctx context.Context, freq *FillsRequest) (*FillsPager, error) {
+	if freq == nil || freq.Symbol == "" {
+		return nil, errBlankSymbol
+	}
+	qv := url.Values{"instrument_id": {freq.Symbol.InstrumentID()}}
+	if freq.OrderID != "" {
+		qv.Set("order_id", freq.OrderID)
+	}
+	fp := new(FillsPager)
+	pager, err := NewPager(freq.Limit, func(preq *PageRequest) (http.Header, error) {
+		var fills []*Fill
+		hdr, err := c.v3Page(ctx, "fills", qv, preq, &fills)
+		if err != nil {
+			return nil, err
+		}
+		fp.mu.Lock()
+		fp.fills = fills
+		fp.mu.Unlock()
+		return hdr, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	fp.Pager = pager
+	return fp, nil
+}
+
+// SpotOrdersPager walks the account's orders of a symbol.
+type SpotOrdersPager struct {
+	*Pager
+
+	mu     sync.Mutex
+	orders []*SpotOrder
+}
+
+// Orders returns the orders of the page that was fetched last.
+func (op *SpotOrdersPager) Orders() []*SpotOrder {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.orders
+}
+
+// SpotOrdersPager returns a pager over the orders described by oreq. Each
+// call to its Next or Prev fetches a page, with ctx bounding every request.
+func (c *Client) SpotOrdersPager(ctx context.Context, oreq *SpotOrdersRequest) (*SpotOrdersPager, error) {
+	if oreq == nil || oreq.Symbol == "" {
+		return nil, errBlankSymbol
+	}
+	state := oreq.State
+	if state == "" {
+		state = "7"
+	}
+	qv := url.Values{"instrument_id": {oreq.Symbol.InstrumentID()}, "state": {state}}
+	op := new(SpotOrdersPager)
+	pager, err := NewPager(oreq.Limit, func(preq *PageRequest) (http.Header, error) {
+		var orders []*SpotOrder
+		hdr, err := c.v3Page(ctx, "orders", qv, preq, &orders)
+		if err != nil {
+			return nil, err
+		}
+		op.mu.Lock()
+		op.orders = orders
+		op.mu.Unlock()
+		return hdr, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	op.Pager = pager
+	return op, nil
+}
+
+// LedgerPager walks the ledger of one of the account's currencies.
+type LedgerPager struct {
+	*Pager
+
+	mu      sync.Mutex
+	entries []*LedgerEntry
+}
+
+// Entries returns the ledger entries of the page that was fetched last.
+func (lp *LedgerPager) Entries() []*LedgerEntry {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.entries
+}
+
+// LedgerPager returns a pager over the ledger described by lreq. Each call
+// to its Next or Prev fetches a page, with ctx bounding every request.
+func (c *Client) LedgerPager(ctx context.Context, lreq *LedgerRequest) (*LedgerPager, error) {
+	if lreq == nil || lreq.Currency == "" {
+		return nil, errBlankCurrency
+	}
+	endpoint := "accounts/" + strings.ToUpper(string(lreq.Currency)) + "/ledger"
+	lp := new(LedgerPager)
+	pager, err := NewPager(lreq.Limit, func(preq *PageRequest) (http.Header, error) {
+		var entries []*LedgerEntry
+		hdr, err := c.v3Page(ctx, endpoint, nil, preq, &entries)
+		if err != nil {
+			return nil, err
+		}
+		lp.mu.Lock()
+		lp.entries = entries
+		lp.mu.Unlock()
+		return hdr, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	lp.Pager = pager
+	return lp, nil
+}
+
+// v3Page decodes the page of the signed v3 listing at
+// endpoint into dst and returns the headers carrying its cursors.
+func (c *Client) v3Page(ctx context.Context, endpoint string, params url.Values, preq *PageRequest, dst interface{}) (http.Header, error) {
+	qv := make(url.Values, len(params)+2)
+	for key, values := range params {
+		qv[key] = values
+	}
+	if preq.Before != "" {
+		qv.Set("before", preq.Before)
+	}
+	if preq.After != "" {
+		qv.Set("after", preq.After)
+	}
+	if preq.Limit > 0 {
+		qv.Set("limit", strconv.Itoa(preq.Limit))
+	}
+	res, err := c.call(ctx, &Call{BaseURL: SpotV3BaseURL, Endpoint: endpoint, Params: qv, Signed: true})
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(res.Body, dst); err != nil {
+		return nil, err
+	}
+	return res.Header, nil
+}
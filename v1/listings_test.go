@@ -0,0 +1,244 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+const apiPassphrase1 = "b@z"
+
+func newListingsClient(t *testing.T) *okcoin.Client {
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1, Passphrase: apiPassphrase1})
+	client.SetHTTPRoundTripper(&backend{route: listingsRoute})
+	return client
+}
+
+func TestFillsPager(t *testing.T) {
+	t.Parallel()
+
+	client := newListingsClient(t)
+	if _, err := client.FillsPager(context.Background(), nil); err == nil {
+		t.Errorf("expecting an error for a blank symbol")
+	}
+	fp, err := client.FillsPager(context.Background(), &okcoin.FillsRequest{Symbol: okcoin.BTCUSD, Limit: 3})
+	if err != nil {
+		t.Fatalf("fills pager: %v", err)
+	}
+
+	var ids []string
+	pages := 0
+	for {
+		err := fp.Next()
+		if err == okcoin.ErrNoMorePages {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		pages++
+		for _, fill := range fp.Fills() {
+			if fill.InstrumentID != "BTC-USD" || fill.Price.String() != "4592.01" {
+				t.Errorf("fill: got=%+v", fill)
+			}
+			ids = append(ids, fill.LedgerID)
+		}
+	}
+	if g, w := pages, 3; g != w {
+		t.Errorf("pages: got=%d want=%d", g, w)
+	}
+	if g, w := ids, []string{"8", "7", "6", "5", "4", "3", "2", "1"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("fills: got=%v want=%v", g, w)
+	}
+
+	// Back from the oldest page towards newer fills.
+	if err := fp.Prev(); err != nil {
+		t.Fatalf("prev: %v", err)
+	}
+	var newer []string
+	for _, fill := range fp.Fills() {
+		newer = append(newer, fill.LedgerID)
+	}
+	if g, w := newer, []string{"5", "4", "3"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("prev: got=%v want=%v", g, w)
+	}
+}
+
+func TestSpotOrdersPager(t *testing.T) {
+	t.Parallel()
+
+	client := newListingsClient(t)
+	op, err := client.SpotOrdersPager(context.Background(), &okcoin.SpotOrdersRequest{Symbol: okcoin.LTCUSD, State: "2", Limit: 5})
+	if err != nil {
+		t.Fatalf("orders pager: %v", err)
+	}
+	var ids []string
+	for {
+		err := op.Next()
+		if err == okcoin.ErrNoMorePages {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		for _, order := range op.Orders() {
+			if order.InstrumentID != "LTC-USD" || order.State != "2" {
+				t.Errorf("order: got=%+v", order)
+			}
+			ids = append(ids, order.OrderID)
+		}
+	}
+	if g, w := ids, []string{"8", "7", "6", "5", "4", "3", "2", "1"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("orders: got=%v want=%v", g, w)
+	}
+}
+
+func TestLedgerPager(t *testing.T) {
+	t.Parallel()
+
+	client := newListingsClient(t)
+	if _, err := client.LedgerPager(context.Background(), &okcoin.LedgerRequest{}); err == nil {
+		t.Errorf("expecting an error for a blank currency")
+	}
+	lp, err := client.LedgerPager(context.Background(), &okcoin.LedgerRequest{Currency: okcoin.USD})
+	if err != nil {
+		t.Fatalf("ledger pager: %v", err)
+	}
+	if err := lp.Next(); err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	entries := lp.Entries()
+	if g, w := len(entries), 8; g != w {
+		t.Fatalf("entries: got=%d want=%d", g, w)
+	}
+	if e := entries[0]; e.Currency != "USD" || e.Amount.String() != "-0.5" || e.Details.OrderID != "8" {
+		t.Errorf("entry: got=%+v", e)
+	}
+	if g, w := lp.Cursor(), (&okcoin.Cursor{Before: "8", After: "1"}); !reflect.DeepEqual(g, w) {
+		t.Errorf("cursor: got=%#v want=%#v", g, w)
+	}
+	if err := lp.Next(); err != okcoin.ErrNoMorePages {
+		t.Errorf("next after the last page: got=%v want=%v", err, okcoin.ErrNoMorePages)
+	}
+}
+
+func TestListingsNeedV3Signature(t *testing.T) {
+	t.Parallel()
+
+	client := newListingsClient(t)
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: "wrong", Passphrase: apiPassphrase1})
+	fp, err := client.FillsPager(context.Background(), &okcoin.FillsRequest{Symbol: okcoin.BTCUSD})
+	if err != nil {
+		t.Fatalf("fills pager: %v", err)
+	}
+	if err := fp.Next(); err == nil || err == okcoin.ErrNoMorePages {
+		t.Errorf("next with the wrong secret: got=%v want a failure", err)
+	}
+}
+
+// listingsRoundTrip serves 8 records, newest first, from each of the
+// v3 fills, orders and ledger listings.
+func (b *backend) listingsRoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return makeResp(fmt.Sprintf(`got method %q want "GET"`, req.Method), http.StatusMethodNotAllowed, nil)
+	}
+	if resp, ok := checkV3Signature(req); !ok {
+		return resp, nil
+	}
+
+	qv := req.URL.Query()
+	limit, _ := strconv.Atoi(qv.Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	var ids []int
+	switch {
+	case qv.Get("after") != "":
+		after, _ := strconv.Atoi(qv.Get("after"))
+		for id := after - 1; id >= 1 && len(ids) < limit; id-- {
+			ids = append(ids, id)
+		}
+	case qv.Get("before") != "":
+		before, _ := strconv.Atoi(qv.Get("before"))
+		for id := before + limit; id > before; id-- {
+			if id <= 8 {
+				ids = append(ids, id)
+			}
+		}
+	default:
+		for id := 8; id >= 1 && len(ids) < limit; id-- {
+			ids = append(ids, id)
+		}
+	}
+
+	var records []string
+	switch path := req.URL.Path; path {
+	case "/api/spot/v3/fills":
+		for _, id := range ids {
+			records = append(records, fmt.Sprintf(`{"ledger_id":"%d","order_id":"%d","instrument_id":%q,"price":"4592.01","size":"0.1","fee":"0.0001","side":"buy","timestamp":"2019-03-18T07:26:50.000Z"}`, id, id, qv.Get("instrument_id")))
+		}
+	case "/api/spot/v3/orders":
+		for _, id := range ids {
+			records = append(records, fmt.Sprintf(`{"order_id":"%d","instrument_id":%q,"price":"60.1","size":"2","state":%q,"timestamp":"2019-03-18T07:26:50.000Z"}`, id, qv.Get("instrument_id"), qv.Get("state")))
+		}
+	case "/api/spot/v3/accounts/USD/ledger":
+		for _, id := range ids {
+			records = append(records, fmt.Sprintf(`{"ledger_id":"%d","currency":"USD","amount":"-0.5","balance":"100","type":"trade","timestamp":"2019-03-18T07:26:50.000Z","details":{"order_id":"%d","instrument_id":"BTC-USD"}}`, id, id))
+		}
+	default:
+		return makeResp(fmt.Sprintf("unknown path %q", path), http.StatusNotFound, nil)
+	}
+
+	resp, err := jsonResp("[" + strings.Join(records, ",") + "]")
+	if len(ids) > 0 {
+		resp.Header.Set("OK-BEFORE", strconv.Itoa(ids[0]))
+		resp.Header.Set("OK-AFTER", strconv.Itoa(ids[len(ids)-1]))
+	}
+	return resp, err
+}
+
+func checkV3Signature(req *http.Request) (*http.Response, bool) {
+	knownSecret, ok := knownAPIKeyToSecrets[req.Header.Get("OK-ACCESS-KEY")]
+	if !ok || req.Header.Get("OK-ACCESS-PASSPHRASE") != apiPassphrase1 {
+		resp, _ := makeResp("unknown API key or passphrase", http.StatusUnauthorized, nil)
+		return resp, false
+	}
+	mac := hmac.New(sha256.New, []byte(knownSecret))
+	fmt.Fprintf(mac, "%s%s%s", req.Header.Get("OK-ACCESS-TIMESTAMP"), req.Method, req.URL.RequestURI())
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); req.Header.Get("OK-ACCESS-SIGN") != want {
+		resp, _ := makeResp("signatures do not match", http.StatusUnauthorized, nil)
+		return resp, false
+	}
+	return nil, true
+}
+
+const (
+	listingsRoute = "/listings"
+)
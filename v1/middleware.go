@@ -0,0 +1,132 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Call is a logical call to the API, such as getting a ticker
+// or placing an order, as seen by a Middleware.
+type Call struct {
+	// BaseURL is the URL that Endpoint is relative to, V1BaseURL
+	// if blank. Instruments for instance calls SpotV3BaseURL.
+	BaseURL string
+	// Endpoint is the name of the API endpoint, e.g. "ticker.do".
+	Endpoint string
+	// Params are the decoded parameters of the call. A Middleware may
+	// change them before passing the call on: signed calls are signed
+	// only after every Middleware ran, so the API key and the signature
+	// are never among them.
+	Params url.Values
+	// Signed reports whether the call needs the client's credentials.
+	// Signed v1 calls are POSTed, the others are GETs. Calls to
+	// SpotV3BaseURL are signed in their headers instead.
+	Signed bool
+}
+
+// Response is the API's answer to a Call.
+type Response struct {
+	Body []byte
+	// Header holds e.g. the OK-BEFORE and OK-AFTER cursors of listings.
+	Header http.Header
+}
+
+// Invoker performs a call and returns the API's response.
+type Invoker func(ctx context.Context, call *Call) (*Response, error)
+
+// Middleware wraps every logical call made by a Client, for example
+// to log, audit or cache them, or to refuse those that break a policy.
+type Middleware interface {
+	// Invoke handles call, usually by passing it on to next. It may
+	// instead answer by itself, e.g. from a cache, or return an error.
+	// The response it returns is decoded as if it came from the API, so
+	// errors reported with an error_code are still turned into *APIError.
+	Invoke(ctx context.Context, call *Call, next Invoker) (*Response, error)
+}
+
+// MiddlewareFunc adapts a function to a Middleware.
+type MiddlewareFunc func(ctx context.Context, call *Call, next Invoker) (*Response, error)
+
+func (f MiddlewareFunc) Invoke(ctx context.Context, call *Call, next Invoker) (*Response, error) {
+	return f(ctx, call, next)
+}
+
+// SetMiddleware makes every call go through mws, the first
+// of which is the outermost. No arguments remove them all.
+func (c *Client) SetMiddleware(mws ...Middleware) {
+	c.mu.Lock()
+	c.mws = append([]Middleware(nil), mws...)
+	c.mu.Unlock()
+}
+
+func (c *Client) middleware() []Middleware {
+	c.mu.RLock()
+	mws := c.mws
+	c.mu.RUnlock()
+	return mws
+}
+
+// call runs the call through the client's middleware then sends it.
+func (c *Client) call(ctx context.Context, call *Call) (*Response, error) {
+	invoke := Invoker(c.send)
+	mws := c.middleware()
+	for i := len(mws) - 1; i >= 0; i-- {
+		mw, next := mws[i], invoke
+		invoke = func(ctx context.Context, call *Call) (*Response, error) {
+			return mw.Invoke(ctx, call, next)
+		}
+	}
+	return invoke(ctx, call)
+}
+
+// send signs the call if need be and makes its HTTP request.
+func (c *Client) send(ctx context.Context, call *Call) (*Response, error) {
+	// Signing must not leak the credentials into the caller's params.
+	qv := make(url.Values, len(call.Params))
+	for key, values := range call.Params {
+		qv[key] = append([]string(nil), values...)
+	}
+
+	base := call.BaseURL
+	if base == "" {
+		base = V1BaseURL
+	}
+	var req *http.Request
+	var err error
+	switch {
+	case call.Signed && base == SpotV3BaseURL:
+		req, err = c.v3SignedReq(base, call.Endpoint, qv)
+	case call.Signed:
+		req, err = c.signedReq(base, call.Endpoint, qv)
+	default:
+		fullURL := fmt.Sprintf("%s/%s", base, call.Endpoint)
+		if len(qv) > 0 {
+			fullURL = fmt.Sprintf("%s?%s", fullURL, qv.Encode())
+		}
+		req, err = http.NewRequest("GET", fullURL, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	blob, header, err := c.doHTTPReq(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Body: blob, Header: header}, nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// callLog records the calls that go through it, tagged with its name.
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (cl *callLog) middleware(name string) okcoin.Middleware {
+	return okcoin.MiddlewareFunc(func(ctx context.Context, call *okcoin.Call, next okcoin.Invoker) (*okcoin.Response, error) {
+		cl.mu.Lock()
+		cl.calls = append(cl.calls, fmt.Sprintf("%s %s %s signed=%v", name, call.Endpoint, call.Params.Encode(), call.Signed))
+		cl.mu.Unlock()
+		return next(ctx, call)
+	})
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	log := new(callLog)
+	client.SetMiddleware(log.middleware("outer"), log.middleware("inner"))
+
+	client.SetHTTPRoundTripper(&backend{route: tickerRoute})
+	if _, err := client.Ticker(okcoin.LTCUSD); err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	client.SetHTTPRoundTripper(&backend{route: fundsRoute})
+	if _, err := client.Funds(); err != nil {
+		t.Fatalf("funds: %v", err)
+	}
+
+	want := []string{
+		"outer ticker.do symbol=ltc_usd signed=false",
+		"inner ticker.do symbol=ltc_usd signed=false",
+		"outer userinfo.do  signed=true",
+		"inner userinfo.do  signed=true",
+	}
+	if !reflect.DeepEqual(log.calls, want) {
+		t.Errorf("calls:\ngot= %q\nwant=%q", log.calls, want)
+	}
+
+	// The params seen by middleware never carry the credentials.
+	for _, call := range log.calls {
+		for _, secret := range []string{apiKey1, "api_key", "sign=", "secret_key"} {
+			if strings.Contains(call, secret) {
+				t.Errorf("%q leaks %q", call, secret)
+			}
+		}
+	}
+
+	client.SetMiddleware()
+	if _, err := client.Funds(); err != nil {
+		t.Fatalf("funds: %v", err)
+	}
+	if n := len(log.calls); n != len(want) {
+		t.Errorf("calls after the middleware was removed: got=%d want=%d", n, len(want))
+	}
+}
+
+func TestMiddlewareRewritesBeforeSigning(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(&backend{route: ordersRoute})
+	client.SetMiddleware(okcoin.MiddlewareFunc(func(ctx context.Context, call *okcoin.Call, next okcoin.Invoker) (*okcoin.Response, error) {
+		if call.Endpoint == "trade.do" {
+			call.Params.Set("type", "buy")
+		}
+		return next(ctx, call)
+	}))
+
+	// The backend only accepts buys and checks the signature.
+	ores, err := client.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.LTCUSD, Type: okcoin.Sell,
+		Price: okcoin.MustParseDecimal("100"), Amount: okcoin.MustParseDecimal("1"),
+	})
+	if err != nil {
+		t.Fatalf("place order: %v", err)
+	}
+	if g, w := ores.OrderID, int64(1); g != w {
+		t.Errorf("order id: got=%d want=%d", g, w)
+	}
+}
+
+func TestMiddlewareShortCircuits(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	// Any call that reaches the backend fails.
+	client.SetHTTPRoundTripper(&backend{route: "unreachable"})
+
+	errNoTrading := errors.New("trading is disabled")
+	cached := map[string]string{
+		"ticker.do":       `{"date":"1410431279","ticker":{"buy":"33.15","high":"34.15","last":"33.15","low":"32.05","sell":"33.16","vol":"10532696.39199642"}}`,
+		"cancel_order.do": `{"result":false,"error_code":10009}`,
+	}
+	client.SetMiddleware(okcoin.MiddlewareFunc(func(ctx context.Context, call *okcoin.Call, next okcoin.Invoker) (*okcoin.Response, error) {
+		if call.Endpoint == "trade.do" {
+			return nil, errNoTrading
+		}
+		if blob, ok := cached[call.Endpoint]; ok {
+			return &okcoin.Response{Body: []byte(blob)}, nil
+		}
+		return next(ctx, call)
+	}))
+
+	tres, err := client.Ticker(okcoin.BTCUSD)
+	if err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	if g, w := tres.Ticker.Last, 33.15; g != w {
+		t.Errorf("cached ticker: got=%v want=%v", g, w)
+	}
+
+	_, err = client.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+		Price: okcoin.MustParseDecimal("100"), Amount: okcoin.MustParseDecimal("1"),
+	})
+	if err != errNoTrading {
+		t.Errorf("place order: got=%v want=%v", err, errNoTrading)
+	}
+
+	// Answers from middleware are decoded like the API's own.
+	err = client.CancelOrder(okcoin.BTCUSD, 1)
+	if ae, ok := err.(*okcoin.APIError); !ok || ae.Code != 10009 {
+		t.Errorf("cancel order: got=%v want error_code 10009", err)
+	}
+
+	if _, err := client.Funds(); err == nil {
+		t.Errorf("funds: want the backend's failure")
+	}
+}
+
+func TestMiddlewareSeesBaseURL(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&backend{route: instrumentsRoute})
+
+	// A cache keyed on the full URL of calls must tell v1 from v3.
+	var mu sync.Mutex
+	cache := make(map[string]*okcoin.Response)
+	client.SetMiddleware(okcoin.MiddlewareFunc(func(ctx context.Context, call *okcoin.Call, next okcoin.Invoker) (*okcoin.Response, error) {
+		key := call.BaseURL + "/" + call.Endpoint
+		mu.Lock()
+		res, ok := cache[key]
+		mu.Unlock()
+		if ok {
+			return res, nil
+		}
+		// Replayed from scratch rather than passed on.
+		res, err := next(ctx, &okcoin.Call{BaseURL: call.BaseURL, Endpoint: call.Endpoint, Params: call.Params})
+		if err == nil {
+			mu.Lock()
+			cache[key] = res
+			mu.Unlock()
+		}
+		return res, err
+	}))
+
+	first, err := client.Instruments()
+	if err != nil {
+		t.Fatalf("instruments: %v", err)
+	}
+	if _, ok := cache[okcoin.SpotV3BaseURL+"/instruments"]; !ok {
+		t.Errorf("cache keys: got=%v want %q", cache, okcoin.SpotV3BaseURL+"/instruments")
+	}
+
+	client.SetHTTPRoundTripper(&backend{route: "unreachable"})
+	second, err := client.Instruments()
+	if err != nil {
+		t.Fatalf("cached instruments: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached instruments: got=%v want=%v", second, first)
+	}
+}
@@ -15,7 +15,11 @@
 package okcoin
 
 import (
+	"context"
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -24,12 +28,16 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/orijtech/otils"
 )
 
 const (
-	baseURL = "https://www.okcoin.com/api/v1"
+	// V1BaseURL is the base URL of the v1 REST API.
+	V1BaseURL = "https://www.okcoin.com/api/v1"
+	// SpotV3BaseURL is the base URL of the spot v3 REST API.
+	SpotV3BaseURL = "https://www.okcoin.com/api/spot/v3"
 )
 
 type Symbol string
@@ -43,16 +51,22 @@ const (
 )
 
 type Client struct {
-	rt http.RoundTripper
-	mu sync.RWMutex
+	rt  http.RoundTripper
+	rl  RateLimiter
+	tr  Tracer
+	mws []Middleware
+	mu  sync.RWMutex
 
-	_apiSecret string
-	_apiKey    string
+	_apiSecret     string
+	_apiKey        string
+	_apiPassphrase string
 }
 
 const (
 	envAPIKeyKey    = "OKCOIN_API_KEY"
 	envAPISecretKey = "OKCOIN_API_SECRET"
+	// Only the v3 API needs a passphrase so it is optional.
+	envAPIPassphraseKey = "OKCOIN_API_PASSPHRASE"
 )
 
 func NewClientFromEnv() (*Client, error) {
@@ -62,7 +76,8 @@ func NewClientFromEnv() (*Client, error) {
 	if len(errsList) > 0 {
 		return nil, errors.New(strings.Join(errsList, "\n"))
 	}
-	return &Client{_apiSecret: apiSecret, _apiKey: apiKey}, nil
+	apiPassphrase := os.Getenv(envAPIPassphraseKey)
+	return &Client{_apiSecret: apiSecret, _apiKey: apiKey, _apiPassphrase: apiPassphrase}, nil
 }
 
 func fromEnvOrAppendError(envKey string, errsList *[]string) string {
@@ -79,6 +94,21 @@ func (c *Client) SetHTTPRoundTripper(rt http.RoundTripper) {
 	c.mu.Unlock()
 }
 
+// SetRateLimiter makes every request first wait on rl.
+// A nil RateLimiter removes any limit.
+func (c *Client) SetRateLimiter(rl RateLimiter) {
+	c.mu.Lock()
+	c.rl = rl
+	c.mu.Unlock()
+}
+
+func (c *Client) rateLimiter() RateLimiter {
+	c.mu.RLock()
+	rl := c.rl
+	c.mu.RUnlock()
+	return rl
+}
+
 func (c *Client) httpClient() *http.Client {
 	c.mu.RLock()
 	rt := c.rt
@@ -94,22 +124,38 @@ func NewDefaultClient() (*Client, error) {
 }
 
 func (c *Client) doHTTPReq(req *http.Request) ([]byte, http.Header, error) {
+	if rl := c.rateLimiter(); rl != nil {
+		if err := rl.Wait(req.Context()); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Traces start after the wait so that latencies are the API's own.
+	if tr := c.tracer(); tr != nil {
+		return traceHTTPReq(tr, req, c.roundTrip)
+	}
+	blob, header, _, err := c.roundTrip(req)
+	return blob, header, err
+}
+
+// roundTrip sends req and returns the body of its response,
+// its header and status code, or an error if it was not a 2XX.
+func (c *Client) roundTrip(req *http.Request) ([]byte, http.Header, int, error) {
 	res, err := c.httpClient().Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
 	if res.Body != nil {
 		defer res.Body.Close()
 	}
 	if !otils.StatusOK(res.StatusCode) {
-		return nil, res.Header, fmt.Errorf("%s %d", res.Status, res.StatusCode)
+		return nil, res.Header, res.StatusCode, fmt.Errorf("%s %d", res.Status, res.StatusCode)
 	}
 
 	blob, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, res.Header, err
+		return nil, res.Header, res.StatusCode, err
 	}
-	return blob, res.Header, nil
+	return blob, res.Header, res.StatusCode, nil
 }
 
 func (c *Client) prepareSignedAuthBody(qv url.Values) (url.Values, error) {
@@ -126,9 +172,62 @@ func (c *Client) prepareSignedAuthBody(qv url.Values) (url.Values, error) {
 	return qv, nil
 }
 
+// doSignedReq POSTs the signed params to the v1 endpoint at path
+// and returns the response body once it reports a successful result.
+func (c *Client) doSignedReq(ctx context.Context, path string, qv url.Values) ([]byte, error) {
+	res, err := c.call(ctx, &Call{Endpoint: path, Params: qv, Signed: true})
+	if err != nil {
+		return nil, err
+	}
+	if err := resultError(res.Body); err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// signedReq builds the POST of the signed params to the endpoint at path.
+func (c *Client) signedReq(base, path string, qv url.Values) (*http.Request, error) {
+	if qv == nil {
+		qv = make(url.Values)
+	}
+	qv.Set("api_key", c.apiKey())
+	qv, err := c.prepareSignedAuthBody(qv)
+	if err != nil {
+		return nil, err
+	}
+	fullURL := fmt.Sprintf("%s/%s?%s", base, path, qv.Encode())
+	return http.NewRequest("POST", fullURL, nil)
+}
+
+// v3SignedReq builds the GET of the v3 endpoint at path, signed as per
+// https://www.okcoin.com/docs/en/#summary-yan-zheng with an HMAC-SHA256
+// of the timestamp, method and request path sent in the OK-ACCESS headers.
+func (c *Client) v3SignedReq(base, path string, qv url.Values) (*http.Request, error) {
+	fullURL := fmt.Sprintf("%s/%s", base, path)
+	if len(qv) > 0 {
+		fullURL = fmt.Sprintf("%s?%s", fullURL, qv.Encode())
+	}
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := time.Now().UTC().Format(v3TimestampLayout)
+	mac := hmac.New(sha256.New, []byte(c.apiSecret()))
+	fmt.Fprintf(mac, "%s%s%s", timestamp, req.Method, req.URL.RequestURI())
+	req.Header.Set("OK-ACCESS-KEY", c.apiKey())
+	req.Header.Set("OK-ACCESS-SIGN", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", c.apiPassphrase())
+	return req, nil
+}
+
+const v3TimestampLayout = "2006-01-02T15:04:05.000Z"
+
 type Credentials struct {
 	APIKey string `json:"api_key"`
 	Secret string `json:"secret"`
+	// Passphrase is only needed by the v3 API.
+	Passphrase string `json:"passphrase,omitempty"`
 }
 
 func (c *Client) SetCredentials(creds *Credentials) {
@@ -138,6 +237,7 @@ func (c *Client) SetCredentials(creds *Credentials) {
 	c.mu.Lock()
 	c._apiKey = creds.APIKey
 	c._apiSecret = creds.Secret
+	c._apiPassphrase = creds.Passphrase
 	c.mu.Unlock()
 }
 
@@ -154,3 +254,10 @@ func (c *Client) apiKey() string {
 	c.mu.Unlock()
 	return apiKey
 }
+
+func (c *Client) apiPassphrase() string {
+	c.mu.Lock()
+	passphrase := c._apiPassphrase
+	c.mu.Unlock()
+	return passphrase
+}
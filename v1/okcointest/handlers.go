@@ -0,0 +1,420 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcointest
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// The error codes used by the server, as listed
+// at https://www.okcoin.com/rest_request.html
+const (
+	codeRequiredField     = 10000
+	codeSecretNotFound    = 10005
+	codeAPIKeyNotFound    = 10006
+	codeSignatureMismatch = 10007
+	codeIllegalParameter  = 10008
+)
+
+const (
+	maxTrades             = 60
+	maxDepthSize          = 200
+	maxOrderHistoryLength = 200
+)
+
+type signedHandler func(acct *account, qv url.Values) (interface{}, error)
+
+func (s *Server) handler() http.Handler {
+	public := map[string]func(url.Values) (interface{}, error){
+		"ticker.do": s.ticker,
+		"trades.do": s.lastTrades,
+		"kline.do":  s.kline,
+		"depth.do":  s.depth,
+	}
+	signed := map[string]signedHandler{
+		"userinfo.do":      userInfo,
+		"trade.do":         trade,
+		"cancel_order.do":  cancelOrder,
+		"order_info.do":    orderInfo,
+		"order_history.do": orderHistory,
+	}
+
+	mux := http.NewServeMux()
+	for endpoint, fn := range public {
+		fn := fn
+		mux.Handle("/api/v1/"+endpoint, s.endpoint(endpoint, "GET", func(r *http.Request) (interface{}, error) {
+			return fn(r.Form)
+		}))
+	}
+	for endpoint, fn := range signed {
+		fn := fn
+		mux.Handle("/api/v1/"+endpoint, s.endpoint(endpoint, "POST", func(r *http.Request) (interface{}, error) {
+			acct, err := s.authenticate(r.Form)
+			if err != nil {
+				return nil, err
+			}
+			return fn(acct, r.Form)
+		}))
+	}
+	return mux
+}
+
+// endpoint wraps fn with the injected latency and failures,
+// encoding its result as JSON and its errors like the exchange.
+func (s *Server) endpoint(name, method string, fn func(*http.Request) (interface{}, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		latency, code, status := s.latency, s.codes[name], s.statuses[name]
+		s.mu.Unlock()
+
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if r.Method != method {
+			http.Error(w, fmt.Sprintf("got method %q want %q", r.Method, method), http.StatusMethodNotAllowed)
+			return
+		}
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		if code != 0 {
+			writeJSON(w, &okcoin.APIError{Code: code})
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writeJSON(w, &okcoin.APIError{Code: codeIllegalParameter})
+			return
+		}
+
+		res, err := fn(r)
+		if err != nil {
+			ae, ok := err.(*okcoin.APIError)
+			if !ok {
+				ae = &okcoin.APIError{Code: codeIllegalParameter}
+			}
+			writeJSON(w, ae)
+			return
+		}
+		writeJSON(w, res)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	if ae, ok := v.(*okcoin.APIError); ok {
+		v = map[string]interface{}{"result": false, "error_code": ae.Code}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// authenticate checks the signature of qv the way the exchange does:
+// the uppercase MD5 of the other parameters followed by the secret key.
+func (s *Server) authenticate(qv url.Values) (*account, error) {
+	apiKey := qv.Get("api_key")
+	if apiKey == "" {
+		return nil, &okcoin.APIError{Code: codeAPIKeyNotFound}
+	}
+	s.mu.Lock()
+	acct, ok := s.accounts[apiKey]
+	s.mu.Unlock()
+	if !ok {
+		return nil, &okcoin.APIError{Code: codeAPIKeyNotFound}
+	}
+	if acct.secret == "" {
+		return nil, &okcoin.APIError{Code: codeSecretNotFound}
+	}
+
+	signed := make(url.Values)
+	for key, values := range qv {
+		if key != "sign" {
+			signed[key] = values
+		}
+	}
+	h := md5.New()
+	fmt.Fprintf(h, "%s&secret_key=%s", signed.Encode(), acct.secret)
+	if want := strings.ToUpper(fmt.Sprintf("%x", h.Sum(nil))); qv.Get("sign") != want {
+		return nil, &okcoin.APIError{Code: codeSignatureMismatch}
+	}
+	return acct, nil
+}
+
+func symbolOf(qv url.Values) (okcoin.Symbol, error) {
+	sym := okcoin.Symbol(qv.Get("symbol"))
+	if sym == "" {
+		return "", &okcoin.APIError{Code: codeRequiredField}
+	}
+	return sym, nil
+}
+
+// intOf returns the integer parameter key, or 0 if it is absent.
+func intOf(qv url.Values, key string) (int64, error) {
+	v := qv.Get(key)
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &okcoin.APIError{Code: codeIllegalParameter}
+	}
+	return i, nil
+}
+
+func (s *Server) ticker(qv url.Values) (interface{}, error) {
+	sym, err := symbolOf(qv)
+	if err != nil {
+		return nil, err
+	}
+	return (*market)(s).Ticker(sym)
+}
+
+// lastTrades returns up to 60 trades after the ID "since",
+// or the 60 most recent ones if it is not set.
+func (s *Server) lastTrades(qv url.Values) (interface{}, error) {
+	sym, err := symbolOf(qv)
+	if err != nil {
+		return nil, err
+	}
+	since, err := intOf(qv, "since")
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trades := make([]*okcoin.Trade, 0)
+	for _, trade := range s.trades[sym] {
+		if trade.ID > since {
+			trades = append(trades, trade)
+		}
+	}
+	if len(trades) > maxTrades {
+		if since > 0 {
+			trades = trades[:maxTrades]
+		} else {
+			trades = trades[len(trades)-maxTrades:]
+		}
+	}
+	return trades, nil
+}
+
+// kline returns the candle sticks from "since", in milliseconds, limited
+// to the first "size" of them, or to the most recent if since is not set.
+func (s *Server) kline(qv url.Values) (interface{}, error) {
+	sym, err := symbolOf(qv)
+	if err != nil {
+		return nil, err
+	}
+	since, err := intOf(qv, "since")
+	if err != nil {
+		return nil, err
+	}
+	size, err := intOf(qv, "size")
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows := make([][]float64, 0)
+	for _, cs := range s.candles[candleKey{sym, okcoin.Period(qv.Get("type"))}] {
+		if cs.TimeStampMs >= float64(since) {
+			rows = append(rows, []float64{cs.TimeStampMs, cs.Open, cs.High, cs.Low, cs.Close, cs.Volume})
+		}
+	}
+	if size > 0 && int64(len(rows)) > size {
+		if since > 0 {
+			rows = rows[:size]
+		} else {
+			rows = rows[int64(len(rows))-size:]
+		}
+	}
+	return rows, nil
+}
+
+// depth lists the asks from the highest price down like the exchange does.
+func (s *Server) depth(qv url.Values) (interface{}, error) {
+	sym, err := symbolOf(qv)
+	if err != nil {
+		return nil, err
+	}
+	size, err := intOf(qv, "size")
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 || size > maxDepthSize {
+		size = maxDepthSize
+	}
+	depth, err := (*market)(s).Depth(&okcoin.DepthRequest{Symbol: sym})
+	if err != nil {
+		return nil, err
+	}
+
+	asks, bids := depth.Asks, depth.Bids
+	if int64(len(asks)) > size {
+		asks = asks[:size]
+	}
+	if int64(len(bids)) > size {
+		bids = bids[:size]
+	}
+	reversed := make([]*okcoin.PriceLevel, len(asks))
+	for i, level := range asks {
+		reversed[len(asks)-1-i] = level
+	}
+	return map[string]interface{}{"asks": reversed, "bids": bids}, nil
+}
+
+func userInfo(acct *account, qv url.Values) (interface{}, error) {
+	funds, err := acct.exchange.Funds()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"result": true,
+		"info":   map[string]interface{}{"funds": funds},
+	}, nil
+}
+
+func trade(acct *account, qv url.Values) (interface{}, error) {
+	sym, err := symbolOf(qv)
+	if err != nil {
+		return nil, err
+	}
+	oreq := &okcoin.OrderRequest{Symbol: sym, Type: okcoin.OrderType(qv.Get("type"))}
+	for key, d := range map[string]*okcoin.Decimal{"price": &oreq.Price, "amount": &oreq.Amount} {
+		if v := qv.Get(key); v != "" {
+			if *d, err = okcoin.ParseDecimal(v); err != nil {
+				return nil, &okcoin.APIError{Code: codeIllegalParameter}
+			}
+		}
+	}
+	ores, err := acct.exchange.PlaceOrder(oreq)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"result": true, "order_id": ores.OrderID}, nil
+}
+
+func cancelOrder(acct *account, qv url.Values) (interface{}, error) {
+	sym, err := symbolOf(qv)
+	if err != nil {
+		return nil, err
+	}
+	orderID, err := intOf(qv, "order_id")
+	if err != nil {
+		return nil, err
+	}
+	if err := acct.exchange.CancelOrder(sym, orderID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"result": true, "order_id": strconv.FormatInt(orderID, 10)}, nil
+}
+
+// orderInfo answers an order_id of -1 with every open order.
+func orderInfo(acct *account, qv url.Values) (interface{}, error) {
+	sym, err := symbolOf(qv)
+	if err != nil {
+		return nil, err
+	}
+	orderID, err := intOf(qv, "order_id")
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []*okcoin.Order
+	if orderID == -1 {
+		orders, err = acct.exchange.OpenOrders(sym)
+	} else {
+		var order *okcoin.Order
+		if order, err = acct.exchange.Order(sym, orderID); err == nil {
+			orders = []*okcoin.Order{order}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = make([]*okcoin.Order, 0)
+	}
+	return map[string]interface{}{"result": true, "orders": orders}, nil
+}
+
+// orderHistory pages through the orders, most recent first, that were
+// at least partially filled if "status" is 1 or not filled at all if it is 0.
+func orderHistory(acct *account, qv url.Values) (interface{}, error) {
+	sym, err := symbolOf(qv)
+	if err != nil {
+		return nil, err
+	}
+	status := qv.Get("status")
+	if status != "0" && status != "1" {
+		return nil, &okcoin.APIError{Code: codeRequiredField}
+	}
+	page, err := intOf(qv, "current_page")
+	if err != nil {
+		return nil, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	pageLength, err := intOf(qv, "page_length")
+	if err != nil {
+		return nil, err
+	}
+	if pageLength <= 0 || pageLength > maxOrderHistoryLength {
+		pageLength = maxOrderHistoryLength
+	}
+
+	all, err := acct.exchange.Orders(sym)
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]*okcoin.Order, 0)
+	for i := len(all) - 1; i >= 0; i-- {
+		if filled := !all[i].DealAmount.IsZero(); filled == (status == "1") {
+			matching = append(matching, all[i])
+		}
+	}
+	orders := make([]*okcoin.Order, 0)
+	if start := (page - 1) * pageLength; start < int64(len(matching)) {
+		end := start + pageLength
+		if end > int64(len(matching)) {
+			end = int64(len(matching))
+		}
+		orders = matching[start:end]
+	}
+	return map[string]interface{}{
+		"result":        true,
+		"total":         len(matching),
+		"currency_page": page,
+		"page_length":   pageLength,
+		"orders":        orders,
+	}, nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcointest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Method string `json:"method"`
+	// URL has its secret parameters redacted.
+	URL string `json:"url"`
+
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Redacted replaces the values of secret parameters and headers.
+const Redacted = "REDACTED"
+
+// secretParams are left out of the keys that replays match by.
+var secretParams = map[string]bool{
+	"api_key":    true,
+	"secret_key": true,
+	"sign":       true,
+}
+
+var secretHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"Ok-Access-Key",
+	"Ok-Access-Passphrase",
+	"Ok-Access-Sign",
+}
+
+// Recorder is an http.RoundTripper, for use with
+// okcoin.Client.SetHTTPRoundTripper, that either records
+// interactions to a file or replays them from it.
+//
+// Replays match requests by method and by URL, ignoring the order of
+// the query parameters and the values of the API key and signature.
+// Identical requests are answered in the order they were recorded,
+// with the last answer repeated. Request bodies are not matched since
+// the client sends every parameter in the query string.
+type Recorder struct {
+	path   string
+	replay bool
+	base   http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	served       map[string]int
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// NewRecorder returns a recorder passing requests on to base, or to
+// http.DefaultTransport if nil. Save writes what it recorded to path.
+func NewRecorder(path string, base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{path: path, base: base}
+}
+
+// NewReplayer returns a recorder serving the interactions saved at path.
+func NewReplayer(path string) (*Recorder, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []*Interaction
+	if err := json.Unmarshal(blob, &interactions); err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		path:         path,
+		replay:       true,
+		interactions: interactions,
+		served:       make(map[string]int),
+	}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.replay {
+		return r.replayRoundTrip(req)
+	}
+
+	res, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, &Interaction{
+		Method:     req.Method,
+		URL:        redactURL(req.URL),
+		StatusCode: res.StatusCode,
+		Header:     redactHeader(res.Header),
+		Body:       string(body),
+	})
+	return res, nil
+}
+
+func (r *Recorder) replayRoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := matchKey(req.Method, req.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []*Interaction
+	for _, in := range r.interactions {
+		if inKey, err := matchKey(in.Method, in.URL); err == nil && inKey == key {
+			matches = append(matches, in)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("okcointest: no recorded interaction for %s", key)
+	}
+	i := r.served[key]
+	if i >= len(matches) {
+		i = len(matches) - 1
+	}
+	r.served[key]++
+
+	in := matches[i]
+	header := make(http.Header)
+	for key, values := range in.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		StatusCode: in.StatusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(in.Body)),
+		Request:    req,
+	}, nil
+}
+
+var errSaveReplay = errors.New("okcointest: a replayer has nothing new to save")
+
+// Save writes the recorded interactions to the recorder's path.
+func (r *Recorder) Save() error {
+	if r.replay {
+		return errSaveReplay
+	}
+	r.mu.Lock()
+	blob, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, blob, 0644)
+}
+
+// Interactions returns what was recorded or loaded for replay.
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction(nil), r.interactions...)
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	qv := u.Query()
+	for key := range qv {
+		if secretParams[key] {
+			qv.Set(key, Redacted)
+		}
+	}
+	redacted.RawQuery = qv.Encode()
+	redacted.User = nil
+	return redacted.String()
+}
+
+func redactHeader(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for key, values := range h {
+		redacted[key] = append([]string(nil), values...)
+	}
+	for _, key := range secretHeaders {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, Redacted)
+		}
+	}
+	return redacted
+}
+
+// matchKey normalizes a request into the method and its URL with
+// the query parameters sorted and the secret ones left out.
+func matchKey(method, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	qv := u.Query()
+	for key := range qv {
+		if secretParams[key] {
+			qv.Del(key)
+		}
+	}
+	normalized := url.URL{
+		Scheme:   strings.ToLower(u.Scheme),
+		Host:     strings.ToLower(u.Host),
+		Path:     u.Path,
+		RawQuery: qv.Encode(),
+	}
+	return strings.ToUpper(method) + " " + normalized.String(), nil
+}
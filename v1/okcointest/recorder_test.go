@@ -0,0 +1,110 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcointest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/okcointest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "okcointest")
+	if err != nil {
+		t.Fatalf("temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	srv := newServer(t)
+	rec := okcointest.NewRecorder(path, srv.Transport())
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(rec)
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+
+	if _, err := client.Ticker(okcoin.BTCUSD); err != nil {
+		t.Fatalf("record ticker: %v", err)
+	}
+	srv.SetTicker(okcoin.BTCUSD, &okcoin.TickerResponse{Ticker: &okcoin.Ticker{Last: 5000}})
+	if _, err := client.Ticker(okcoin.BTCUSD); err != nil {
+		t.Fatalf("record second ticker: %v", err)
+	}
+	if _, err := client.Funds(); err != nil {
+		t.Fatalf("record funds: %v", err)
+	}
+	srv.Close()
+	if err := rec.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette: %v", err)
+	}
+	saved := string(blob)
+	if strings.Contains(saved, "api_key="+apiKey1) {
+		t.Errorf("cassette leaks the API key: %s", saved)
+	}
+	for _, param := range []string{"api_key=", "sign="} {
+		if !strings.Contains(saved, param+okcointest.Redacted) {
+			t.Errorf("cassette: want %q redacted, got %s", param, saved)
+		}
+	}
+
+	replayer, err := okcointest.NewReplayer(path)
+	if err != nil {
+		t.Fatalf("new replayer: %v", err)
+	}
+	// Other credentials sign differently yet match the recording.
+	replay, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	replay.SetHTTPRoundTripper(replayer)
+	replay.SetCredentials(&okcoin.Credentials{APIKey: "other", Secret: "other"})
+
+	for i, want := range []float64{4592, 5000, 5000} {
+		tres, err := replay.Ticker(okcoin.BTCUSD)
+		if err != nil {
+			t.Fatalf("replay ticker #%d: %v", i, err)
+		}
+		if g := tres.Ticker.Last; g != want {
+			t.Errorf("replay ticker #%d: got=%v want=%v", i, g, want)
+		}
+	}
+	funds, err := replay.Funds()
+	if err != nil {
+		t.Fatalf("replay funds: %v", err)
+	}
+	if g, w := funds.Free.Balance(okcoin.USD), 10000.0; g != w {
+		t.Errorf("replay funds: got=%v want=%v", g, w)
+	}
+	if _, err := replay.Ticker(okcoin.LTCUSD); err == nil {
+		t.Errorf("unrecorded request: want non-nil error")
+	}
+	if err := replayer.Save(); err == nil {
+		t.Errorf("saving a replayer: want non-nil error")
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package okcointest provides an in-process fake OKCoin exchange for tests.
+//
+// A Server serves the public ticker, trades, kline and depth endpoints
+// from data set by the test, and the signed userinfo, trade, cancel_order,
+// order_info and order_history endpoints for accounts registered with
+// their API keys, rejecting requests whose MD5 signatures do not match.
+// Orders are kept and matched against the server's tickers by a
+// paper.Exchange per account. Errors and latency can be injected per
+// endpoint.
+//
+// The client's base URL cannot be changed, so requests are redirected
+// to the server by the round tripper of Server.Transport:
+//
+//	srv := okcointest.NewServer()
+//	defer srv.Close()
+//	client, err := srv.NewClient(nil)
+//
+// A Recorder captures the interactions with a real or fake exchange,
+// with secrets redacted, and replays them in later runs.
+package okcointest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/paper"
+)
+
+type Server struct {
+	// URL is the base URL of the server, like http://127.0.0.1:1234.
+	URL string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	tickers  map[okcoin.Symbol]*okcoin.TickerResponse
+	trades   map[okcoin.Symbol][]*okcoin.Trade
+	candles  map[candleKey][]*okcoin.CandleStick
+	depths   map[okcoin.Symbol]*okcoin.Depth
+	accounts map[string]*account
+	codes    map[string]int
+	statuses map[string]int
+	latency  time.Duration
+}
+
+type candleKey struct {
+	symbol okcoin.Symbol
+	period okcoin.Period
+}
+
+type account struct {
+	secret   string
+	exchange *paper.Exchange
+}
+
+// NewServer starts a server without any data or accounts.
+// It must be closed once the test is done.
+func NewServer() *Server {
+	s := &Server{
+		tickers:  make(map[okcoin.Symbol]*okcoin.TickerResponse),
+		trades:   make(map[okcoin.Symbol][]*okcoin.Trade),
+		candles:  make(map[candleKey][]*okcoin.CandleStick),
+		depths:   make(map[okcoin.Symbol]*okcoin.Depth),
+		accounts: make(map[string]*account),
+		codes:    make(map[string]int),
+		statuses: make(map[string]int),
+	}
+	s.srv = httptest.NewServer(s.handler())
+	s.URL = s.srv.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Transport returns a round tripper that sends every
+// request to the server, whatever its original host.
+func (s *Server) Transport() http.RoundTripper {
+	target, _ := url.Parse(s.URL)
+	return &rewriter{target: target, base: s.srv.Client().Transport}
+}
+
+type rewriter struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (rw *rewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Round trippers must not modify the request they are given.
+	redirected := req.WithContext(req.Context())
+	u := *req.URL
+	u.Scheme, u.Host = rw.target.Scheme, rw.target.Host
+	redirected.URL = &u
+	redirected.Host = rw.target.Host
+	return rw.base.RoundTrip(redirected)
+}
+
+// NewClient returns a client talking to the server with creds, if set.
+func (s *Server) NewClient(creds *okcoin.Credentials) (*okcoin.Client, error) {
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		return nil, err
+	}
+	client.SetHTTPRoundTripper(s.Transport())
+	if creds != nil {
+		client.SetCredentials(creds)
+	}
+	return client, nil
+}
+
+func (s *Server) SetTicker(sym okcoin.Symbol, tres *okcoin.TickerResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tickers[sym] = tres
+}
+
+// SetTrades sets the trades served for sym, in ascending order of ID.
+func (s *Server) SetTrades(sym okcoin.Symbol, trades []*okcoin.Trade) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trades[sym] = append([]*okcoin.Trade(nil), trades...)
+}
+
+// SetCandleSticks sets the candle sticks served for
+// sym and period, in ascending order of time.
+func (s *Server) SetCandleSticks(sym okcoin.Symbol, period okcoin.Period, csticks []*okcoin.CandleStick) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.candles[candleKey{sym, period}] = append([]*okcoin.CandleStick(nil), csticks...)
+}
+
+// SetDepth sets the order book served for sym,
+// with both sides sorted best price first.
+func (s *Server) SetDepth(sym okcoin.Symbol, depth *okcoin.Depth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.depths[sym] = depth
+}
+
+// AddAccount registers an account with its API key, secret and
+// initial free balances. Its orders fill against the server's
+// tickers, charging no fees.
+func (s *Server) AddAccount(apiKey, secret string, balances map[okcoin.Currency]float64) error {
+	ex, err := paper.New(&paper.Config{Market: (*market)(s), Balances: balances})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[apiKey] = &account{secret: secret, exchange: ex}
+	return nil
+}
+
+// Match fills the resting orders of every account
+// that the server's tickers now cross.
+func (s *Server) Match() error {
+	s.mu.Lock()
+	var exchanges []*paper.Exchange
+	for _, acct := range s.accounts {
+		exchanges = append(exchanges, acct.exchange)
+	}
+	s.mu.Unlock()
+
+	for _, ex := range exchanges {
+		if err := ex.Match(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FailWithCode makes the endpoint, like "ticker.do", answer
+// {"result":false,"error_code":code}. A code of 0 clears it.
+func (s *Server) FailWithCode(endpoint string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code == 0 {
+		delete(s.codes, endpoint)
+	} else {
+		s.codes[endpoint] = code
+	}
+}
+
+// FailWithStatus makes the endpoint answer with the HTTP
+// status and an empty body. A status of 0 clears it.
+func (s *Server) FailWithStatus(endpoint string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.statuses, endpoint)
+	} else {
+		s.statuses[endpoint] = status
+	}
+}
+
+// SetLatency delays every response by d, or until the request is cancelled.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// market serves the server's data to the accounts' exchanges.
+type market Server
+
+var _ paper.Market = (*market)(nil)
+
+func (m *market) Ticker(sym okcoin.Symbol) (*okcoin.TickerResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tres, ok := m.tickers[sym]
+	if !ok {
+		return nil, &okcoin.APIError{Code: codeIllegalParameter}
+	}
+	return tres, nil
+}
+
+func (m *market) Depth(dr *okcoin.DepthRequest) (*okcoin.Depth, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	depth, ok := m.depths[dr.Symbol]
+	if !ok {
+		return nil, &okcoin.APIError{Code: codeIllegalParameter}
+	}
+	return depth, nil
+}
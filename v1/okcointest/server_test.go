@@ -0,0 +1,303 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcointest_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/okcointest"
+	"github.com/orijtech/okcoin/v1/pnl"
+)
+
+const (
+	apiKey1    = "key1"
+	apiSecret1 = "secret1"
+)
+
+func newServer(t *testing.T) *okcointest.Server {
+	srv := okcointest.NewServer()
+	srv.SetTicker(okcoin.BTCUSD, &okcoin.TickerResponse{
+		TimeAtEpoch: 1503960025,
+		Ticker:      &okcoin.Ticker{Buy: 4590, Sell: 4594.17, Last: 4592},
+	})
+	if err := srv.AddAccount(apiKey1, apiSecret1, map[okcoin.Currency]float64{okcoin.USD: 10000}); err != nil {
+		srv.Close()
+		t.Fatalf("add account: %v", err)
+	}
+	return srv
+}
+
+func TestMarketData(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+
+	var trades []*okcoin.Trade
+	for id := int64(1); id <= 100; id++ {
+		trades = append(trades, &okcoin.Trade{ID: id, Price: 4590, Amount: 0.1, Date: 1503960000})
+	}
+	srv.SetTrades(okcoin.BTCUSD, trades)
+	srv.SetCandleSticks(okcoin.BTCUSD, okcoin.P1Min, []*okcoin.CandleStick{
+		{TimeStampMs: 1503960000000, Open: 1, High: 2, Low: 1, Close: 2, Volume: 3},
+		{TimeStampMs: 1503960060000, Open: 2, High: 3, Low: 2, Close: 3, Volume: 4},
+	})
+	srv.SetDepth(okcoin.BTCUSD, &okcoin.Depth{
+		Asks: []*okcoin.PriceLevel{{Price: 4594.17, Amount: 1}, {Price: 4600, Amount: 2}},
+		Bids: []*okcoin.PriceLevel{{Price: 4590, Amount: 1}},
+	})
+
+	client, err := srv.NewClient(nil)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	tres, err := client.Ticker(okcoin.BTCUSD)
+	if err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	if g, w := tres.Ticker.Last, 4592.0; g != w {
+		t.Errorf("ticker last: got=%v want=%v", g, w)
+	}
+	if _, err := client.Ticker(okcoin.LTCUSD); err == nil {
+		t.Errorf("unknown ticker: want non-nil error")
+	}
+
+	ltres, err := client.LastTrades(&okcoin.LastTradesRequest{Symbol: okcoin.BTCUSD, LastTradeID: 10})
+	if err != nil {
+		t.Fatalf("trades: %v", err)
+	}
+	if n := len(ltres.Trades); n != 60 || ltres.Trades[0].ID != 11 {
+		t.Errorf("trades: got %d from #%d want 60 from #11", n, ltres.Trades[0].ID)
+	}
+
+	cres, err := client.CandleStick(&okcoin.CandleStickRequest{Symbol: okcoin.BTCUSD, Period: okcoin.P1Min, N: 1})
+	if err != nil {
+		t.Fatalf("candle sticks: %v", err)
+	}
+	if len(cres.CandleSticks) != 1 || cres.CandleSticks[0].Close != 3 {
+		t.Errorf("candle sticks: got=%+v want the most recent", cres.CandleSticks)
+	}
+
+	depth, err := client.Depth(&okcoin.DepthRequest{Symbol: okcoin.BTCUSD})
+	if err != nil {
+		t.Fatalf("depth: %v", err)
+	}
+	if g, w := depth.BestAsk().Price, 4594.17; g != w {
+		t.Errorf("best ask: got=%v want=%v", g, w)
+	}
+}
+
+func TestTrading(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+
+	client, err := srv.NewClient(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	filled, err := client.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+		Price: okcoin.MustParseDecimal("4600"), Amount: okcoin.MustParseDecimal("1"),
+	})
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	resting, err := client.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+		Price: okcoin.MustParseDecimal("4000"), Amount: okcoin.MustParseDecimal("1"),
+	})
+	if err != nil {
+		t.Fatalf("place resting: %v", err)
+	}
+
+	order, err := client.Order(okcoin.BTCUSD, filled.OrderID)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	if g, w := order.Status, okcoin.StatusFilled; g != w {
+		t.Errorf("status: got=%v want=%v", g, w)
+	}
+	open, err := client.OpenOrders(okcoin.BTCUSD)
+	if err != nil {
+		t.Fatalf("open orders: %v", err)
+	}
+	if len(open) != 1 || open[0].ID != resting.OrderID {
+		t.Errorf("open orders: got=%+v want #%d", open, resting.OrderID)
+	}
+
+	funds, err := client.Funds()
+	if err != nil {
+		t.Fatalf("funds: %v", err)
+	}
+	if g, w := funds.Free.Balance(okcoin.BTC), 1.0; g != w {
+		t.Errorf("free btc: got=%v want=%v", g, w)
+	}
+	if g, w := funds.Frozen.Balance(okcoin.USD), 4000.0; g != w {
+		t.Errorf("frozen usd: got=%v want=%v", g, w)
+	}
+
+	if err := client.CancelOrder(okcoin.BTCUSD, resting.OrderID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	err = client.CancelOrder(okcoin.BTCUSD, resting.OrderID)
+	if ae, ok := err.(*okcoin.APIError); !ok || ae.Code != 10009 {
+		t.Errorf("cancelling twice: got=%v want error_code 10009", err)
+	}
+}
+
+func TestOrderHistory(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+
+	client, err := srv.NewClient(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	var filledIDs []int64
+	for i := 0; i < 3; i++ {
+		ores, err := client.PlaceOrder(&okcoin.OrderRequest{
+			Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+			Price: okcoin.MustParseDecimal("4600"), Amount: okcoin.MustParseDecimal("0.1"),
+		})
+		if err != nil {
+			t.Fatalf("place #%d: %v", i, err)
+		}
+		filledIDs = append(filledIDs, ores.OrderID)
+	}
+	resting, err := client.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+		Price: okcoin.MustParseDecimal("4000"), Amount: okcoin.MustParseDecimal("1"),
+	})
+	if err != nil {
+		t.Fatalf("place resting: %v", err)
+	}
+
+	var pages [][]int64
+	for page := 1; page <= 3; page++ {
+		ohres, err := client.OrderHistory(&okcoin.OrderHistoryRequest{Symbol: okcoin.BTCUSD, Filled: true, Page: page, PageLength: 2})
+		if err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+		if ohres.Total != 3 || ohres.Page != page || ohres.PageLength != 2 {
+			t.Errorf("page %d: got=%+v", page, ohres)
+		}
+		var ids []int64
+		for _, order := range ohres.Orders {
+			ids = append(ids, order.ID)
+		}
+		pages = append(pages, ids)
+	}
+	want := [][]int64{{filledIDs[2], filledIDs[1]}, {filledIDs[0]}, nil}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("filled pages: got=%v want=%v", pages, want)
+	}
+
+	ohres, err := client.OrderHistory(&okcoin.OrderHistoryRequest{Symbol: okcoin.BTCUSD})
+	if err != nil {
+		t.Fatalf("unfilled: %v", err)
+	}
+	if len(ohres.Orders) != 1 || ohres.Orders[0].ID != resting.OrderID {
+		t.Errorf("unfilled: got=%+v want #%d", ohres.Orders, resting.OrderID)
+	}
+
+	fills, err := pnl.FetchFills(client, okcoin.BTCUSD, okcoin.Decimal{})
+	if err != nil {
+		t.Fatalf("fetch fills: %v", err)
+	}
+	if len(fills) != 3 {
+		t.Fatalf("fills: got %d want 3", len(fills))
+	}
+	for _, fill := range fills {
+		if fill.Side != pnl.Buy || !fill.Amount.Equal(okcoin.MustParseDecimal("0.1")) || !fill.Price.Equal(okcoin.MustParseDecimal("4594.17")) {
+			t.Errorf("fill: got=%+v", fill)
+		}
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+
+	tests := []struct {
+		creds    *okcoin.Credentials
+		wantCode int
+	}{
+		{&okcoin.Credentials{APIKey: apiKey1, Secret: "wrong"}, 10007},
+		{&okcoin.Credentials{APIKey: "unknown", Secret: apiSecret1}, 10006},
+	}
+	for i, tt := range tests {
+		client, err := srv.NewClient(tt.creds)
+		if err != nil {
+			t.Fatalf("#%d: new client: %v", i, err)
+		}
+		_, err = client.Funds()
+		if ae, ok := err.(*okcoin.APIError); !ok || ae.Code != tt.wantCode {
+			t.Errorf("#%d: got=%v want error_code %d", i, err, tt.wantCode)
+		}
+	}
+}
+
+func TestInjectedFailures(t *testing.T) {
+	t.Parallel()
+
+	srv := newServer(t)
+	defer srv.Close()
+
+	client, err := srv.NewClient(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	srv.FailWithCode("userinfo.do", 10001)
+	_, err = client.Funds()
+	if ae, ok := err.(*okcoin.APIError); !ok || ae.Code != 10001 {
+		t.Errorf("injected code: got=%v want error_code 10001", err)
+	}
+	srv.FailWithCode("userinfo.do", 0)
+	if _, err := client.Funds(); err != nil {
+		t.Errorf("cleared code: %v", err)
+	}
+
+	srv.FailWithStatus("ticker.do", http.StatusServiceUnavailable)
+	if _, err := client.Ticker(okcoin.BTCUSD); err == nil {
+		t.Errorf("injected status: want non-nil error")
+	}
+	srv.FailWithStatus("ticker.do", 0)
+
+	srv.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	res := client.Tickers(ctx, okcoin.BTCUSD)[okcoin.BTCUSD]
+	if res == nil || res.Err == nil {
+		t.Errorf("injected latency: want the deadline to be exceeded")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("injected latency: took %v despite the deadline", elapsed)
+	}
+}
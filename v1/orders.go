@@ -0,0 +1,317 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type OrderType string
+
+const (
+	Buy  OrderType = "buy"
+	Sell OrderType = "sell"
+
+	// For market buys Price is the total amount of the
+	// quote currency to spend and Amount is ignored.
+	BuyMarket OrderType = "buy_market"
+	// For market sells Amount is the amount of the base
+	// currency to sell and Price is ignored.
+	SellMarket OrderType = "sell_market"
+)
+
+type OrderStatus int
+
+const (
+	StatusCancelled       OrderStatus = -1
+	StatusUnfilled        OrderStatus = 0
+	StatusPartiallyFilled OrderStatus = 1
+	StatusFilled          OrderStatus = 2
+	StatusCancelling      OrderStatus = 4
+)
+
+func (s OrderStatus) String() string {
+	switch s {
+	case StatusCancelled:
+		return "cancelled"
+	case StatusUnfilled:
+		return "unfilled"
+	case StatusPartiallyFilled:
+		return "partially filled"
+	case StatusFilled:
+		return "filled"
+	case StatusCancelling:
+		return "cancelling"
+	default:
+		return fmt.Sprintf("OrderStatus(%d)", int(s))
+	}
+}
+
+type OrderRequest struct {
+	Symbol Symbol    `json:"symbol"`
+	Type   OrderType `json:"type"`
+	Price  Decimal   `json:"price"`
+	Amount Decimal   `json:"amount"`
+}
+
+var (
+	errBlankOrderType   = errors.New("expecting a non-blank order type")
+	errNonPositivePrice = errors.New("expecting a positive price")
+	errNonPositiveAmt   = errors.New("expecting a positive amount")
+)
+
+func (oreq *OrderRequest) Validate() error {
+	if oreq == nil || oreq.Symbol == "" {
+		return errBlankSymbol
+	}
+	switch oreq.Type {
+	case "":
+		return errBlankOrderType
+	case Buy, Sell:
+		if oreq.Price.Sign() <= 0 {
+			return errNonPositivePrice
+		}
+		if oreq.Amount.Sign() <= 0 {
+			return errNonPositiveAmt
+		}
+	case BuyMarket:
+		if oreq.Price.Sign() <= 0 {
+			return errNonPositivePrice
+		}
+	case SellMarket:
+		if oreq.Amount.Sign() <= 0 {
+			return errNonPositiveAmt
+		}
+	default:
+		return fmt.Errorf("unknown order type %q", oreq.Type)
+	}
+	return nil
+}
+
+// values returns the order's parameters with prices and amounts
+// formatted from their exact decimal digits.
+func (oreq *OrderRequest) values() url.Values {
+	qv := make(url.Values)
+	qv.Set("symbol", string(oreq.Symbol))
+	qv.Set("type", string(oreq.Type))
+	if oreq.Type != SellMarket {
+		qv.Set("price", oreq.Price.String())
+	}
+	if oreq.Type != BuyMarket {
+		qv.Set("amount", oreq.Amount.String())
+	}
+	return qv
+}
+
+type OrderResponse struct {
+	OrderID int64 `json:"order_id"`
+}
+
+func (c *Client) PlaceOrder(oreq *OrderRequest) (*OrderResponse, error) {
+	return c.PlaceOrderContext(context.Background(), oreq)
+}
+
+// PlaceOrderContext is like PlaceOrder but gives up once ctx is done.
+// The order may still have been placed if ctx is done after sending it.
+func (c *Client) PlaceOrderContext(ctx context.Context, oreq *OrderRequest) (*OrderResponse, error) {
+	if err := oreq.Validate(); err != nil {
+		return nil, err
+	}
+	blob, err := c.doSignedReq(ctx, "trade.do", oreq.values())
+	if err != nil {
+		return nil, err
+	}
+	ores := new(OrderResponse)
+	if err := json.Unmarshal(blob, ores); err != nil {
+		return nil, err
+	}
+	return ores, nil
+}
+
+// PlaceOrderRequest returns the signed request that PlaceOrder
+// would send for oreq, without sending it, e.g. for a dry run.
+func (c *Client) PlaceOrderRequest(oreq *OrderRequest) (*http.Request, error) {
+	if err := oreq.Validate(); err != nil {
+		return nil, err
+	}
+	return c.signedReq(V1BaseURL, "trade.do", oreq.values())
+}
+
+func (c *Client) CancelOrder(sym Symbol, orderID int64) error {
+	return c.CancelOrderContext(context.Background(), sym, orderID)
+}
+
+// CancelOrderContext is like CancelOrder but gives up once ctx is done.
+func (c *Client) CancelOrderContext(ctx context.Context, sym Symbol, orderID int64) error {
+	if sym == "" {
+		return errBlankSymbol
+	}
+	_, err := c.doSignedReq(ctx, "cancel_order.do", cancelValues(sym, orderID))
+	return err
+}
+
+// CancelOrderRequest returns the signed request that CancelOrder
+// would send, without sending it.
+func (c *Client) CancelOrderRequest(sym Symbol, orderID int64) (*http.Request, error) {
+	if sym == "" {
+		return nil, errBlankSymbol
+	}
+	return c.signedReq(V1BaseURL, "cancel_order.do", cancelValues(sym, orderID))
+}
+
+func cancelValues(sym Symbol, orderID int64) url.Values {
+	qv := make(url.Values)
+	qv.Set("symbol", string(sym))
+	qv.Set("order_id", strconv.FormatInt(orderID, 10))
+	return qv
+}
+
+type Order struct {
+	ID     int64       `json:"order_id"`
+	Symbol Symbol      `json:"symbol"`
+	Type   OrderType   `json:"type"`
+	Status OrderStatus `json:"status"`
+
+	Price      Decimal `json:"price"`
+	Amount     Decimal `json:"amount"`
+	AvgPrice   Decimal `json:"avg_price"`
+	DealAmount Decimal `json:"deal_amount"`
+
+	CreateDate Timestamp `json:"create_date"`
+}
+
+type orderInfoResponse struct {
+	Orders []*Order `json:"orders"`
+}
+
+// allOpenOrdersID asks order_info.do for every unfilled order.
+const allOpenOrdersID = -1
+
+func (c *Client) orderInfo(ctx context.Context, sym Symbol, orderID int64) ([]*Order, error) {
+	if sym == "" {
+		return nil, errBlankSymbol
+	}
+	qv := make(url.Values)
+	qv.Set("symbol", string(sym))
+	qv.Set("order_id", strconv.FormatInt(orderID, 10))
+	blob, err := c.doSignedReq(ctx, "order_info.do", qv)
+	if err != nil {
+		return nil, err
+	}
+	oires := new(orderInfoResponse)
+	if err := json.Unmarshal(blob, oires); err != nil {
+		return nil, err
+	}
+	return oires.Orders, nil
+}
+
+var errOrderNotFound = &APIError{Code: 10009}
+
+func (c *Client) Order(sym Symbol, orderID int64) (*Order, error) {
+	return c.OrderContext(context.Background(), sym, orderID)
+}
+
+// OrderContext is like Order but gives up once ctx is done.
+func (c *Client) OrderContext(ctx context.Context, sym Symbol, orderID int64) (*Order, error) {
+	orders, err := c.orderInfo(ctx, sym, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.ID == orderID {
+			return order, nil
+		}
+	}
+	return nil, errOrderNotFound
+}
+
+// OpenOrders returns the unfilled and partially filled orders for sym.
+func (c *Client) OpenOrders(sym Symbol) ([]*Order, error) {
+	return c.OpenOrdersContext(context.Background(), sym)
+}
+
+// OpenOrdersContext is like OpenOrders but gives up once ctx is done.
+func (c *Client) OpenOrdersContext(ctx context.Context, sym Symbol) ([]*Order, error) {
+	return c.orderInfo(ctx, sym, allOpenOrdersID)
+}
+
+type OrderHistoryRequest struct {
+	Symbol Symbol `json:"symbol"`
+
+	// Filled selects the orders that were at least partially
+	// filled, otherwise the unfilled ones are returned.
+	Filled bool `json:"filled"`
+
+	// Page starts at and defaults to 1.
+	Page int `json:"current_page"`
+	// PageLength is at most and defaults to 200.
+	PageLength int `json:"page_length"`
+}
+
+type OrderHistoryResponse struct {
+	Total int `json:"total"`
+	// The exchange misspells the key as "currency_page".
+	Page       int      `json:"currency_page"`
+	PageLength int      `json:"page_length"`
+	Orders     []*Order `json:"orders"`
+}
+
+const maxOrderHistoryPageLength = 200
+
+// OrderHistory returns one page of the account's
+// orders for a symbol, most recent first.
+func (c *Client) OrderHistory(ohr *OrderHistoryRequest) (*OrderHistoryResponse, error) {
+	return c.OrderHistoryContext(context.Background(), ohr)
+}
+
+// OrderHistoryContext is like OrderHistory but gives up once ctx is done.
+func (c *Client) OrderHistoryContext(ctx context.Context, ohr *OrderHistoryRequest) (*OrderHistoryResponse, error) {
+	if ohr == nil || ohr.Symbol == "" {
+		return nil, errBlankSymbol
+	}
+	page := ohr.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageLength := ohr.PageLength
+	if pageLength <= 0 || pageLength > maxOrderHistoryPageLength {
+		pageLength = maxOrderHistoryPageLength
+	}
+	status := "0"
+	if ohr.Filled {
+		status = "1"
+	}
+
+	qv := make(url.Values)
+	qv.Set("symbol", string(ohr.Symbol))
+	qv.Set("status", status)
+	qv.Set("current_page", strconv.Itoa(page))
+	qv.Set("page_length", strconv.Itoa(pageLength))
+	blob, err := c.doSignedReq(ctx, "order_history.do", qv)
+	if err != nil {
+		return nil, err
+	}
+	ohres := new(OrderHistoryResponse)
+	if err := json.Unmarshal(blob, ohres); err != nil {
+		return nil, err
+	}
+	return ohres, nil
+}
@@ -0,0 +1,374 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(&backend{route: ordersRoute})
+
+	tests := [...]struct {
+		req     *okcoin.OrderRequest
+		wantID  int64
+		wantErr string
+	}{
+		0: {req: nil, wantErr: "symbol"},
+		1: {req: &okcoin.OrderRequest{Symbol: okcoin.BTCUSD}, wantErr: "order type"},
+		2: {
+			req:     &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.Buy, Amount: okcoin.MustParseDecimal("1")},
+			wantErr: "positive price",
+		},
+		3: {
+			req:     &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.SellMarket},
+			wantErr: "positive amount",
+		},
+		4: {
+			// The exact digits must be sent, not "1e-05" nor "0.30000000000000004".
+			req: &okcoin.OrderRequest{
+				Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+				Price:  okcoin.MustParseDecimal("0.1").Add(okcoin.MustParseDecimal("0.2")),
+				Amount: okcoin.MustParseDecimal("0.00001"),
+			},
+			wantID: 1,
+		},
+		5: {
+			req: &okcoin.OrderRequest{
+				Symbol: okcoin.LTCUSD, Type: okcoin.BuyMarket,
+				Price: okcoin.MustParseDecimal("100"),
+			},
+			wantID: 2,
+		},
+		6: {
+			req: &okcoin.OrderRequest{
+				Symbol: okcoin.LTCUSD, Type: okcoin.Sell,
+				Price:  okcoin.MustParseDecimal("100"),
+				Amount: okcoin.MustParseDecimal("100000"),
+			},
+			wantErr: "error_code 10010",
+		},
+	}
+
+	for i, tt := range tests {
+		ores, err := client.PlaceOrder(tt.req)
+		if tt.wantErr != "" {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error; got %#v", i, ores)
+			} else if g, w := err.Error(), tt.wantErr; !strings.Contains(g, w) {
+				t.Errorf("#%d: got=%q want=%q", i, g, w)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: got err: %v", i, err)
+			continue
+		}
+		if g, w := ores.OrderID, tt.wantID; g != w {
+			t.Errorf("#%d: orderID: got=%d want=%d", i, g, w)
+		}
+	}
+}
+
+func TestOrderRequests(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	// Building the requests must not send them.
+	client.SetHTTPRoundTripper(&backend{route: "unreachable"})
+
+	req, err := client.PlaceOrderRequest(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Sell,
+		Price: okcoin.MustParseDecimal("4600.5"), Amount: okcoin.MustParseDecimal("0.25"),
+	})
+	if err != nil {
+		t.Fatalf("place order request: %v", err)
+	}
+	if g, w := req.Method, "POST"; g != w {
+		t.Errorf("method: got=%q want=%q", g, w)
+	}
+	if !strings.HasSuffix(req.URL.Path, "/trade.do") {
+		t.Errorf("path: got=%q want trade.do", req.URL.Path)
+	}
+	qv := req.URL.Query()
+	if resp, ok := checkSignature(qv); !ok {
+		t.Errorf("signature: %s", resp.Status)
+	}
+	if g, w := qv.Get("price"), "4600.5"; g != w {
+		t.Errorf("price: got=%q want=%q", g, w)
+	}
+
+	if _, err := client.PlaceOrderRequest(&okcoin.OrderRequest{Symbol: okcoin.BTCUSD}); err == nil {
+		t.Errorf("invalid order: want non-nil error")
+	}
+
+	req, err = client.CancelOrderRequest(okcoin.BTCUSD, 10000591)
+	if err != nil {
+		t.Fatalf("cancel order request: %v", err)
+	}
+	if g, w := req.URL.Query().Get("order_id"), "10000591"; g != w {
+		t.Errorf("order_id: got=%q want=%q", g, w)
+	}
+	if _, err := client.CancelOrderRequest("", 1); err == nil {
+		t.Errorf("blank symbol: want non-nil error")
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(&backend{route: ordersRoute})
+
+	if err := client.CancelOrder("", 10000591); err == nil {
+		t.Errorf("expected an error for a blank symbol")
+	}
+	if err := client.CancelOrder(okcoin.BTCUSD, 10000591); err != nil {
+		t.Errorf("cancel known order: %v", err)
+	}
+	err = client.CancelOrder(okcoin.BTCUSD, 404)
+	if ae, ok := err.(*okcoin.APIError); !ok || ae.Code != 10009 {
+		t.Errorf("cancel unknown order: got=%#v want APIError 10009", err)
+	} else if g, w := ae.Message(), "order does not exist"; g != w {
+		t.Errorf("message: got=%q want=%q", g, w)
+	}
+}
+
+// ctxChecker fails the requests whose context is done,
+// as a transport does when giving up on a request.
+type ctxChecker struct {
+	base http.RoundTripper
+}
+
+func (cc *ctxChecker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	return cc.base.RoundTrip(req)
+}
+
+func TestOrdersWithCancelledContext(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(&ctxChecker{base: &backend{route: ordersRoute}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	oreq := &okcoin.OrderRequest{Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+		Price: okcoin.MustParseDecimal("4000"), Amount: okcoin.MustParseDecimal("0.1")}
+	if _, err := client.PlaceOrderContext(ctx, oreq); err == nil {
+		t.Errorf("place: want non-nil error")
+	}
+	if err := client.CancelOrderContext(ctx, okcoin.BTCUSD, 10000591); err == nil {
+		t.Errorf("cancel: want non-nil error")
+	}
+	if _, err := client.OrderContext(ctx, okcoin.BTCUSD, 10000591); err == nil {
+		t.Errorf("order: want non-nil error")
+	}
+	if _, err := client.OpenOrdersContext(ctx, okcoin.BTCUSD); err == nil {
+		t.Errorf("open orders: want non-nil error")
+	}
+	if _, err := client.OrderHistoryContext(ctx, &okcoin.OrderHistoryRequest{Symbol: okcoin.BTCUSD}); err == nil {
+		t.Errorf("order history: want non-nil error")
+	}
+}
+
+func TestOrderInfo(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(&backend{route: ordersRoute})
+
+	orders, err := client.OpenOrders(okcoin.BTCUSD)
+	if err != nil {
+		t.Fatalf("open orders: %v", err)
+	}
+	if g, w := len(orders), 2; g != w {
+		t.Fatalf("open orders: got=%d want=%d", g, w)
+	}
+
+	order, err := client.Order(okcoin.BTCUSD, 10000724)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	// Bare JSON numbers must keep all their digits.
+	if g, w := order.Price.String(), "0.00000001"; g != w {
+		t.Errorf("price: got=%q want=%q", g, w)
+	}
+	if g, w := order.Amount.String(), "0.00001"; g != w {
+		t.Errorf("amount: got=%q want=%q", g, w)
+	}
+	if g, w := order.Status, okcoin.StatusPartiallyFilled; g != w {
+		t.Errorf("status: got=%v want=%v", g, w)
+	}
+
+	if _, err := client.Order(okcoin.BTCUSD, 404); err == nil {
+		t.Errorf("expected an error for an unknown order")
+	}
+}
+
+func TestOrderHistory(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(&backend{route: ordersRoute})
+
+	ohres, err := client.OrderHistory(&okcoin.OrderHistoryRequest{Symbol: okcoin.BTCUSD, Filled: true})
+	if err != nil {
+		t.Fatalf("order history: %v", err)
+	}
+	if g, w := ohres.Total, 3; g != w {
+		t.Errorf("total: got=%d want=%d", g, w)
+	}
+	if g, w := len(ohres.Orders), 3; g != w {
+		t.Fatalf("orders: got=%d want=%d", g, w)
+	}
+	if g, w := ohres.Orders[2].DealAmount.String(), "1.5"; g != w {
+		t.Errorf("deal amount: got=%q want=%q", g, w)
+	}
+
+	ohres, err = client.OrderHistory(&okcoin.OrderHistoryRequest{Symbol: okcoin.BTCUSD, Filled: true, Page: 2})
+	if err != nil {
+		t.Fatalf("order history page 2: %v", err)
+	}
+	if g, w := len(ohres.Orders), 0; g != w {
+		t.Errorf("page 2 orders: got=%d want=%d", g, w)
+	}
+
+	if _, err := client.OrderHistory(&okcoin.OrderHistoryRequest{}); err == nil {
+		t.Errorf("expected an error for a blank symbol")
+	}
+}
+
+// checkSignature mirrors the exchange's validation of signed requests.
+func checkSignature(qv url.Values) (*http.Response, bool) {
+	apiKey := qv.Get("api_key")
+	if apiKey == "" {
+		resp, _ := makeResp(`expecting "api_key" in query string`, http.StatusBadRequest, nil)
+		return resp, false
+	}
+	knownSecret, ok := knownAPIKeyToSecrets[apiKey]
+	if !ok || knownSecret == "" {
+		resp, _ := makeResp("unknown API secret", http.StatusUnauthorized, nil)
+		return resp, false
+	}
+	gotSignature := qv.Get("sign")
+	signed := make(url.Values)
+	for key, values := range qv {
+		if key != "sign" {
+			signed[key] = values
+		}
+	}
+	h := md5.New()
+	fmt.Fprintf(h, "%s&secret_key=%s", signed.Encode(), knownSecret)
+	if wantSignature := strings.ToUpper(fmt.Sprintf("%x", h.Sum(nil))); wantSignature != gotSignature {
+		resp, _ := makeResp("signatures do not match", http.StatusBadRequest, nil)
+		return resp, false
+	}
+	return nil, true
+}
+
+func jsonResp(body string) (*http.Response, error) {
+	return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body)))
+}
+
+func (b *backend) ordersRoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "POST" {
+		return makeResp(fmt.Sprintf(`got method %q want "POST"`, req.Method), http.StatusMethodNotAllowed, nil)
+	}
+	qv := req.URL.Query()
+	if resp, ok := checkSignature(qv); !ok {
+		return resp, nil
+	}
+
+	switch path := req.URL.Path; {
+	case strings.HasSuffix(path, "/api/v1/trade.do"):
+		for _, key := range []string{"price", "amount"} {
+			if strings.ContainsAny(qv.Get(key), "eE") || len(qv.Get(key)) > 10 {
+				return jsonResp(`{"result":false,"error_code":10008}`)
+			}
+		}
+		switch qv.Get("type") {
+		case "buy":
+			return jsonResp(`{"result":true,"order_id":1}`)
+		case "buy_market":
+			if qv.Get("amount") != "" {
+				return jsonResp(`{"result":false,"error_code":10008}`)
+			}
+			return jsonResp(`{"result":true,"order_id":2}`)
+		default:
+			return jsonResp(`{"result":false,"error_code":10010}`)
+		}
+
+	case strings.HasSuffix(path, "/api/v1/cancel_order.do"):
+		if qv.Get("order_id") != "10000591" {
+			return jsonResp(`{"result":false,"error_code":10009}`)
+		}
+		return jsonResp(`{"result":true,"order_id":"10000591"}`)
+
+	case strings.HasSuffix(path, "/api/v1/order_info.do"):
+		return respFromFile(fmt.Sprintf("./testdata/order_info-%s.json", qv.Get("symbol")))
+
+	case strings.HasSuffix(path, "/api/v1/order_history.do"):
+		if qv.Get("status") != "1" || qv.Get("current_page") != "1" {
+			return jsonResp(`{"result":true,"total":3,"currency_page":2,"page_length":200,"orders":[]}`)
+		}
+		return respFromFile(fmt.Sprintf("./testdata/order_history-%s.json", qv.Get("symbol")))
+
+	default:
+		return makeResp(fmt.Sprintf("unknown path %q", path), http.StatusNotFound, nil)
+	}
+}
+
+const (
+	ordersRoute = "/orders"
+)
@@ -0,0 +1,150 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// The v3 list endpoints return their cursors in these response
+// headers: OK-BEFORE holds the newest record ID of the page and
+// OK-AFTER holds the oldest.
+const (
+	headerBefore = "OK-BEFORE"
+	headerAfter  = "OK-AFTER"
+)
+
+// Cursor marks the boundaries of a page of results.
+type Cursor struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// CursorFromHeader extracts the pagination cursor from a response's
+// headers. It returns nil if neither cursor header was set.
+func CursorFromHeader(hdr http.Header) *Cursor {
+	if hdr == nil {
+		return nil
+	}
+	cur := &Cursor{Before: hdr.Get(headerBefore), After: hdr.Get(headerAfter)}
+	if cur.Before == "" && cur.After == "" {
+		return nil
+	}
+	return cur
+}
+
+// PageRequest holds the query parameters for fetching a single page.
+// At most one of Before and After is set.
+type PageRequest struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// PageFetcher fetches and decodes the page described by preq, returning
+// the headers of the response so that the next cursor can be read off.
+// Implementations typically wrap a Client call and append the decoded
+// records to a slice they own.
+type PageFetcher func(preq *PageRequest) (http.Header, error)
+
+// Pager walks a cursor paginated listing, such as those of
+// Client.FillsPager, Client.SpotOrdersPager and Client.LedgerPager.
+// Next moves towards older records and Prev towards newer ones.
+type Pager struct {
+	mu sync.Mutex
+
+	limit int
+	fetch PageFetcher
+
+	cursor  *Cursor
+	started bool
+}
+
+var errNilPageFetcher = errors.New("expecting a non-nil page fetcher")
+
+// ErrNoMorePages is returned by Next and Prev once the listing is
+// exhausted in that direction.
+var ErrNoMorePages = errors.New("no more pages")
+
+func NewPager(limit int, fetch PageFetcher) (*Pager, error) {
+	if fetch == nil {
+		return nil, errNilPageFetcher
+	}
+	return &Pager{limit: limit, fetch: fetch}, nil
+}
+
+// Cursor returns the cursor of the most recently fetched page.
+func (p *Pager) Cursor() *Cursor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cursor == nil {
+		return nil
+	}
+	cur := *p.cursor
+	return &cur
+}
+
+// Next fetches the page of records older than the current one. The
+// first call fetches the most recent page.
+func (p *Pager) Next() error {
+	return p.page(func(preq *PageRequest, cur *Cursor) bool {
+		if cur.After == "" {
+			return false
+		}
+		preq.After = cur.After
+		return true
+	})
+}
+
+// Prev fetches the page of records newer than the current one.
+func (p *Pager) Prev() error {
+	return p.page(func(preq *PageRequest, cur *Cursor) bool {
+		if cur.Before == "" {
+			return false
+		}
+		preq.Before = cur.Before
+		return true
+	})
+}
+
+func (p *Pager) page(setCursor func(*PageRequest, *Cursor) bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	preq := &PageRequest{Limit: p.limit}
+	if p.started {
+		if p.cursor == nil || !setCursor(preq, p.cursor) {
+			return ErrNoMorePages
+		}
+	}
+
+	hdr, err := p.fetch(preq)
+	if err != nil {
+		return err
+	}
+	p.started = true
+
+	cur := CursorFromHeader(hdr)
+	if cur == nil {
+		// An empty page carries no cursors: keep the previous
+		// ones so that the other direction can still be walked.
+		return ErrNoMorePages
+	}
+	p.cursor = cur
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// ledger mimics a v3 listing of records with IDs 1 through n, served
+// newest first.
+type ledger struct {
+	n int
+
+	got []int
+}
+
+func (l *ledger) fetch(preq *okcoin.PageRequest) (http.Header, error) {
+	limit := preq.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	var ids []int
+	switch {
+	case preq.After != "":
+		after, _ := strconv.Atoi(preq.After)
+		for id := after - 1; id >= 1 && len(ids) < limit; id-- {
+			ids = append(ids, id)
+		}
+	case preq.Before != "":
+		before, _ := strconv.Atoi(preq.Before)
+		for id := before + limit; id > before; id-- {
+			if id <= l.n {
+				ids = append(ids, id)
+			}
+		}
+	default:
+		for id := l.n; id >= 1 && len(ids) < limit; id-- {
+			ids = append(ids, id)
+		}
+	}
+	l.got = append(l.got, ids...)
+
+	hdr := make(http.Header)
+	if len(ids) > 0 {
+		hdr.Set("OK-BEFORE", strconv.Itoa(ids[0]))
+		hdr.Set("OK-AFTER", strconv.Itoa(ids[len(ids)-1]))
+	}
+	return hdr, nil
+}
+
+func TestPager(t *testing.T) {
+	t.Parallel()
+
+	if _, err := okcoin.NewPager(3, nil); err == nil {
+		t.Errorf("expecting an error for a nil fetcher")
+	}
+
+	l := &ledger{n: 8}
+	pager, err := okcoin.NewPager(3, l.fetch)
+	if err != nil {
+		t.Fatalf("new pager: %v", err)
+	}
+
+	pages := 0
+	for {
+		err := pager.Next()
+		if err == okcoin.ErrNoMorePages {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		pages++
+	}
+	if g, w := pages, 3; g != w {
+		t.Errorf("forward pages: got=%d want=%d", g, w)
+	}
+	if g, w := l.got, []int{8, 7, 6, 5, 4, 3, 2, 1}; !reflect.DeepEqual(g, w) {
+		t.Errorf("forward: got=%v want=%v", g, w)
+	}
+
+	// Walking back from the oldest page should yield newer records.
+	l.got = nil
+	if err := pager.Prev(); err != nil {
+		t.Fatalf("prev: %v", err)
+	}
+	if g, w := l.got, []int{5, 4, 3}; !reflect.DeepEqual(g, w) {
+		t.Errorf("backward: got=%v want=%v", g, w)
+	}
+	if g, w := pager.Cursor(), (&okcoin.Cursor{Before: "5", After: "3"}); !reflect.DeepEqual(g, w) {
+		t.Errorf("cursor: got=%#v want=%#v", g, w)
+	}
+}
+
+func TestCursorFromHeader(t *testing.T) {
+	tests := [...]struct {
+		hdr  http.Header
+		want *okcoin.Cursor
+	}{
+		0: {hdr: nil, want: nil},
+		1: {hdr: http.Header{}, want: nil},
+		2: {
+			hdr:  http.Header{"Ok-Before": {"10"}, "Ok-After": {"1"}},
+			want: &okcoin.Cursor{Before: "10", After: "1"},
+		},
+		3: {
+			hdr:  http.Header{"Ok-After": {"1"}},
+			want: &okcoin.Cursor{After: "1"},
+		},
+	}
+
+	for i, tt := range tests {
+		if g, w := okcoin.CursorFromHeader(tt.hdr), tt.want; !reflect.DeepEqual(g, w) {
+			t.Errorf("#%d: got=%#v want=%#v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package paper simulates trading against live market data.
+//
+// An Exchange has the order placement, cancellation and query methods of
+// okcoin.Client but fills orders locally against the current ticker or
+// order book, charging configurable fees and slippage to a simulated
+// balance. Orders that do not fill right away rest until a later call to
+// Match finds the market crossing their price.
+package paper
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/internal/numeric"
+)
+
+// Market is the source of the prices that orders are matched against.
+// Every okcoin.MarketData, such as *okcoin.Client, implements it.
+type Market interface {
+	Ticker(okcoin.Symbol) (*okcoin.TickerResponse, error)
+	Depth(*okcoin.DepthRequest) (*okcoin.Depth, error)
+}
+
+var _ Market = okcoin.MarketData(nil)
+
+type Config struct {
+	Market Market
+
+	// Balances are the initial free balances.
+	Balances map[okcoin.Currency]float64
+
+	// MakerFee is the rate charged on fills of resting orders and
+	// TakerFee that on fills of orders that cross the market when placed.
+	// Like on the exchange, fees are taken from the currency received.
+	MakerFee float64
+	TakerFee float64
+
+	// Slippage worsens the price of taker fills by a fraction of
+	// it, e.g. 0.001 for 10 basis points. Limit prices still hold.
+	Slippage float64
+
+	// UseDepth matches against the order book, consuming its
+	// liquidity level by level. Otherwise orders are matched in
+	// full against the best bid and ask of the ticker.
+	UseDepth bool
+
+	// Now defaults to time.Now and dates orders.
+	Now func() time.Time
+}
+
+// Exchange is a simulated exchange implementing okcoin.Trader.
+// It is safe for concurrent use.
+type Exchange struct {
+	market   Market
+	makerFee float64
+	takerFee float64
+	slippage float64
+	useDepth bool
+	now      func() time.Time
+
+	mu     sync.Mutex
+	free   map[okcoin.Currency]float64
+	frozen map[okcoin.Currency]float64
+	orders map[int64]*order
+	nextID int64
+}
+
+var _ okcoin.Trader = (*Exchange)(nil)
+
+var (
+	errNilMarket    = errors.New("expecting a non-nil market")
+	errNegativeRate = errors.New("expecting non-negative fees and slippage")
+	errNoPrice      = errors.New("no price to match against")
+
+	errOrderNotFound     = &okcoin.APIError{Code: 10009}
+	errInsufficientFunds = &okcoin.APIError{Code: 10010}
+	errInsufficientCoins = &okcoin.APIError{Code: 10016}
+)
+
+func New(cfg *Config) (*Exchange, error) {
+	if cfg == nil || cfg.Market == nil {
+		return nil, errNilMarket
+	}
+	if cfg.MakerFee < 0 || cfg.TakerFee < 0 || cfg.Slippage < 0 {
+		return nil, errNegativeRate
+	}
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	ex := &Exchange{
+		market:   cfg.Market,
+		makerFee: cfg.MakerFee,
+		takerFee: cfg.TakerFee,
+		slippage: cfg.Slippage,
+		useDepth: cfg.UseDepth,
+		now:      now,
+		free:     make(map[okcoin.Currency]float64),
+		frozen:   make(map[okcoin.Currency]float64),
+		orders:   make(map[int64]*order),
+	}
+	for currency, amount := range cfg.Balances {
+		ex.free[currency] = amount
+	}
+	return ex, nil
+}
+
+type order struct {
+	id      int64
+	req     okcoin.OrderRequest
+	status  okcoin.OrderStatus
+	created time.Time
+
+	// price and amount are those of req. For buy_market
+	// orders price is the amount of quote currency to spend.
+	price  float64
+	amount float64
+
+	dealAmount float64
+	dealQuote  float64
+
+	// reserved is what remains frozen for the order, in the quote
+	// currency for buys and in the base currency for sells.
+	reserved float64
+}
+
+func (o *order) buying() bool {
+	return o.req.Type == okcoin.Buy || o.req.Type == okcoin.BuyMarket
+}
+
+func (o *order) market() bool {
+	return o.req.Type == okcoin.BuyMarket || o.req.Type == okcoin.SellMarket
+}
+
+func (o *order) open() bool {
+	return o.status == okcoin.StatusUnfilled || o.status == okcoin.StatusPartiallyFilled
+}
+
+func (o *order) done() bool {
+	if o.req.Type == okcoin.BuyMarket {
+		return o.price-o.dealQuote <= numeric.Dust
+	}
+	return o.amount-o.dealAmount <= numeric.Dust
+}
+
+func (o *order) reservedCurrency() okcoin.Currency {
+	if o.buying() {
+		return o.req.Symbol.Quote()
+	}
+	return o.req.Symbol.Base()
+}
+
+func (o *order) snapshot() *okcoin.Order {
+	snap := &okcoin.Order{
+		ID:         o.id,
+		Symbol:     o.req.Symbol,
+		Type:       o.req.Type,
+		Status:     o.status,
+		Price:      o.req.Price,
+		Amount:     o.req.Amount,
+		DealAmount: okcoin.NewDecimalFromFloat(o.dealAmount),
+		CreateDate: okcoin.Timestamp{Time: o.created},
+	}
+	if o.dealAmount > 0 {
+		snap.AvgPrice = okcoin.NewDecimalFromFloat(o.dealQuote / o.dealAmount)
+	}
+	return snap
+}
+
+// levels returns the side of the market that an order
+// buying or selling sym trades against, best price first.
+func (ex *Exchange) levels(sym okcoin.Symbol, buying bool) ([]*okcoin.PriceLevel, error) {
+	if ex.useDepth {
+		depth, err := ex.market.Depth(&okcoin.DepthRequest{Symbol: sym})
+		if err != nil {
+			return nil, err
+		}
+		side := depth.Bids
+		if buying {
+			side = depth.Asks
+		}
+		// Copied since matching consumes their amounts.
+		levels := make([]*okcoin.PriceLevel, len(side))
+		for i, level := range side {
+			copied := *level
+			levels[i] = &copied
+		}
+		return levels, nil
+	}
+
+	tres, err := ex.market.Ticker(sym)
+	if err != nil {
+		return nil, err
+	}
+	if tres == nil || tres.Ticker == nil {
+		return nil, errNoPrice
+	}
+	price := tres.Ticker.Buy
+	if buying {
+		price = tres.Ticker.Sell
+	}
+	if price <= 0 {
+		price = tres.Ticker.Last
+	}
+	if price <= 0 {
+		return nil, errNoPrice
+	}
+	return []*okcoin.PriceLevel{{Price: price, Amount: math.Inf(1)}}, nil
+}
+
+// PlaceOrder fills as much of the order as the market allows right away.
+// The remainder of a limit order rests while that of a market order is
+// cancelled.
+func (ex *Exchange) PlaceOrder(oreq *okcoin.OrderRequest) (*okcoin.OrderResponse, error) {
+	if err := oreq.Validate(); err != nil {
+		return nil, err
+	}
+	o := &order{
+		req:    *oreq,
+		status: okcoin.StatusUnfilled,
+		price:  oreq.Price.Float64(),
+		amount: oreq.Amount.Float64(),
+	}
+	levels, err := ex.levels(oreq.Symbol, o.buying())
+	if err != nil {
+		return nil, err
+	}
+
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	switch o.req.Type {
+	case okcoin.Buy:
+		o.reserved = o.price * o.amount
+	case okcoin.BuyMarket:
+		o.reserved = o.price
+	default:
+		o.reserved = o.amount
+	}
+	currency := o.reservedCurrency()
+	if ex.free[currency]+numeric.Dust < o.reserved {
+		if o.buying() {
+			return nil, errInsufficientFunds
+		}
+		return nil, errInsufficientCoins
+	}
+	ex.free[currency] -= o.reserved
+	ex.frozen[currency] += o.reserved
+
+	ex.nextID++
+	o.id = ex.nextID
+	o.created = ex.now()
+	ex.orders[o.id] = o
+
+	ex.match(o, levels, true)
+	if o.open() && o.market() {
+		ex.finish(o, okcoin.StatusCancelled)
+	}
+	return &okcoin.OrderResponse{OrderID: o.id}, nil
+}
+
+// match fills o against levels, consuming their liquidity.
+// Taker fills pay the taker fee and slippage, resting
+// orders fill at their own price and pay the maker fee.
+func (ex *Exchange) match(o *order, levels []*okcoin.PriceLevel, taker bool) {
+	feeRate := ex.makerFee
+	if taker {
+		feeRate = ex.takerFee
+	}
+	for _, level := range levels {
+		if o.done() {
+			break
+		}
+		if !o.market() && (o.buying() && level.Price > o.price || !o.buying() && level.Price < o.price) {
+			break
+		}
+
+		price := o.price
+		if taker {
+			price = level.Price
+			if o.buying() {
+				price *= 1 + ex.slippage
+			} else {
+				price *= 1 - ex.slippage
+			}
+			if !o.market() && (o.buying() && price > o.price || !o.buying() && price < o.price) {
+				price = o.price
+			}
+		}
+
+		qty := o.amount - o.dealAmount
+		if o.req.Type == okcoin.BuyMarket {
+			qty = (o.price - o.dealQuote) / price
+		}
+		qty = math.Min(qty, level.Amount)
+		if qty <= numeric.Dust {
+			continue
+		}
+		level.Amount -= qty
+		ex.settle(o, qty, price, feeRate)
+	}
+
+	switch {
+	case o.done():
+		ex.finish(o, okcoin.StatusFilled)
+	case o.dealAmount > 0:
+		o.status = okcoin.StatusPartiallyFilled
+	}
+}
+
+func (ex *Exchange) settle(o *order, qty, price, feeRate float64) {
+	base, quote := o.req.Symbol.Base(), o.req.Symbol.Quote()
+	cost := qty * price
+	if o.buying() {
+		ex.frozen[quote] -= cost
+		o.reserved -= cost
+		ex.free[base] += qty * (1 - feeRate)
+	} else {
+		ex.frozen[base] -= qty
+		o.reserved -= qty
+		ex.free[quote] += cost * (1 - feeRate)
+	}
+	o.dealAmount += qty
+	o.dealQuote += cost
+}
+
+// finish closes o with status, releasing what it still had frozen.
+func (ex *Exchange) finish(o *order, status okcoin.OrderStatus) {
+	o.status = status
+	currency := o.reservedCurrency()
+	ex.frozen[currency] -= o.reserved
+	ex.free[currency] += o.reserved
+	o.reserved = 0
+	if math.Abs(ex.frozen[currency]) <= numeric.Dust {
+		ex.frozen[currency] = 0
+	}
+}
+
+// Match fills the resting orders that the market now crosses.
+func (ex *Exchange) Match() error {
+	type side struct {
+		sym    okcoin.Symbol
+		buying bool
+	}
+	ex.mu.Lock()
+	var sides []side
+	seen := make(map[side]bool)
+	for _, o := range ex.sortedOrders("") {
+		if s := (side{o.req.Symbol, o.buying()}); o.open() && !seen[s] {
+			seen[s] = true
+			sides = append(sides, s)
+		}
+	}
+	ex.mu.Unlock()
+
+	for _, s := range sides {
+		levels, err := ex.levels(s.sym, s.buying)
+		if err != nil {
+			return err
+		}
+		ex.mu.Lock()
+		// Orders may have been cancelled meanwhile, hence
+		// checking again; the oldest have priority.
+		for _, o := range ex.sortedOrders(s.sym) {
+			if o.open() && o.buying() == s.buying {
+				ex.match(o, levels, false)
+			}
+		}
+		ex.mu.Unlock()
+	}
+	return nil
+}
+
+// sortedOrders returns the orders of sym, or all of
+// them if sym is blank, from the oldest to the newest.
+func (ex *Exchange) sortedOrders(sym okcoin.Symbol) []*order {
+	var orders []*order
+	for _, o := range ex.orders {
+		if sym == "" || o.req.Symbol == sym {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].id < orders[j].id })
+	return orders
+}
+
+func (ex *Exchange) lookup(sym okcoin.Symbol, orderID int64) (*order, error) {
+	o, ok := ex.orders[orderID]
+	if !ok || o.req.Symbol != sym {
+		return nil, errOrderNotFound
+	}
+	return o, nil
+}
+
+func (ex *Exchange) CancelOrder(sym okcoin.Symbol, orderID int64) error {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	o, err := ex.lookup(sym, orderID)
+	if err != nil {
+		return err
+	}
+	if !o.open() {
+		return errOrderNotFound
+	}
+	ex.finish(o, okcoin.StatusCancelled)
+	return nil
+}
+
+func (ex *Exchange) Order(sym okcoin.Symbol, orderID int64) (*okcoin.Order, error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	o, err := ex.lookup(sym, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return o.snapshot(), nil
+}
+
+// OpenOrders returns the unfilled and partially filled orders for sym.
+func (ex *Exchange) OpenOrders(sym okcoin.Symbol) ([]*okcoin.Order, error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	var orders []*okcoin.Order
+	for _, o := range ex.sortedOrders(sym) {
+		if o.open() {
+			orders = append(orders, o.snapshot())
+		}
+	}
+	return orders, nil
+}
+
+// Orders returns every order for sym, open or not, from the oldest to the newest.
+func (ex *Exchange) Orders(sym okcoin.Symbol) ([]*okcoin.Order, error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	var orders []*okcoin.Order
+	for _, o := range ex.sortedOrders(sym) {
+		orders = append(orders, o.snapshot())
+	}
+	return orders, nil
+}
+
+// Funds returns the simulated free and frozen balances.
+func (ex *Exchange) Funds() (*okcoin.Funds, error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	return &okcoin.Funds{
+		Free:   &okcoin.Fund{Balances: copyBalances(ex.free)},
+		Frozen: &okcoin.Fund{Balances: copyBalances(ex.frozen)},
+	}, nil
+}
+
+func copyBalances(balances map[okcoin.Currency]float64) map[okcoin.Currency]float64 {
+	copied := make(map[okcoin.Currency]float64, len(balances))
+	for currency, amount := range balances {
+		copied[currency] = amount
+	}
+	return copied
+}
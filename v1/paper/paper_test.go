@@ -0,0 +1,234 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paper_test
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/paper"
+)
+
+// fakeMarket serves a settable ticker and order book for every symbol.
+type fakeMarket struct {
+	mu     sync.Mutex
+	ticker okcoin.Ticker
+	depth  okcoin.Depth
+}
+
+func (fm *fakeMarket) setAsk(price float64) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.ticker.Sell = price
+}
+
+func (fm *fakeMarket) Ticker(sym okcoin.Symbol) (*okcoin.TickerResponse, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	t := fm.ticker
+	return &okcoin.TickerResponse{Ticker: &t}, nil
+}
+
+func (fm *fakeMarket) Depth(dr *okcoin.DepthRequest) (*okcoin.Depth, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	d := fm.depth
+	return &d, nil
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestTickerMatching(t *testing.T) {
+	t.Parallel()
+
+	market := &fakeMarket{ticker: okcoin.Ticker{Buy: 4590, Sell: 4594.17, Last: 4592}}
+	ex, err := paper.New(&paper.Config{
+		Market:   market,
+		Balances: map[okcoin.Currency]float64{okcoin.USD: 10000},
+		MakerFee: 0.001,
+		TakerFee: 0.002,
+		Slippage: 0.001,
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	// Crossing the ask fills right away with slippage and the taker fee.
+	ores, err := ex.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+		Price: okcoin.MustParseDecimal("4600"), Amount: okcoin.MustParseDecimal("1"),
+	})
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	order, err := ex.Order(okcoin.BTCUSD, ores.OrderID)
+	if err != nil {
+		t.Fatalf("order: %v", err)
+	}
+	wantPrice := 4594.17 * 1.001
+	if order.Status != okcoin.StatusFilled || !approx(order.AvgPrice.Float64(), wantPrice) {
+		t.Errorf("taker buy: got status=%v avg=%v want filled at %v", order.Status, order.AvgPrice, wantPrice)
+	}
+	funds, _ := ex.Funds()
+	if g, w := funds.Free.Balance(okcoin.BTC), 0.998; !approx(g, w) {
+		t.Errorf("btc: got=%v want=%v", g, w)
+	}
+	if g, w := funds.Free.Balance(okcoin.USD), 10000-wantPrice; !approx(g, w) {
+		t.Errorf("usd: got=%v want=%v", g, w)
+	}
+
+	// Below the ask the order rests with its funds frozen.
+	ores, err = ex.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+		Price: okcoin.MustParseDecimal("4000"), Amount: okcoin.MustParseDecimal("1"),
+	})
+	if err != nil {
+		t.Fatalf("place resting: %v", err)
+	}
+	funds, _ = ex.Funds()
+	if g, w := funds.Frozen.Balance(okcoin.USD), 4000.0; !approx(g, w) {
+		t.Errorf("frozen usd: got=%v want=%v", g, w)
+	}
+	if err := ex.Match(); err != nil {
+		t.Fatalf("match: %v", err)
+	}
+	if open, _ := ex.OpenOrders(okcoin.BTCUSD); len(open) != 1 {
+		t.Fatalf("open orders: got=%d want=1", len(open))
+	}
+
+	market.setAsk(3990)
+	if err := ex.Match(); err != nil {
+		t.Fatalf("match: %v", err)
+	}
+	order, _ = ex.Order(okcoin.BTCUSD, ores.OrderID)
+	if order.Status != okcoin.StatusFilled || !approx(order.AvgPrice.Float64(), 4000) {
+		t.Errorf("resting buy: got status=%v avg=%v want filled at 4000", order.Status, order.AvgPrice)
+	}
+	funds, _ = ex.Funds()
+	if g, w := funds.Free.Balance(okcoin.BTC), 0.998+0.999; !approx(g, w) {
+		t.Errorf("btc after maker fill: got=%v want=%v", g, w)
+	}
+	if g := funds.Frozen.Balance(okcoin.USD); g != 0 {
+		t.Errorf("frozen usd after fill: got=%v want=0", g)
+	}
+
+	// Unaffordable orders are rejected like the exchange does.
+	_, err = ex.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Buy,
+		Price: okcoin.MustParseDecimal("4000"), Amount: okcoin.MustParseDecimal("100"),
+	})
+	if ae, ok := err.(*okcoin.APIError); !ok || ae.Code != 10010 {
+		t.Errorf("insufficient funds: got=%v want error_code 10010", err)
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	t.Parallel()
+
+	market := &fakeMarket{ticker: okcoin.Ticker{Buy: 4590, Sell: 4594.17}}
+	ex, err := paper.New(&paper.Config{
+		Market:   market,
+		Balances: map[okcoin.Currency]float64{okcoin.BTC: 1},
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	ores, err := ex.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.Sell,
+		Price: okcoin.MustParseDecimal("9000"), Amount: okcoin.MustParseDecimal("0.5"),
+	})
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if err := ex.CancelOrder(okcoin.BTCUSD, ores.OrderID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := ex.CancelOrder(okcoin.BTCUSD, ores.OrderID); err == nil {
+		t.Errorf("cancelling twice: want non-nil error")
+	}
+	if _, err := ex.Order(okcoin.LTCUSD, ores.OrderID); err == nil {
+		t.Errorf("order of another symbol: want non-nil error")
+	}
+
+	order, _ := ex.Order(okcoin.BTCUSD, ores.OrderID)
+	if order.Status != okcoin.StatusCancelled {
+		t.Errorf("status: got=%v want=%v", order.Status, okcoin.StatusCancelled)
+	}
+	funds, _ := ex.Funds()
+	if g, w := funds.Free.Balance(okcoin.BTC), 1.0; g != w {
+		t.Errorf("btc: got=%v want=%v", g, w)
+	}
+}
+
+func TestDepthMatching(t *testing.T) {
+	t.Parallel()
+
+	market := &fakeMarket{depth: okcoin.Depth{
+		Bids: []*okcoin.PriceLevel{{Price: 4592.1, Amount: 0.4}, {Price: 4590, Amount: 1.5}},
+		Asks: []*okcoin.PriceLevel{{Price: 4594.17, Amount: 0.25}},
+	}}
+	ex, err := paper.New(&paper.Config{
+		Market:   market,
+		Balances: map[okcoin.Currency]float64{okcoin.BTC: 3},
+		UseDepth: true,
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	ores, err := ex.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.SellMarket, Amount: okcoin.MustParseDecimal("1"),
+	})
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	order, _ := ex.Order(okcoin.BTCUSD, ores.OrderID)
+	wantProceeds := 0.4*4592.1 + 0.6*4590
+	if order.Status != okcoin.StatusFilled || !approx(order.AvgPrice.Float64(), wantProceeds) {
+		t.Errorf("walked the book: got status=%v avg=%v want filled at %v", order.Status, order.AvgPrice, wantProceeds)
+	}
+
+	// The rest of a market order beyond the book's liquidity is cancelled.
+	ores, err = ex.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.BTCUSD, Type: okcoin.SellMarket, Amount: okcoin.MustParseDecimal("2"),
+	})
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	order, _ = ex.Order(okcoin.BTCUSD, ores.OrderID)
+	if order.Status != okcoin.StatusCancelled || !approx(order.DealAmount.Float64(), 1.9) {
+		t.Errorf("exhausted book: got status=%v deal=%v want cancelled after 1.9", order.Status, order.DealAmount)
+	}
+	funds, _ := ex.Funds()
+	if g, w := funds.Free.Balance(okcoin.BTC), 3-1-1.9; !approx(g, w) {
+		t.Errorf("btc: got=%v want=%v", g, w)
+	}
+	if g := funds.Frozen.Balance(okcoin.BTC); g != 0 {
+		t.Errorf("frozen btc: got=%v want=0", g)
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	t.Parallel()
+
+	if _, err := paper.New(nil); err == nil {
+		t.Errorf("nil config: want non-nil error")
+	}
+	if _, err := paper.New(&paper.Config{Market: new(fakeMarket), Slippage: -1}); err == nil {
+		t.Errorf("negative slippage: want non-nil error")
+	}
+}
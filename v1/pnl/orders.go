@@ -0,0 +1,130 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pnl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// FillFromOrder converts the filled part of an order into a fill at its
+// average price, or returns nil if nothing was filled. The exchange does
+// not report fees on orders, so they are charged at feeRate the way it
+// does: in the base currency on buys and in the quote on sells.
+//
+// The fill is dated at the order's creation since
+// the times of its executions are not reported.
+func FillFromOrder(order *okcoin.Order, feeRate okcoin.Decimal) *Fill {
+	if order == nil || order.DealAmount.Sign() <= 0 || order.AvgPrice.Sign() <= 0 {
+		return nil
+	}
+	f := &Fill{
+		ID:     strconv.FormatInt(order.ID, 10),
+		Symbol: order.Symbol,
+		Price:  order.AvgPrice,
+		Amount: order.DealAmount,
+		Time:   order.CreateDate.Time,
+	}
+	switch order.Type {
+	case okcoin.Buy, okcoin.BuyMarket:
+		f.Side = Buy
+		f.Fee = feeRate.Mul(f.Amount)
+		f.FeeCurrency = order.Symbol.Base()
+	case okcoin.Sell, okcoin.SellMarket:
+		f.Side = Sell
+		f.Fee = feeRate.Mul(f.Amount).Mul(f.Price)
+		f.FeeCurrency = order.Symbol.Quote()
+	default:
+		return nil
+	}
+	return f
+}
+
+// FillsFromOrders converts the filled parts of orders, skipping unfilled ones.
+func FillsFromOrders(orders []*okcoin.Order, feeRate okcoin.Decimal) []*Fill {
+	var fills []*Fill
+	for _, order := range orders {
+		if f := FillFromOrder(order, feeRate); f != nil {
+			fills = append(fills, f)
+		}
+	}
+	return fills
+}
+
+// FillFromSpot converts a fill listed by the spot v3 API, which unlike
+// orders reports the fee actually charged and its currency. The API
+// gives charges as negative fees, so the fee's sign is dropped.
+func FillFromSpot(sf *okcoin.Fill) (*Fill, error) {
+	if sf == nil {
+		return nil, errNilFill
+	}
+	base, quote, err := okcoin.ParseSymbol(sf.InstrumentID)
+	if err != nil {
+		return nil, err
+	}
+	f := &Fill{
+		ID:          sf.TradeID,
+		Symbol:      okcoin.NewSymbol(base, quote),
+		Side:        Side(strings.ToLower(sf.Side)),
+		Price:       sf.Price,
+		Amount:      sf.Size,
+		Fee:         sf.Fee.Abs(),
+		FeeCurrency: okcoin.Currency(strings.ToLower(sf.Currency)),
+		Time:        sf.Timestamp,
+	}
+	if f.ID == "" {
+		f.ID = sf.LedgerID
+	}
+	switch f.Side {
+	case Buy, Sell:
+	default:
+		return nil, fmt.Errorf("fill %q: unknown side %q", f.ID, sf.Side)
+	}
+	return f, nil
+}
+
+// FillsFromSpot converts fills listed by the spot v3 API.
+func FillsFromSpot(sfs []*okcoin.Fill) ([]*Fill, error) {
+	fills := make([]*Fill, 0, len(sfs))
+	for _, sf := range sfs {
+		f, err := FillFromSpot(sf)
+		if err != nil {
+			return nil, err
+		}
+		fills = append(fills, f)
+	}
+	return fills, nil
+}
+
+// FetchFills pages through the filled order history of sym.
+//
+// trade_history.do is not used because it returns the
+// trades of every account, not just those of the caller.
+func FetchFills(c *okcoin.Client, sym okcoin.Symbol, feeRate okcoin.Decimal) ([]*Fill, error) {
+	var fills []*Fill
+	for page := 1; ; page++ {
+		ohres, err := c.OrderHistory(&okcoin.OrderHistoryRequest{Symbol: sym, Filled: true, Page: page})
+		if err != nil {
+			return nil, err
+		}
+		fills = append(fills, FillsFromOrders(ohres.Orders, feeRate)...)
+		if len(ohres.Orders) == 0 || len(ohres.Orders) < ohres.PageLength {
+			return fills, nil
+		}
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pnl keeps tax lots of an account's fills and reports
+// their realized and unrealized profit and loss.
+//
+// Lots are kept per symbol: buying btc_usd opens a lot of BTC whose cost
+// is in USD. Sells close lots in first-in first-out, last-in first-out
+// or average-cost order. All amounts of money are in the quote currency,
+// with buying fees folded into the cost of lots and selling fees
+// deducted from proceeds. Amounts are decimals so that lots close
+// exactly rather than leaving floating point dust behind.
+package pnl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+type Method int
+
+const (
+	// FIFO closes the oldest lots first.
+	FIFO Method = iota
+	// LIFO closes the newest lots first.
+	LIFO
+	// AverageCost pools all the lots of a symbol into
+	// one whose unit cost is their weighted average.
+	AverageCost
+)
+
+func (m Method) String() string {
+	switch m {
+	case FIFO:
+		return "FIFO"
+	case LIFO:
+		return "LIFO"
+	case AverageCost:
+		return "AverageCost"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+// Fill is an executed trade of the account.
+type Fill struct {
+	ID     string         `json:"id,omitempty"`
+	Symbol okcoin.Symbol  `json:"symbol"`
+	Side   Side           `json:"side"`
+	Price  okcoin.Decimal `json:"price"`
+	Amount okcoin.Decimal `json:"amount"`
+
+	// Fee is charged in FeeCurrency, which must be either the base
+	// or the quote currency of Symbol. A blank FeeCurrency is the quote.
+	Fee         okcoin.Decimal  `json:"fee"`
+	FeeCurrency okcoin.Currency `json:"fee_currency,omitempty"`
+
+	Time time.Time `json:"time"`
+}
+
+// Lot is an open holding of the base currency of Symbol.
+type Lot struct {
+	Symbol okcoin.Symbol  `json:"symbol"`
+	Amount okcoin.Decimal `json:"amount"`
+	// Cost includes the fees paid to acquire the lot.
+	Cost   okcoin.Decimal `json:"cost"`
+	Opened time.Time      `json:"opened"`
+}
+
+// UnitCost returns the cost of the lot per unit of the base currency.
+func (lot *Lot) UnitCost() okcoin.Decimal {
+	return lot.Cost.Div(lot.Amount, DivisionPlaces)
+}
+
+// Realization records the lots, or parts of lots, closed by a sell.
+type Realization struct {
+	FillID string         `json:"fill_id,omitempty"`
+	Symbol okcoin.Symbol  `json:"symbol"`
+	Amount okcoin.Decimal `json:"amount"`
+
+	// Proceeds are net of the selling fee.
+	Proceeds  okcoin.Decimal `json:"proceeds"`
+	CostBasis okcoin.Decimal `json:"cost_basis"`
+	PnL       okcoin.Decimal `json:"pnl"`
+
+	Opened time.Time `json:"opened"`
+	Closed time.Time `json:"closed"`
+}
+
+// DivisionPlaces is the number of digits after the point kept when
+// a cost or proceeds is split between lots and when unit costs are
+// reported. Splits hand the remainder to the last part, so the parts
+// always add up to the whole.
+const DivisionPlaces = 18
+
+// Book applies fills to the lots of each symbol.
+// It is not safe for concurrent use.
+type Book struct {
+	method       Method
+	lots         map[okcoin.Symbol][]*Lot
+	realizations []*Realization
+	fees         okcoin.Decimal
+}
+
+var (
+	errNilFill          = errors.New("expecting a non-nil fill")
+	errNonPositivePrice = errors.New("expecting a positive price")
+	errNonPositiveAmt   = errors.New("expecting a positive amount")
+	errNegativeFee      = errors.New("expecting a non-negative fee")
+)
+
+func NewBook(method Method) (*Book, error) {
+	switch method {
+	case FIFO, LIFO, AverageCost:
+	default:
+		return nil, fmt.Errorf("unknown method %v", method)
+	}
+	return &Book{method: method, lots: make(map[okcoin.Symbol][]*Lot)}, nil
+}
+
+// Build returns a book with fills applied in chronological order.
+func Build(method Method, fills []*Fill) (*Book, error) {
+	b, err := NewBook(method)
+	if err != nil {
+		return nil, err
+	}
+	sorted := append([]*Fill(nil), fills...)
+	for _, f := range sorted {
+		if f == nil {
+			return nil, errNilFill
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	for _, f := range sorted {
+		if err := b.Add(f); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// fees returns the fill's fee valued in the quote currency and,
+// if it was charged in the base currency, its amount in the base.
+func (f *Fill) fees() (quoteFee, baseFee okcoin.Decimal, err error) {
+	switch f.FeeCurrency {
+	case "", f.Symbol.Quote():
+		return f.Fee, okcoin.Decimal{}, nil
+	case f.Symbol.Base():
+		return f.Fee.Mul(f.Price), f.Fee, nil
+	default:
+		return okcoin.Decimal{}, okcoin.Decimal{}, fmt.Errorf("fee currency %q is neither the base nor the quote of %q", f.FeeCurrency, f.Symbol)
+	}
+}
+
+// Add applies a fill. A sell that exceeds the amount held is
+// rejected without changing the book.
+func (b *Book) Add(f *Fill) error {
+	switch {
+	case f == nil:
+		return errNilFill
+	case f.Symbol == "":
+		return errors.New("expecting a non-blank symbol")
+	case f.Price.Sign() <= 0:
+		return errNonPositivePrice
+	case f.Amount.Sign() <= 0:
+		return errNonPositiveAmt
+	case f.Fee.Sign() < 0:
+		return errNegativeFee
+	}
+	quoteFee, baseFee, err := f.fees()
+	if err != nil {
+		return err
+	}
+
+	notional := f.Price.Mul(f.Amount)
+	switch f.Side {
+	case Buy:
+		// A fee in the base currency shrinks the amount received.
+		amount := f.Amount.Sub(baseFee)
+		if amount.Sign() <= 0 {
+			return fmt.Errorf("the fee of fill %q consumes its whole amount", f.ID)
+		}
+		cost := notional
+		if baseFee.IsZero() {
+			cost = cost.Add(quoteFee)
+		}
+		b.open(&Lot{Symbol: f.Symbol, Amount: amount, Cost: cost, Opened: f.Time})
+	case Sell:
+		// A fee in the base currency is paid out of the lots
+		// on top of the amount sold and brings in nothing.
+		proceeds, consumed := notional.Sub(quoteFee), f.Amount
+		if !baseFee.IsZero() {
+			proceeds, consumed = notional, f.Amount.Add(baseFee)
+		}
+		if held := b.held(f.Symbol); consumed.Cmp(held) > 0 {
+			return fmt.Errorf("selling %v of %q exceeds the %v held", consumed, f.Symbol, held)
+		}
+		b.close(f, consumed, proceeds)
+	default:
+		return fmt.Errorf("unknown side %q", f.Side)
+	}
+	b.fees = b.fees.Add(quoteFee)
+	return nil
+}
+
+func (b *Book) held(sym okcoin.Symbol) okcoin.Decimal {
+	var amount okcoin.Decimal
+	for _, lot := range b.lots[sym] {
+		amount = amount.Add(lot.Amount)
+	}
+	return amount
+}
+
+func (b *Book) open(lot *Lot) {
+	lots := b.lots[lot.Symbol]
+	if b.method != AverageCost || len(lots) == 0 {
+		b.lots[lot.Symbol] = append(lots, lot)
+		return
+	}
+	pool := lots[0]
+	pool.Amount = pool.Amount.Add(lot.Amount)
+	pool.Cost = pool.Cost.Add(lot.Cost)
+}
+
+// share returns the part of total that amount is of whole.
+func share(total, amount, whole okcoin.Decimal) okcoin.Decimal {
+	if amount.Equal(whole) {
+		return total
+	}
+	return total.Mul(amount).Div(whole, DivisionPlaces)
+}
+
+// close takes consumed from the lots of the fill's symbol,
+// splitting proceeds between them by the amount taken from each.
+func (b *Book) close(f *Fill, consumed, proceeds okcoin.Decimal) {
+	lots := b.lots[f.Symbol]
+	remaining := consumed
+	for remaining.Sign() > 0 && len(lots) > 0 {
+		i := 0
+		if b.method == LIFO {
+			i = len(lots) - 1
+		}
+		lot := lots[i]
+		amount := lot.Amount
+		if amount.Cmp(remaining) > 0 {
+			amount = remaining
+		}
+		r := &Realization{
+			FillID:    f.ID,
+			Symbol:    f.Symbol,
+			Amount:    amount,
+			Proceeds:  share(proceeds, amount, remaining),
+			CostBasis: share(lot.Cost, amount, lot.Amount),
+			Opened:    lot.Opened,
+			Closed:    f.Time,
+		}
+		r.PnL = r.Proceeds.Sub(r.CostBasis)
+		b.realizations = append(b.realizations, r)
+
+		proceeds = proceeds.Sub(r.Proceeds)
+		remaining = remaining.Sub(amount)
+		lot.Amount = lot.Amount.Sub(amount)
+		lot.Cost = lot.Cost.Sub(r.CostBasis)
+		if lot.Amount.Sign() <= 0 {
+			lots = append(lots[:i], lots[i+1:]...)
+		}
+	}
+	if len(lots) == 0 {
+		delete(b.lots, f.Symbol)
+	} else {
+		b.lots[f.Symbol] = lots
+	}
+}
+
+// Lots returns copies of the open lots of sym in the order they are held.
+func (b *Book) Lots(sym okcoin.Symbol) []*Lot {
+	var lots []*Lot
+	for _, lot := range b.lots[sym] {
+		copied := *lot
+		lots = append(lots, &copied)
+	}
+	return lots
+}
+
+// Realizations returns the closings of lots in the order they happened.
+func (b *Book) Realizations() []*Realization {
+	return append([]*Realization(nil), b.realizations...)
+}
+
+// Position summarizes the open lots of a symbol.
+type Position struct {
+	Symbol    okcoin.Symbol  `json:"symbol"`
+	Amount    okcoin.Decimal `json:"amount"`
+	CostBasis okcoin.Decimal `json:"cost_basis"`
+	UnitCost  okcoin.Decimal `json:"unit_cost"`
+
+	// MarkPrice, MarketValue and Unrealized are 0 if no price was given.
+	MarkPrice   okcoin.Decimal `json:"mark_price"`
+	MarketValue okcoin.Decimal `json:"market_value"`
+	Unrealized  okcoin.Decimal `json:"unrealized"`
+}
+
+type Report struct {
+	Method Method `json:"method"`
+
+	// Positions are sorted by symbol.
+	Positions    []*Position    `json:"positions"`
+	Realizations []*Realization `json:"realizations"`
+
+	Realized   okcoin.Decimal `json:"realized"`
+	Unrealized okcoin.Decimal `json:"unrealized"`
+	// Fees is the total of the fees paid, in quote currencies.
+	Fees okcoin.Decimal `json:"fees"`
+
+	// Unpriced lists the open positions without a mark price.
+	Unpriced []okcoin.Symbol `json:"unpriced,omitempty"`
+}
+
+// Report marks the open positions to prices, keyed by symbol, which
+// are typically the last prices of tickers.
+func (b *Book) Report(prices map[okcoin.Symbol]okcoin.Decimal) *Report {
+	rep := &Report{Method: b.method, Realizations: b.Realizations(), Fees: b.fees}
+	for _, r := range b.realizations {
+		rep.Realized = rep.Realized.Add(r.PnL)
+	}
+
+	var symbols []okcoin.Symbol
+	for sym := range b.lots {
+		symbols = append(symbols, sym)
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i] < symbols[j] })
+
+	for _, sym := range symbols {
+		pos := &Position{Symbol: sym}
+		for _, lot := range b.lots[sym] {
+			pos.Amount = pos.Amount.Add(lot.Amount)
+			pos.CostBasis = pos.CostBasis.Add(lot.Cost)
+		}
+		pos.UnitCost = pos.CostBasis.Div(pos.Amount, DivisionPlaces)
+		if price, ok := prices[sym]; ok && price.Sign() > 0 {
+			pos.MarkPrice = price
+			pos.MarketValue = price.Mul(pos.Amount)
+			pos.Unrealized = pos.MarketValue.Sub(pos.CostBasis)
+			rep.Unrealized = rep.Unrealized.Add(pos.Unrealized)
+		} else {
+			rep.Unpriced = append(rep.Unpriced, sym)
+		}
+		rep.Positions = append(rep.Positions, pos)
+	}
+	return rep
+}
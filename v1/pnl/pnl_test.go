@@ -0,0 +1,310 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pnl_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/pnl"
+)
+
+func dec(s string) okcoin.Decimal { return okcoin.MustParseDecimal(s) }
+
+var t0 = time.Date(2017, 8, 28, 0, 0, 0, 0, time.UTC)
+
+func fill(side pnl.Side, price, amount string, hour int) *pnl.Fill {
+	return &pnl.Fill{
+		Symbol: okcoin.BTCUSD,
+		Side:   side,
+		Price:  dec(price),
+		Amount: dec(amount),
+		Time:   t0.Add(time.Duration(hour) * time.Hour),
+	}
+}
+
+func TestMethods(t *testing.T) {
+	t.Parallel()
+
+	// Given out of order to exercise Build's sorting.
+	fills := []*pnl.Fill{
+		fill(pnl.Sell, "6000", "1.5", 2),
+		fill(pnl.Buy, "4000", "1", 0),
+		fill(pnl.Buy, "5000", "1", 1),
+	}
+	marks := map[okcoin.Symbol]okcoin.Decimal{okcoin.BTCUSD: dec("5500")}
+
+	tests := []struct {
+		method         pnl.Method
+		wantRealized   string
+		wantUnitCost   string
+		wantUnrealized string
+		wantLegs       int
+	}{
+		{pnl.FIFO, "2500", "5000", "250", 2},        // 9000 - (4000 + 0.5*5000)
+		{pnl.LIFO, "2000", "4000", "750", 2},        // 9000 - (5000 + 0.5*4000)
+		{pnl.AverageCost, "2250", "4500", "500", 1}, // 9000 - 1.5*4500
+	}
+
+	for _, tt := range tests {
+		book, err := pnl.Build(tt.method, fills)
+		if err != nil {
+			t.Errorf("%v: build: %v", tt.method, err)
+			continue
+		}
+		rep := book.Report(marks)
+		if g, w := rep.Realized, dec(tt.wantRealized); !g.Equal(w) {
+			t.Errorf("%v: realized: got=%v want=%v", tt.method, g, w)
+		}
+		if g, w := rep.Unrealized, dec(tt.wantUnrealized); !g.Equal(w) {
+			t.Errorf("%v: unrealized: got=%v want=%v", tt.method, g, w)
+		}
+		if g, w := len(rep.Realizations), tt.wantLegs; g != w {
+			t.Errorf("%v: realizations: got=%d want=%d", tt.method, g, w)
+		}
+		if len(rep.Positions) != 1 {
+			t.Errorf("%v: positions: got=%d want=1", tt.method, len(rep.Positions))
+			continue
+		}
+		pos := rep.Positions[0]
+		if !pos.Amount.Equal(dec("0.5")) || !pos.UnitCost.Equal(dec(tt.wantUnitCost)) {
+			t.Errorf("%v: position: got=%+v", tt.method, pos)
+		}
+	}
+}
+
+func TestLotsCloseExactly(t *testing.T) {
+	t.Parallel()
+
+	// In float64 0.1+0.2 exceeds 0.3, which would leave a sliver of a lot open.
+	for _, method := range []pnl.Method{pnl.FIFO, pnl.LIFO, pnl.AverageCost} {
+		book, err := pnl.Build(method, []*pnl.Fill{
+			fill(pnl.Buy, "4000", "0.1", 0),
+			fill(pnl.Buy, "4100", "0.2", 1),
+			fill(pnl.Sell, "4200", "0.3", 2),
+		})
+		if err != nil {
+			t.Errorf("%v: build: %v", method, err)
+			continue
+		}
+		if lots := book.Lots(okcoin.BTCUSD); len(lots) != 0 {
+			t.Errorf("%v: lots left open: %+v", method, lots[0])
+		}
+		// 1260 - (400 + 820)
+		if g, w := book.Report(nil).Realized, dec("40"); !g.Equal(w) {
+			t.Errorf("%v: realized: got=%v want=%v", method, g, w)
+		}
+	}
+
+	// Closing thirds of a lot whose cost does not divide by three still
+	// realizes its whole cost, with the last third taking the remainder.
+	third := fill(pnl.Buy, "100", "3", 0)
+	third.Fee = dec("1")
+	book, err := pnl.Build(pnl.FIFO, []*pnl.Fill{third, fill(pnl.Buy, "200", "1", 0)})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	for hour := 1; hour <= 3; hour++ {
+		if err := book.Add(fill(pnl.Sell, "100", "1", hour)); err != nil {
+			t.Fatalf("sell #%d: %v", hour, err)
+		}
+	}
+	if g, w := book.Report(nil).Realized, dec("-1"); !g.Equal(w) {
+		t.Errorf("thirds: realized: got=%v want=%v", g, w)
+	}
+	if lots := book.Lots(okcoin.BTCUSD); len(lots) != 1 || !lots[0].Cost.Equal(dec("200")) {
+		t.Errorf("thirds: lots: got=%+v", lots)
+	}
+}
+
+func TestFees(t *testing.T) {
+	t.Parallel()
+
+	book, err := pnl.NewBook(pnl.FIFO)
+	if err != nil {
+		t.Fatalf("new book: %v", err)
+	}
+
+	buy := fill(pnl.Buy, "100", "1", 0)
+	buy.Fee = dec("1")
+	if err := book.Add(buy); err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+	// A fee in the base currency shrinks the lot instead.
+	baseFeeBuy := fill(pnl.Buy, "100", "1", 1)
+	baseFeeBuy.Fee, baseFeeBuy.FeeCurrency = dec("0.2"), okcoin.BTC
+	if err := book.Add(baseFeeBuy); err != nil {
+		t.Fatalf("base fee buy: %v", err)
+	}
+	lots := book.Lots(okcoin.BTCUSD)
+	if len(lots) != 2 || !lots[0].UnitCost().Equal(dec("101")) || !lots[1].Amount.Equal(dec("0.8")) || !lots[1].UnitCost().Equal(dec("125")) {
+		t.Fatalf("lots: got=%+v", lots)
+	}
+
+	// Selling 1 with a fee of 0.2 in the base takes 1.2 out of the lots.
+	sell := fill(pnl.Sell, "110", "1", 2)
+	sell.Fee, sell.FeeCurrency = dec("0.2"), okcoin.BTC
+	if err := book.Add(sell); err != nil {
+		t.Fatalf("sell: %v", err)
+	}
+	lots = book.Lots(okcoin.BTCUSD)
+	if len(lots) != 1 || !lots[0].Amount.Equal(dec("0.6")) || !lots[0].Cost.Equal(dec("75")) {
+		t.Fatalf("lots after sell: got=%+v", lots)
+	}
+
+	rep := book.Report(nil)
+	// 110 - (101 + 0.2*125)
+	if g, w := rep.Realized, dec("-16"); !g.Equal(w) {
+		t.Errorf("realized: got=%v want=%v", g, w)
+	}
+	// 1 + 0.2*100 + 0.2*110
+	if g, w := rep.Fees, dec("43"); !g.Equal(w) {
+		t.Errorf("fees: got=%v want=%v", g, w)
+	}
+	if g, w := rep.Unpriced, []okcoin.Symbol{okcoin.BTCUSD}; len(g) != 1 || g[0] != w[0] {
+		t.Errorf("unpriced: got=%v want=%v", g, w)
+	}
+
+	// The base fee counts towards what a sell needs held.
+	oversell := fill(pnl.Sell, "110", "0.5", 3)
+	oversell.Fee, oversell.FeeCurrency = dec("0.2"), okcoin.BTC
+	if err := book.Add(oversell); err == nil {
+		t.Error("oversell: want non-nil error")
+	}
+}
+
+func TestAddRejects(t *testing.T) {
+	t.Parallel()
+
+	book, err := pnl.NewBook(pnl.LIFO)
+	if err != nil {
+		t.Fatalf("new book: %v", err)
+	}
+	if err := book.Add(fill(pnl.Buy, "100", "1", 0)); err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+
+	badFee := fill(pnl.Buy, "100", "1", 0)
+	badFee.FeeCurrency = okcoin.ETH
+	tests := []*pnl.Fill{
+		nil,
+		fill(pnl.Sell, "100", "1.00000001", 1),
+		fill(pnl.Buy, "0", "1", 1),
+		fill(pnl.Buy, "100", "-1", 1),
+		fill("short", "100", "1", 1),
+		badFee,
+	}
+	for i, f := range tests {
+		if err := book.Add(f); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+	}
+	// The rejected oversell left the lot untouched.
+	if lots := book.Lots(okcoin.BTCUSD); len(lots) != 1 || !lots[0].Amount.Equal(dec("1")) {
+		t.Errorf("lots: got=%v", lots)
+	}
+	if _, err := pnl.NewBook(pnl.Method(42)); err == nil {
+		t.Error("unknown method: want non-nil error")
+	}
+}
+
+func TestFillFromSpot(t *testing.T) {
+	t.Parallel()
+
+	f, err := pnl.FillFromSpot(&okcoin.Fill{
+		LedgerID:     "1",
+		TradeID:      "42",
+		InstrumentID: "BTC-USD",
+		Side:         "sell",
+		Price:        dec("4592.01"),
+		Size:         dec("0.1"),
+		Fee:          dec("-0.45920100"),
+		Currency:     "USD",
+		Timestamp:    t0,
+	})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if f.ID != "42" || f.Symbol != okcoin.BTCUSD || f.Side != pnl.Sell || !f.Time.Equal(t0) {
+		t.Errorf("fill: got=%+v", f)
+	}
+	if !f.Price.Equal(dec("4592.01")) || !f.Amount.Equal(dec("0.1")) {
+		t.Errorf("price and amount: got=%v, %v", f.Price, f.Amount)
+	}
+	if !f.Fee.Equal(dec("0.459201")) || f.FeeCurrency != okcoin.USD {
+		t.Errorf("fee: got=%v %q", f.Fee, f.FeeCurrency)
+	}
+
+	bad := []*okcoin.Fill{
+		nil,
+		{InstrumentID: "BTCUSD", Side: "buy"},
+		{InstrumentID: "BTC-USD", Side: "short"},
+	}
+	for i, sf := range bad {
+		if _, err := pnl.FillFromSpot(sf); err == nil {
+			t.Errorf("#%d: want non-nil error", i)
+		}
+	}
+}
+
+type orderHistoryBackend struct{}
+
+func (orderHistoryBackend) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := ioutil.NopCloser(strings.NewReader(`{"result":true,"total":3,"currency_page":2,"page_length":200,"orders":[]}`))
+	if req.URL.Query().Get("current_page") == "1" {
+		f, err := os.Open("../testdata/order_history-btc_usd.json")
+		if err != nil {
+			return nil, err
+		}
+		body = f
+	}
+	return &http.Response{Status: "200 OK", StatusCode: http.StatusOK, Body: body, Header: make(http.Header)}, nil
+}
+
+func TestFetchFills(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: "key", Secret: "secret"})
+	client.SetHTTPRoundTripper(orderHistoryBackend{})
+
+	fills, err := pnl.FetchFills(client, okcoin.BTCUSD, dec("0.002"))
+	if err != nil {
+		t.Fatalf("fetch fills: %v", err)
+	}
+	if g, w := len(fills), 3; g != w {
+		t.Fatalf("fills: got=%d want=%d", g, w)
+	}
+	buy, sell := fills[0], fills[2]
+	if buy.Side != pnl.Buy || buy.FeeCurrency != okcoin.BTC || !buy.Fee.Equal(dec("0.002")) {
+		t.Errorf("buy: got=%+v", buy)
+	}
+	// The cancelled sell counts for its filled part only.
+	if sell.Side != pnl.Sell || !sell.Amount.Equal(dec("1.5")) || sell.FeeCurrency != okcoin.USD || !sell.Fee.Equal(dec("18")) {
+		t.Errorf("sell: got=%+v", sell)
+	}
+
+	if _, err := pnl.Build(pnl.FIFO, fills); err != nil {
+		t.Errorf("build: %v", err)
+	}
+}
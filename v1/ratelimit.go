@@ -0,0 +1,71 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter paces the requests made by a Client.
+type RateLimiter interface {
+	// Wait blocks until a request may be made or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// NewRateLimiter returns a RateLimiter that spaces requests at least
+// interval apart. For example OKCoin's public limit of 6000 requests
+// per 10 minutes corresponds to an interval of 100ms.
+func NewRateLimiter(interval time.Duration) RateLimiter {
+	return &intervalLimiter{interval: interval}
+}
+
+type intervalLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+var _ RateLimiter = (*intervalLimiter)(nil)
+
+func (il *intervalLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	il.mu.Lock()
+	now := time.Now()
+	slot := il.next
+	if slot.Before(now) {
+		slot = now
+	}
+	il.next = slot.Add(il.interval)
+	il.mu.Unlock()
+
+	wait := slot.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
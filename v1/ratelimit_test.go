@@ -0,0 +1,46 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	interval := 20 * time.Millisecond
+	rl := okcoin.NewRateLimiter(interval)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := rl.Wait(context.Background()); err != nil {
+			t.Fatalf("#%d: wait: %v", i, err)
+		}
+	}
+	if g, w := time.Since(start), 3*interval; g < w {
+		t.Errorf("4 waits took %v, want at least %v", g, w)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := rl.Wait(ctx); err == nil {
+		t.Errorf("expected an error from a cancelled context")
+	}
+}
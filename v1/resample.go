@@ -0,0 +1,86 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var errNilCandleStickResponse = errors.New("expecting a non-nil candle stick response")
+
+// Resample converts cres into candle sticks of the coarser period to,
+// which must be a multiple of cres.Period. Buckets are aligned to UTC:
+// days start at midnight and weeks on Monday. Buckets at either end
+// that the input only partially covers are still returned.
+func Resample(cres *CandleStickResponse, to Period) (*CandleStickResponse, error) {
+	if cres == nil {
+		return nil, errNilCandleStickResponse
+	}
+	fromDur, toDur := cres.Period.Duration(), to.Duration()
+	switch {
+	case fromDur <= 0:
+		return nil, fmt.Errorf("unknown source period %q", cres.Period)
+	case toDur <= 0:
+		return nil, fmt.Errorf("unknown target period %q", to)
+	case toDur < fromDur:
+		return nil, fmt.Errorf("cannot resample %q to the finer %q", cres.Period, to)
+	case toDur%fromDur != 0:
+		return nil, fmt.Errorf("%q is not a multiple of %q", to, cres.Period)
+	}
+
+	sorted := make([]*CandleStick, 0, len(cres.CandleSticks))
+	for _, cs := range cres.CandleSticks {
+		if cs != nil {
+			sorted = append(sorted, cs)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TimeStampMs < sorted[j].TimeStampMs
+	})
+
+	var resampled []*CandleStick
+	var cur *CandleStick
+	for _, cs := range sorted {
+		// Go's zero time is midnight UTC on a Monday so
+		// truncating aligns days and weeks as intended.
+		bucket := TimeToEpochMs(cs.Time().Truncate(toDur))
+		if cur == nil || cur.TimeStampMs != bucket {
+			cur = &CandleStick{
+				TimeStampMs: bucket,
+				Open:        cs.Open,
+				High:        cs.High,
+				Low:         cs.Low,
+			}
+			resampled = append(resampled, cur)
+		}
+		if cs.High > cur.High {
+			cur.High = cs.High
+		}
+		if cs.Low < cur.Low {
+			cur.Low = cs.Low
+		}
+		cur.Close = cs.Close
+		cur.Volume += cs.Volume
+	}
+
+	return &CandleStickResponse{
+		Symbol:       cres.Symbol,
+		Since:        cres.Since,
+		Period:       to,
+		CandleSticks: resampled,
+	}, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestPeriodDuration(t *testing.T) {
+	tests := [...]struct {
+		period okcoin.Period
+		want   time.Duration
+	}{
+		0: {period: okcoin.P1Min, want: time.Minute},
+		1: {period: okcoin.P15Min, want: 15 * time.Minute},
+		2: {period: okcoin.P4Hour, want: 4 * time.Hour},
+		3: {period: okcoin.P3Day, want: 72 * time.Hour},
+		4: {period: okcoin.P1Week, want: 168 * time.Hour},
+		5: {period: "2min", want: 0},
+		6: {period: "", want: 0},
+	}
+
+	for i, tt := range tests {
+		if g, w := tt.period.Duration(), tt.want; g != w {
+			t.Errorf("#%d: got=%v want=%v", i, g, w)
+		}
+	}
+}
+
+func minuteBars(start time.Time, n int) []*okcoin.CandleStick {
+	var csticks []*okcoin.CandleStick
+	for i := 0; i < n; i++ {
+		price := float64(100 + i)
+		csticks = append(csticks, &okcoin.CandleStick{
+			TimeStampMs: okcoin.TimeToEpochMs(start.Add(time.Duration(i) * time.Minute)),
+			Open:        price, High: price + 0.5, Low: price - 0.5, Close: price + 0.25, Volume: 1,
+		})
+	}
+	return csticks
+}
+
+func TestResample(t *testing.T) {
+	t.Parallel()
+
+	// 40 minutes from 23:50 UTC straddle midnight and
+	// do not start on a 15 minute boundary.
+	start := time.Date(2017, 8, 27, 23, 50, 0, 0, time.UTC)
+	cres := &okcoin.CandleStickResponse{
+		Symbol:       okcoin.BTCUSD,
+		Period:       okcoin.P1Min,
+		CandleSticks: minuteBars(start, 40),
+	}
+	// Shuffle the input to ensure it is sorted first.
+	cres.CandleSticks[0], cres.CandleSticks[39] = cres.CandleSticks[39], cres.CandleSticks[0]
+
+	tests := [...]struct {
+		to      okcoin.Period
+		want    []*okcoin.CandleStick
+		wantErr bool
+	}{
+		0: {to: "2min", wantErr: true},
+		1: {to: okcoin.P1Min, want: minuteBars(start, 40)},
+		2: {
+			to: okcoin.P15Min,
+			want: []*okcoin.CandleStick{
+				{TimeStampMs: okcoin.TimeToEpochMs(start.Add(-5 * time.Minute)), Open: 100, High: 109.5, Low: 99.5, Close: 109.25, Volume: 10},
+				{TimeStampMs: okcoin.TimeToEpochMs(start.Add(10 * time.Minute)), Open: 110, High: 124.5, Low: 109.5, Close: 124.25, Volume: 15},
+				{TimeStampMs: okcoin.TimeToEpochMs(start.Add(25 * time.Minute)), Open: 125, High: 139.5, Low: 124.5, Close: 139.25, Volume: 15},
+			},
+		},
+		3: {
+			to: okcoin.P1Day,
+			want: []*okcoin.CandleStick{
+				{TimeStampMs: okcoin.TimeToEpochMs(time.Date(2017, 8, 27, 0, 0, 0, 0, time.UTC)), Open: 100, High: 109.5, Low: 99.5, Close: 109.25, Volume: 10},
+				{TimeStampMs: okcoin.TimeToEpochMs(time.Date(2017, 8, 28, 0, 0, 0, 0, time.UTC)), Open: 110, High: 139.5, Low: 109.5, Close: 139.25, Volume: 30},
+			},
+		},
+		4: {
+			// 2017-08-27 is a Sunday so the week starts on Monday the 21st.
+			to: okcoin.P1Week,
+			want: []*okcoin.CandleStick{
+				{TimeStampMs: okcoin.TimeToEpochMs(time.Date(2017, 8, 21, 0, 0, 0, 0, time.UTC)), Open: 100, High: 109.5, Low: 99.5, Close: 109.25, Volume: 10},
+				{TimeStampMs: okcoin.TimeToEpochMs(time.Date(2017, 8, 28, 0, 0, 0, 0, time.UTC)), Open: 110, High: 139.5, Low: 109.5, Close: 139.25, Volume: 30},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		rres, err := okcoin.Resample(cres, tt.to)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: got err: %v", i, err)
+			continue
+		}
+		if g, w := rres.Period, tt.to; g != w {
+			t.Errorf("#%d: period: got=%q want=%q", i, g, w)
+		}
+		if g, w := rres.CandleSticks, tt.want; !reflect.DeepEqual(g, w) {
+			t.Errorf("#%d: got %d candle sticks, want %d", i, len(g), len(w))
+			for j := 0; j < len(g) && j < len(w); j++ {
+				if !reflect.DeepEqual(g[j], w[j]) {
+					t.Errorf("#%d.%d:\ngot= %+v\nwant=%+v", i, j, g[j], w[j])
+				}
+			}
+		}
+	}
+
+	if _, err := okcoin.Resample(&okcoin.CandleStickResponse{Period: okcoin.P1Hour}, okcoin.P15Min); err == nil {
+		t.Errorf("expected an error resampling to a finer period")
+	}
+	if _, err := okcoin.Resample(&okcoin.CandleStickResponse{Period: okcoin.P3Day}, okcoin.P1Week); err == nil {
+		t.Errorf("expected an error resampling to a non-multiple period")
+	}
+	if _, err := okcoin.Resample(nil, okcoin.P1Week); err == nil {
+		t.Errorf("expected an error for a nil response")
+	}
+}
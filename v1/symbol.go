@@ -0,0 +1,96 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Currency string
+
+const (
+	BCC Currency = "bcc"
+	BTC Currency = "btc"
+	ETC Currency = "etc"
+	ETH Currency = "eth"
+	LTC Currency = "ltc"
+	USD Currency = "usd"
+)
+
+func (c Currency) String() string { return string(c) }
+
+const symbolSeparator = "_"
+
+// NewSymbol returns the symbol for trading base against quote.
+func NewSymbol(base, quote Currency) Symbol {
+	return Symbol(string(base) + symbolSeparator + string(quote))
+}
+
+// ParseSymbol splits a symbol into its base and quote currencies.
+// Besides the API's own form "btc_usd" it accepts "BTC-USD" and "btc/usd".
+func ParseSymbol(s string) (base, quote Currency, err error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	splits := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == '-' || r == '/'
+	})
+	if len(splits) != 2 || len(s) != len(splits[0])+len(splits[1])+1 {
+		return "", "", fmt.Errorf("malformed symbol %q, expecting the form \"base_quote\"", s)
+	}
+	return Currency(splits[0]), Currency(splits[1]), nil
+}
+
+func (s Symbol) String() string { return string(s) }
+
+// Base returns the currency being bought or sold e.g. "btc" for "btc_usd".
+// It returns "" if the symbol is malformed.
+func (s Symbol) Base() Currency {
+	base, _, _ := ParseSymbol(string(s))
+	return base
+}
+
+// Quote returns the currency that prices are quoted in e.g. "usd" for "btc_usd".
+// It returns "" if the symbol is malformed.
+func (s Symbol) Quote() Currency {
+	_, quote, _ := ParseSymbol(string(s))
+	return quote
+}
+
+// Invert returns the symbol with its base and quote swapped e.g. "usd_btc" for "btc_usd".
+func (s Symbol) Invert() Symbol {
+	base, quote, err := ParseSymbol(string(s))
+	if err != nil {
+		return s
+	}
+	return NewSymbol(quote, base)
+}
+
+// Pair formats the symbol as "BTC/USD".
+func (s Symbol) Pair() string {
+	return s.format("/")
+}
+
+// InstrumentID formats the symbol as the v3 API's instrument_id e.g. "BTC-USD".
+func (s Symbol) InstrumentID() string {
+	return s.format("-")
+}
+
+func (s Symbol) format(sep string) string {
+	base, quote, err := ParseSymbol(string(s))
+	if err != nil {
+		return strings.ToUpper(string(s))
+	}
+	return strings.ToUpper(string(base)) + sep + strings.ToUpper(string(quote))
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestParseSymbol(t *testing.T) {
+	tests := [...]struct {
+		in                  string
+		wantBase, wantQuote okcoin.Currency
+		wantErr             bool
+	}{
+		0: {in: "btc_usd", wantBase: okcoin.BTC, wantQuote: okcoin.USD},
+		1: {in: "ETH-BTC", wantBase: okcoin.ETH, wantQuote: okcoin.BTC},
+		2: {in: " ltc/usd ", wantBase: okcoin.LTC, wantQuote: okcoin.USD},
+		3: {in: "", wantErr: true},
+		4: {in: "btcusd", wantErr: true},
+		5: {in: "btc__usd", wantErr: true},
+		6: {in: "btc_usd_eth", wantErr: true},
+		7: {in: "_usd", wantErr: true},
+	}
+
+	for i, tt := range tests {
+		base, quote, err := okcoin.ParseSymbol(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error; got base=%q quote=%q", i, base, quote)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: got err: %v", i, err)
+			continue
+		}
+		if base != tt.wantBase || quote != tt.wantQuote {
+			t.Errorf("#%d: got=(%q, %q) want=(%q, %q)", i, base, quote, tt.wantBase, tt.wantQuote)
+		}
+	}
+}
+
+func TestSymbolHelpers(t *testing.T) {
+	sym := okcoin.NewSymbol(okcoin.ETH, okcoin.USD)
+	if g, w := sym, okcoin.ETHUSD; g != w {
+		t.Errorf("NewSymbol: got=%q want=%q", g, w)
+	}
+	if g, w := sym.Base(), okcoin.ETH; g != w {
+		t.Errorf("Base: got=%q want=%q", g, w)
+	}
+	if g, w := sym.Quote(), okcoin.USD; g != w {
+		t.Errorf("Quote: got=%q want=%q", g, w)
+	}
+	if g, w := sym.Invert(), okcoin.Symbol("usd_eth"); g != w {
+		t.Errorf("Invert: got=%q want=%q", g, w)
+	}
+	if g, w := sym.Pair(), "ETH/USD"; g != w {
+		t.Errorf("Pair: got=%q want=%q", g, w)
+	}
+	if g, w := sym.InstrumentID(), "ETH-USD"; g != w {
+		t.Errorf("InstrumentID: got=%q want=%q", g, w)
+	}
+
+	bad := okcoin.Symbol("fugazi")
+	if g := bad.Base(); g != "" {
+		t.Errorf("malformed Base: got=%q want blank", g)
+	}
+	if g, w := bad.Invert(), bad; g != w {
+		t.Errorf("malformed Invert: got=%q want=%q", g, w)
+	}
+}
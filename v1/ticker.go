@@ -15,11 +15,12 @@
 package okcoin
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"net/http"
+	"net/url"
 	"reflect"
+	"time"
 )
 
 type TickerResponse struct {
@@ -28,6 +29,11 @@ type TickerResponse struct {
 	Ticker *Ticker `json:"ticker"`
 }
 
+// Time returns the time at which the ticker was reported.
+func (tr *TickerResponse) Time() time.Time {
+	return EpochToTime(tr.TimeAtEpoch)
+}
+
 type Ticker struct {
 	Buy    float64 `json:"buy,string,omitempty"`
 	High   float64 `json:"high,string,omitempty"`
@@ -35,8 +41,33 @@ type Ticker struct {
 	Low    float64 `json:"low,string,omitempty"`
 	Sell   float64 `json:"sell,string,omitempty"`
 	Volume float64 `json:"vol,string,omitempty"`
+
+	// raw is the JSON that the ticker was decoded from, if any.
+	raw json.RawMessage
 }
 
+func (t *Ticker) UnmarshalJSON(b []byte) error {
+	type ticker Ticker
+	recv := new(ticker)
+	if err := json.Unmarshal(b, recv); err != nil {
+		return err
+	}
+	*t = Ticker(*recv)
+	t.raw = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// The Decimal accessors return the digits sent by the exchange, read
+// from the ticker's JSON, or convert the float64 fields of tickers
+// that were not decoded from JSON.
+
+func (t *Ticker) BuyDecimal() Decimal    { return objectDecimal(t.raw, "buy", t.Buy) }
+func (t *Ticker) HighDecimal() Decimal   { return objectDecimal(t.raw, "high", t.High) }
+func (t *Ticker) LastDecimal() Decimal   { return objectDecimal(t.raw, "last", t.Last) }
+func (t *Ticker) LowDecimal() Decimal    { return objectDecimal(t.raw, "low", t.Low) }
+func (t *Ticker) SellDecimal() Decimal   { return objectDecimal(t.raw, "sell", t.Sell) }
+func (t *Ticker) VolumeDecimal() Decimal { return objectDecimal(t.raw, "vol", t.Volume) }
+
 var (
 	errBlankSymbol         = errors.New("expecting a non-blank symbol")
 	errBlankTickerResponse = errors.New("expecting a non-blank ticker response")
@@ -47,21 +78,20 @@ var (
 )
 
 func (c *Client) Ticker(sym Symbol) (*TickerResponse, error) {
+	return c.ticker(context.Background(), sym)
+}
+
+func (c *Client) ticker(ctx context.Context, sym Symbol) (*TickerResponse, error) {
 	if sym == "" {
 		return nil, errBlankSymbol
 	}
-	fullURL := fmt.Sprintf("%s/ticker.do?symbol=%s", baseURL, sym)
-	req, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	blob, _, err := c.doHTTPReq(req)
+	qv := url.Values{"symbol": {string(sym)}}
+	res, err := c.call(ctx, &Call{Endpoint: "ticker.do", Params: qv})
 	if err != nil {
 		return nil, err
 	}
 	tRes := new(TickerResponse)
-	if err := json.Unmarshal(blob, tRes); err != nil {
+	if err := json.Unmarshal(res.Body, tRes); err != nil {
 		return nil, err
 	}
 	if reflect.DeepEqual(tRes, blankTickerResponse) {
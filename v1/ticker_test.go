@@ -63,6 +63,9 @@ func TestTicker(t *testing.T) {
 		if reflect.DeepEqual(tres, blankTickerResponse) {
 			t.Errorf("#%d: got blankTickerResponse", i)
 		}
+		if tres.Time().IsZero() {
+			t.Errorf("#%d: expected a non-zero ticker time", i)
+		}
 	}
 }
 
@@ -86,6 +89,20 @@ func (b *backend) RoundTrip(req *http.Request) (*http.Response, error) {
 		return b.candleStickRoundTrip(req)
 	case fundsRoute:
 		return b.fundsRoundTrip(req)
+	case instrumentsRoute:
+		return b.instrumentsRoundTrip(req)
+	case ordersRoute:
+		return b.ordersRoundTrip(req)
+	case candleStickHistoryRoute:
+		return b.candleStickHistoryRoundTrip(req)
+	case sparseHistoryRoute:
+		return b.sparseHistoryRoundTrip(req)
+	case valuationRoute:
+		return b.valuationRoundTrip(req)
+	case depthRoute:
+		return b.depthRoundTrip(req)
+	case listingsRoute:
+		return b.listingsRoundTrip(req)
 	default:
 		return nil, errUnimplemented
 	}
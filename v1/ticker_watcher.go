@@ -0,0 +1,263 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DeliveryPolicy decides what happens to an update
+// when a subscriber's buffer is full.
+type DeliveryPolicy int
+
+const (
+	// Block waits for the subscriber to make room,
+	// holding back the updates of every other subscriber.
+	Block DeliveryPolicy = iota
+	// DropNewest discards the update that did not fit.
+	DropNewest
+	// DropOldest discards the oldest buffered update to make room.
+	DropOldest
+)
+
+type TickerUpdate struct {
+	Symbol Symbol          `json:"symbol"`
+	Ticker *TickerResponse `json:"ticker"`
+}
+
+type TickerWatchRequest struct {
+	Symbols []Symbol `json:"symbols"`
+
+	// Interval is the time between polls, defaulting to 1 second.
+	Interval time.Duration `json:"interval,omitempty"`
+
+	// OnError if set is invoked with the errors of failed polls.
+	OnError func(Symbol, error) `json:"-"`
+}
+
+// TickerWatcher polls the tickers of symbols with Client.Tickers and
+// pushes an update to its subscribers whenever a symbol's last, buy or
+// sell price changes.
+type TickerWatcher struct {
+	client   *Client
+	symbols  []Symbol
+	interval time.Duration
+	onError  func(Symbol, error)
+
+	mu      sync.Mutex
+	subs    []*TickerSubscription
+	stopped bool
+	last    map[Symbol]*Ticker
+}
+
+// TickerSubscription receives updates on C until it
+// is closed or the watcher stops, which closes C.
+type TickerSubscription struct {
+	C <-chan *TickerUpdate
+
+	ch      chan *TickerUpdate
+	policy  DeliveryPolicy
+	watcher *TickerWatcher
+	done    chan struct{}
+	once    sync.Once
+	dropped uint64
+}
+
+const defaultTickerWatchInterval = 1 * time.Second
+
+var errNoSymbols = errors.New("expecting at least one symbol")
+
+func (c *Client) NewTickerWatcher(twr *TickerWatchRequest) (*TickerWatcher, error) {
+	if twr == nil || len(twr.Symbols) == 0 {
+		return nil, errNoSymbols
+	}
+	for _, sym := range twr.Symbols {
+		if sym == "" {
+			return nil, errBlankSymbol
+		}
+	}
+	interval := twr.Interval
+	if interval <= 0 {
+		interval = defaultTickerWatchInterval
+	}
+	return &TickerWatcher{
+		client:   c,
+		symbols:  append([]Symbol(nil), twr.Symbols...),
+		interval: interval,
+		onError:  twr.OnError,
+		last:     make(map[Symbol]*Ticker),
+	}, nil
+}
+
+// Subscribe registers a subscriber whose channel buffers up to bufSize
+// updates, handling overflow according to policy.
+func (tw *TickerWatcher) Subscribe(bufSize int, policy DeliveryPolicy) *TickerSubscription {
+	if bufSize < 0 {
+		bufSize = 0
+	}
+	if bufSize == 0 && policy == DropOldest {
+		// Nothing is ever buffered to be dropped.
+		policy = DropNewest
+	}
+	ch := make(chan *TickerUpdate, bufSize)
+	sub := &TickerSubscription{
+		C:       ch,
+		ch:      ch,
+		policy:  policy,
+		watcher: tw,
+		done:    make(chan struct{}),
+	}
+
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.stopped {
+		sub.closeDone()
+		close(ch)
+		return sub
+	}
+	tw.subs = append(tw.subs, sub)
+	return sub
+}
+
+// Dropped returns the number of updates discarded for this subscriber.
+func (sub *TickerSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+func (sub *TickerSubscription) closeDone() {
+	sub.once.Do(func() { close(sub.done) })
+}
+
+// Close unsubscribes and closes C.
+func (sub *TickerSubscription) Close() {
+	// Release any delivery blocked on this subscriber
+	// before waiting for the watcher's lock.
+	sub.closeDone()
+
+	tw := sub.watcher
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	// Whoever removes the subscription from the
+	// watcher is responsible for closing its channel.
+	for i, s := range tw.subs {
+		if s == sub {
+			tw.subs = append(tw.subs[:i], tw.subs[i+1:]...)
+			close(sub.ch)
+			return
+		}
+	}
+}
+
+// Run polls until ctx is done, then closes all the subscriptions.
+func (tw *TickerWatcher) Run(ctx context.Context) error {
+	defer tw.stop()
+
+	ticker := time.NewTicker(tw.interval)
+	defer ticker.Stop()
+	for {
+		tw.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (tw *TickerWatcher) stop() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.stopped = true
+	for _, sub := range tw.subs {
+		sub.closeDone()
+		close(sub.ch)
+	}
+	tw.subs = nil
+}
+
+func (tw *TickerWatcher) poll(ctx context.Context) {
+	results := tw.client.Tickers(ctx, tw.symbols...)
+	// Deliver in the order the symbols were given.
+	for _, sym := range tw.symbols {
+		res := results[sym]
+		if res == nil {
+			continue
+		}
+		if res.Err != nil {
+			if tw.onError != nil && ctx.Err() == nil {
+				tw.onError(sym, res.Err)
+			}
+			continue
+		}
+		if res.Ticker == nil || res.Ticker.Ticker == nil {
+			continue
+		}
+		if tw.changed(sym, res.Ticker.Ticker) {
+			tw.publish(ctx, &TickerUpdate{Symbol: sym, Ticker: res.Ticker})
+		}
+	}
+}
+
+func (tw *TickerWatcher) changed(sym Symbol, t *Ticker) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	prev := tw.last[sym]
+	tw.last[sym] = t
+	return prev == nil || prev.Last != t.Last || prev.Buy != t.Buy || prev.Sell != t.Sell
+}
+
+func (tw *TickerWatcher) publish(ctx context.Context, update *TickerUpdate) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	for _, sub := range tw.subs {
+		sub.deliver(ctx, update)
+	}
+}
+
+func (sub *TickerSubscription) deliver(ctx context.Context, update *TickerUpdate) {
+	switch sub.policy {
+	case DropNewest:
+		select {
+		case sub.ch <- update:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+
+	case DropOldest:
+		for {
+			select {
+			case sub.ch <- update:
+				return
+			default:
+			}
+			select {
+			case <-sub.ch:
+				atomic.AddUint64(&sub.dropped, 1)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case sub.ch <- update:
+		case <-sub.done:
+		case <-ctx.Done():
+		}
+	}
+}
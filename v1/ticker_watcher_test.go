@@ -0,0 +1,131 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// scriptedTicker serves the last prices in script one poll at a time,
+// repeating the final one once the script runs out.
+type scriptedTicker struct {
+	mu     sync.Mutex
+	script []float64
+	polls  int
+}
+
+func (st *scriptedTicker) RoundTrip(req *http.Request) (*http.Response, error) {
+	st.mu.Lock()
+	i := st.polls
+	if i >= len(st.script) {
+		i = len(st.script) - 1
+	}
+	st.polls++
+	st.mu.Unlock()
+
+	body := fmt.Sprintf(`{"date":"1503960025","ticker":{"buy":"4572.48","last":"%v","sell":"4594.17"}}`, st.script[i])
+	return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(body)))
+}
+
+func TestTickerWatcher(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&scriptedTicker{script: []float64{1, 1, 2, 2, 3}})
+
+	if _, err := client.NewTickerWatcher(&okcoin.TickerWatchRequest{}); err == nil {
+		t.Errorf("expected an error without symbols")
+	}
+	watcher, err := client.NewTickerWatcher(&okcoin.TickerWatchRequest{
+		Symbols:  []okcoin.Symbol{okcoin.BTCUSD},
+		Interval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+
+	dropNewest := watcher.Subscribe(1, okcoin.DropNewest)
+	dropOldest := watcher.Subscribe(1, okcoin.DropOldest)
+	unsubscribed := watcher.Subscribe(0, okcoin.Block)
+	unsubscribed.Close()
+	// Subscribed last so that by the time it has received an
+	// update, the other subscribers have been offered it too.
+	blocking := watcher.Subscribe(0, okcoin.Block)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- watcher.Run(ctx) }()
+
+	var lasts []float64
+	for len(lasts) < 3 {
+		select {
+		case update := <-blocking.C:
+			lasts = append(lasts, update.Ticker.Ticker.Last)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out with updates %v", lasts)
+		}
+	}
+	if g, w := fmt.Sprint(lasts), "[1 2 3]"; g != w {
+		t.Errorf("blocking: got=%s want=%s", g, w)
+	}
+
+	// Unchanged prices must not be published again.
+	select {
+	case update := <-blocking.C:
+		t.Errorf("unexpected update: %+v", update.Ticker.Ticker)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-runErr; err != context.Canceled {
+		t.Errorf("run: got=%v want=%v", err, context.Canceled)
+	}
+
+	if g, w := dropNewest.Dropped(), uint64(2); g != w {
+		t.Errorf("DropNewest dropped: got=%d want=%d", g, w)
+	}
+	if update := <-dropNewest.C; update == nil || update.Ticker.Ticker.Last != 1 {
+		t.Errorf("DropNewest: got=%+v want the first update", update)
+	}
+	if g, w := dropOldest.Dropped(), uint64(2); g != w {
+		t.Errorf("DropOldest dropped: got=%d want=%d", g, w)
+	}
+	if update := <-dropOldest.C; update == nil || update.Ticker.Ticker.Last != 3 {
+		t.Errorf("DropOldest: got=%+v want the last update", update)
+	}
+
+	// Every subscription is closed once the watcher stops.
+	for i, sub := range []*okcoin.TickerSubscription{dropNewest, dropOldest, unsubscribed, blocking} {
+		if _, ok := <-sub.C; ok {
+			t.Errorf("#%d: expected a closed channel", i)
+		}
+	}
+	if _, ok := <-watcher.Subscribe(1, okcoin.Block).C; ok {
+		t.Errorf("expected a closed channel when subscribing to a stopped watcher")
+	}
+}
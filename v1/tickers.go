@@ -0,0 +1,75 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"sync"
+)
+
+// TickerResult holds either the ticker for a symbol or
+// the error encountered while retrieving it.
+type TickerResult struct {
+	Ticker *TickerResponse `json:"ticker,omitempty"`
+	Err    error           `json:"-"`
+}
+
+// maxConcurrentTickers bounds the number of ticker requests in flight.
+const maxConcurrentTickers = 4
+
+// Tickers fetches the tickers of symbols concurrently, with at most
+// maxConcurrentTickers requests in flight, all paced by the Client's
+// RateLimiter. A failure for one symbol is reported in its result
+// and does not affect the others.
+func (c *Client) Tickers(ctx context.Context, symbols ...Symbol) map[Symbol]*TickerResult {
+	results := make(map[Symbol]*TickerResult, len(symbols))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	symbolsChan := make(chan Symbol)
+	workers := maxConcurrentTickers
+	if len(symbols) < workers {
+		workers = len(symbols)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sym := range symbolsChan {
+				var tres *TickerResponse
+				err := ctx.Err()
+				if err == nil {
+					tres, err = c.ticker(ctx, sym)
+				}
+				mu.Lock()
+				results[sym] = &TickerResult{Ticker: tres, Err: err}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[Symbol]bool, len(symbols))
+	for _, sym := range symbols {
+		if seen[sym] {
+			continue
+		}
+		seen[sym] = true
+		symbolsChan <- sym
+	}
+	close(symbolsChan)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestTickers(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&backend{route: tickerRoute})
+	limiter := new(countingLimiter)
+	client.SetRateLimiter(limiter)
+
+	results := client.Tickers(context.Background(),
+		okcoin.BTCUSD, okcoin.LTCUSD, "fugazi-coin", "", okcoin.BTCUSD)
+
+	if g, w := len(results), 4; g != w {
+		t.Fatalf("results: got=%d want=%d", g, w)
+	}
+	for _, sym := range []okcoin.Symbol{okcoin.BTCUSD, okcoin.LTCUSD} {
+		res := results[sym]
+		if res == nil || res.Err != nil || res.Ticker == nil {
+			t.Errorf("%s: got=%#v want a ticker", sym, res)
+		}
+	}
+	for _, sym := range []okcoin.Symbol{"fugazi-coin", ""} {
+		res := results[sym]
+		if res == nil || res.Err == nil {
+			t.Errorf("%q: got=%#v want an error", sym, res)
+		}
+	}
+	// The blank symbol fails before any request is made.
+	if g, w := limiter.waits, 3; g != w {
+		t.Errorf("rate limiter waits: got=%d want=%d", g, w)
+	}
+}
+
+// slowTicker serves a fixed ticker after a delay,
+// recording the most requests it had in flight at once.
+type slowTicker struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (st *slowTicker) RoundTrip(req *http.Request) (*http.Response, error) {
+	st.mu.Lock()
+	st.inFlight++
+	if st.inFlight > st.maxInFlight {
+		st.maxInFlight = st.inFlight
+	}
+	st.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	st.mu.Lock()
+	st.inFlight--
+	st.mu.Unlock()
+	return respFromFile("./testdata/ticker-btc_usd.json")
+}
+
+func TestTickersBoundedConcurrency(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	st := new(slowTicker)
+	client.SetHTTPRoundTripper(st)
+
+	var symbols []okcoin.Symbol
+	for _, base := range []okcoin.Currency{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		symbols = append(symbols, okcoin.NewSymbol(base, okcoin.USD))
+	}
+	results := client.Tickers(context.Background(), symbols...)
+	for _, sym := range symbols {
+		if res := results[sym]; res == nil || res.Err != nil {
+			t.Errorf("%s: got=%#v", sym, res)
+		}
+	}
+	if st.maxInFlight > 4 {
+		t.Errorf("in flight: got=%d want at most 4", st.maxInFlight)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for sym, res := range client.Tickers(ctx, symbols...) {
+		if res.Err == nil {
+			t.Errorf("%s: expected an error from a cancelled context", sym)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"time"
+)
+
+// The API reports some times in seconds and others in milliseconds
+// since the Unix epoch. Epochs at or beyond this magnitude are taken
+// to be in milliseconds: as seconds they would fall after the year 5000.
+const msEpochThreshold = 1e11
+
+// EpochToTime converts an epoch in either seconds or milliseconds
+// to a UTC time. The zero epoch converts to the zero time.
+func EpochToTime(epoch float64) time.Time {
+	if epoch == 0 || math.IsNaN(epoch) || math.IsInf(epoch, 0) {
+		return time.Time{}
+	}
+	if math.Abs(epoch) >= msEpochThreshold {
+		epoch /= 1e3
+	}
+	secs, frac := math.Modf(epoch)
+	nsecs := math.Round(frac*1e6) * 1e3 // Only keep microseconds of float64 precision.
+	return time.Unix(int64(secs), int64(nsecs)).UTC()
+}
+
+// TimeToEpochMs returns t as milliseconds since the Unix epoch,
+// the unit that request parameters such as kline's "since" expect.
+func TimeToEpochMs(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano() / int64(time.Millisecond))
+}
+
+// Timestamp is a time that decodes from JSON epochs in either seconds
+// or milliseconds, given as numbers or as strings. It encodes back to
+// milliseconds.
+type Timestamp struct {
+	time.Time
+}
+
+func (ts *Timestamp) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(bytes.TrimSpace(b), `"`)
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) {
+		ts.Time = time.Time{}
+		return nil
+	}
+	epoch, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return err
+	}
+	ts.Time = EpochToTime(epoch)
+	return nil
+}
+
+func (ts Timestamp) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatFloat(TimeToEpochMs(ts.Time), 'f', -1, 64)), nil
+}
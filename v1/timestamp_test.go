@@ -0,0 +1,76 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func TestEpochToTime(t *testing.T) {
+	tests := [...]struct {
+		epoch float64
+		want  time.Time
+	}{
+		0: {epoch: 0, want: time.Time{}},
+		1: {epoch: 1503960025, want: time.Unix(1503960025, 0).UTC()},
+		2: {epoch: 1503985018000, want: time.Unix(1503985018, 0).UTC()},
+		3: {epoch: 1503985018123, want: time.Unix(1503985018, 123e6).UTC()},
+		4: {epoch: 1504068023.163283, want: time.Unix(1504068023, 163283e3).UTC()},
+	}
+
+	for i, tt := range tests {
+		if g, w := okcoin.EpochToTime(tt.epoch), tt.want; !g.Equal(w) {
+			t.Errorf("#%d: got=%v want=%v", i, g, w)
+		}
+	}
+
+	if g, w := okcoin.TimeToEpochMs(time.Unix(1503985018, 123e6)), 1503985018123.0; g != w {
+		t.Errorf("TimeToEpochMs: got=%f want=%f", g, w)
+	}
+}
+
+func TestTimestampJSON(t *testing.T) {
+	var recv struct {
+		Seconds      okcoin.Timestamp `json:"s"`
+		Milliseconds okcoin.Timestamp `json:"ms"`
+		Quoted       okcoin.Timestamp `json:"q"`
+		Null         okcoin.Timestamp `json:"n"`
+	}
+	blob := []byte(`{"s":1503960025,"ms":1503960025000,"q":"1503960025","n":null}`)
+	if err := json.Unmarshal(blob, &recv); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := time.Unix(1503960025, 0)
+	for i, ts := range []okcoin.Timestamp{recv.Seconds, recv.Milliseconds, recv.Quoted} {
+		if !ts.Equal(want) {
+			t.Errorf("#%d: got=%v want=%v", i, ts.Time, want)
+		}
+	}
+	if !recv.Null.IsZero() {
+		t.Errorf("null: got=%v want zero time", recv.Null.Time)
+	}
+
+	out, err := json.Marshal(recv.Seconds)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if g, w := string(out), "1503960025000"; g != w {
+		t.Errorf("marshal: got=%s want=%s", g, w)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+// Tracer observes every HTTP request made by a Client, for example to
+// start a tracing span and record metrics. Package tracing/otel adapts
+// it to OpenTelemetry, keeping this package free of its dependencies.
+//
+// Neither the API key, the secret key nor the signature of a request
+// are ever passed to a Tracer.
+type Tracer interface {
+	// StartRequest is called before the request described by info is
+	// sent. The returned context, which may carry a span, is the one the
+	// request is sent with, and end is called once with its outcome.
+	StartRequest(ctx context.Context, info *RequestInfo) (_ context.Context, end func(*RequestResult))
+}
+
+// RequestInfo describes a request to the API.
+type RequestInfo struct {
+	// Endpoint is the name of the API endpoint, e.g. "ticker.do".
+	Endpoint string
+	Method   string
+	// URL is the request's URL without the secret parameters.
+	URL string
+	// Symbol is the symbol that the request is about, if any.
+	Symbol Symbol
+}
+
+// RequestResult is the outcome of a request to the API.
+type RequestResult struct {
+	// StatusCode is the HTTP status, or 0 if no response arrived.
+	StatusCode int
+	// ErrorCode is the error_code that the API answered with, if any.
+	ErrorCode int
+	// Err is the failure to get a successful HTTP response, if any,
+	// with the secret parameters of the request's URL removed.
+	Err error
+
+	Latency       time.Duration
+	ResponseBytes int
+}
+
+// SetTracer makes every request be reported to t.
+// A nil Tracer turns tracing off.
+func (c *Client) SetTracer(t Tracer) {
+	c.mu.Lock()
+	c.tr = t
+	c.mu.Unlock()
+}
+
+func (c *Client) tracer() Tracer {
+	c.mu.RLock()
+	tr := c.tr
+	c.mu.RUnlock()
+	return tr
+}
+
+// secretParams must never reach a Tracer.
+var secretParams = []string{"api_key", "secret_key", "sign"}
+
+func newRequestInfo(req *http.Request) *RequestInfo {
+	qv := req.URL.Query()
+	return &RequestInfo{
+		Endpoint: path.Base(req.URL.Path),
+		Method:   req.Method,
+		URL:      redactURL(req.URL),
+		Symbol:   Symbol(qv.Get("symbol")),
+	}
+}
+
+// redactURL returns u without its secret parameters.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	qv := u.Query()
+	for _, key := range secretParams {
+		qv.Del(key)
+	}
+	redacted.RawQuery = qv.Encode()
+	redacted.User = nil
+	return redacted.String()
+}
+
+// redactError removes the secret parameters from the URL
+// that net/http includes in the errors of requests.
+func redactError(err error) error {
+	ue, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	u, perr := url.Parse(ue.URL)
+	if perr != nil {
+		return &url.Error{Op: ue.Op, Err: ue.Err}
+	}
+	return &url.Error{Op: ue.Op, URL: redactURL(u), Err: ue.Err}
+}
+
+// traceHTTPReq sends req through roundTrip, reporting it to tr.
+func traceHTTPReq(tr Tracer, req *http.Request, roundTrip func(*http.Request) ([]byte, http.Header, int, error)) ([]byte, http.Header, error) {
+	ctx, end := tr.StartRequest(req.Context(), newRequestInfo(req))
+	start := time.Now()
+	blob, header, status, err := roundTrip(req.WithContext(ctx))
+	res := &RequestResult{
+		StatusCode:    status,
+		Latency:       time.Since(start),
+		ResponseBytes: len(blob),
+	}
+	if err != nil {
+		res.Err = redactError(err)
+	} else if ae, ok := resultError(blob).(*APIError); ok {
+		res.ErrorCode = ae.Code
+	}
+	end(res)
+	return blob, header, err
+}
@@ -0,0 +1,150 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otel reports the requests of an okcoin.Client to OpenTelemetry
+// as client spans and metrics:
+//
+//	tr, err := otel.New(nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	client.SetTracer(tr)
+//
+// Spans are named after the endpoint, e.g. "okcoin ticker.do", and carry
+// its method, symbol and URL without the secret parameters, along with
+// the HTTP status, error_code and size of the response. Failed requests
+// have an error status.
+//
+// The package is a module of its own, with the versions of OpenTelemetry
+// pinned in its go.mod, so that only the programs importing it depend on
+// OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	global "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// ScopeName is the name of the instrumentation scope of the spans and metrics.
+const ScopeName = "github.com/orijtech/okcoin/v1/tracing/otel"
+
+// Attribute keys of the spans and metrics.
+const (
+	EndpointKey      = attribute.Key("okcoin.endpoint")
+	SymbolKey        = attribute.Key("okcoin.symbol")
+	ErrorCodeKey     = attribute.Key("okcoin.error_code")
+	MethodKey        = attribute.Key("http.request.method")
+	URLKey           = attribute.Key("url.full")
+	StatusCodeKey    = attribute.Key("http.response.status_code")
+	ResponseBytesKey = attribute.Key("http.response.body.size")
+)
+
+type Config struct {
+	// TracerProvider creates the spans, the global one if nil.
+	TracerProvider trace.TracerProvider
+
+	// MeterProvider records the metrics, the global one if nil.
+	MeterProvider metric.MeterProvider
+}
+
+// Tracer is an okcoin.Tracer that reports to OpenTelemetry.
+type Tracer struct {
+	tracer   trace.Tracer
+	requests metric.Int64Counter
+	latency  metric.Float64Histogram
+	size     metric.Int64Histogram
+}
+
+var _ okcoin.Tracer = (*Tracer)(nil)
+
+// New returns a Tracer for cfg, which may be nil for the global providers.
+func New(cfg *Config) (*Tracer, error) {
+	if cfg == nil {
+		cfg = new(Config)
+	}
+	tp, mp := cfg.TracerProvider, cfg.MeterProvider
+	if tp == nil {
+		tp = global.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = global.GetMeterProvider()
+	}
+
+	meter := mp.Meter(ScopeName)
+	requests, err := meter.Int64Counter("okcoin.client.requests",
+		metric.WithDescription("Requests made to the OKCoin API by endpoint and outcome."))
+	if err != nil {
+		return nil, err
+	}
+	latency, err := meter.Float64Histogram("okcoin.client.duration", metric.WithUnit("s"),
+		metric.WithDescription("Latency of the requests to the OKCoin API by endpoint."))
+	if err != nil {
+		return nil, err
+	}
+	size, err := meter.Int64Histogram("okcoin.client.response.size", metric.WithUnit("By"),
+		metric.WithDescription("Size of the responses of the OKCoin API by endpoint."))
+	if err != nil {
+		return nil, err
+	}
+	return &Tracer{tracer: tp.Tracer(ScopeName), requests: requests, latency: latency, size: size}, nil
+}
+
+// StartRequest starts a client span for the request, ended along with it.
+func (t *Tracer) StartRequest(ctx context.Context, info *okcoin.RequestInfo) (context.Context, func(*okcoin.RequestResult)) {
+	attrs := []attribute.KeyValue{
+		EndpointKey.String(info.Endpoint),
+		MethodKey.String(info.Method),
+		URLKey.String(info.URL),
+	}
+	if info.Symbol != "" {
+		attrs = append(attrs, SymbolKey.String(string(info.Symbol)))
+	}
+	ctx, span := t.tracer.Start(ctx, "okcoin "+info.Endpoint,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	return ctx, func(res *okcoin.RequestResult) {
+		// The metrics are only labelled with what has few values.
+		mattrs := []attribute.KeyValue{EndpointKey.String(info.Endpoint)}
+		if res.StatusCode != 0 {
+			span.SetAttributes(StatusCodeKey.Int(res.StatusCode))
+			mattrs = append(mattrs, StatusCodeKey.Int(res.StatusCode))
+		}
+		if res.ErrorCode != 0 {
+			span.SetAttributes(ErrorCodeKey.Int(res.ErrorCode))
+			mattrs = append(mattrs, ErrorCodeKey.Int(res.ErrorCode))
+		}
+		span.SetAttributes(ResponseBytesKey.Int(res.ResponseBytes))
+		switch {
+		case res.Err != nil:
+			span.RecordError(res.Err)
+			span.SetStatus(codes.Error, res.Err.Error())
+		case res.ErrorCode != 0:
+			span.SetStatus(codes.Error, fmt.Sprintf("error_code %d", res.ErrorCode))
+		}
+		span.End()
+
+		set := metric.WithAttributes(mattrs...)
+		t.requests.Add(ctx, 1, set)
+		t.latency.Record(ctx, res.Latency.Seconds(), set)
+		t.size.Record(ctx, int64(res.ResponseBytes), set)
+	}
+}
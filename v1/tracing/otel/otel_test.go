@@ -0,0 +1,193 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/orijtech/okcoin/v1"
+	"github.com/orijtech/okcoin/v1/okcointest"
+	"github.com/orijtech/okcoin/v1/tracing/otel"
+)
+
+const (
+	apiKey1    = "key1"
+	apiSecret1 = "secret1"
+)
+
+func newTracedClient(t *testing.T) (*okcoin.Client, *okcointest.Server, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	srv := okcointest.NewServer()
+	srv.SetTicker(okcoin.BTCUSD, &okcoin.TickerResponse{Ticker: &okcoin.Ticker{Last: 4592}})
+	if err := srv.AddAccount(apiKey1, apiSecret1, map[okcoin.Currency]float64{okcoin.USD: 100}); err != nil {
+		srv.Close()
+		t.Fatalf("add account: %v", err)
+	}
+	client, err := srv.NewClient(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	if err != nil {
+		srv.Close()
+		t.Fatalf("new client: %v", err)
+	}
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	tr, err := otel.New(&otel.Config{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	if err != nil {
+		srv.Close()
+		t.Fatalf("new tracer: %v", err)
+	}
+	client.SetTracer(tr)
+	return client, srv, spans, reader
+}
+
+func attrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	client, srv, spans, _ := newTracedClient(t)
+	defer srv.Close()
+
+	if _, err := client.Ticker(okcoin.BTCUSD); err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	if _, err := client.Funds(); err != nil {
+		t.Fatalf("funds: %v", err)
+	}
+
+	ended := spans.Ended()
+	if g, w := len(ended), 2; g != w {
+		t.Fatalf("spans: got=%d want=%d", g, w)
+	}
+	ticker, funds := ended[0], ended[1]
+	if g, w := ticker.Name(), "okcoin ticker.do"; g != w {
+		t.Errorf("name: got=%q want=%q", g, w)
+	}
+	if g, w := ticker.SpanKind(), trace.SpanKindClient; g != w {
+		t.Errorf("kind: got=%v want=%v", g, w)
+	}
+	if g := ticker.Status().Code; g != codes.Unset {
+		t.Errorf("status: got=%v want unset", g)
+	}
+	got := attrs(ticker)
+	for key, want := range map[attribute.Key]attribute.Value{
+		otel.EndpointKey:   attribute.StringValue("ticker.do"),
+		otel.SymbolKey:     attribute.StringValue("btc_usd"),
+		otel.MethodKey:     attribute.StringValue("GET"),
+		otel.StatusCodeKey: attribute.IntValue(http.StatusOK),
+	} {
+		if g := got[key]; g != want {
+			t.Errorf("%s: got=%v want=%v", key, g.Emit(), want.Emit())
+		}
+	}
+	if got[otel.ResponseBytesKey].AsInt64() <= 0 {
+		t.Errorf("response bytes: got=%v want > 0", got[otel.ResponseBytesKey].Emit())
+	}
+	if _, ok := got[otel.ErrorCodeKey]; ok {
+		t.Errorf("successful request has an error_code")
+	}
+
+	// The signed request's secrets are kept out of its span.
+	if g, w := funds.Name(), "okcoin userinfo.do"; g != w {
+		t.Errorf("name: got=%q want=%q", g, w)
+	}
+	for _, kv := range funds.Attributes() {
+		for _, secret := range []string{apiKey1, apiSecret1, "sign="} {
+			if strings.Contains(kv.Value.Emit(), secret) {
+				t.Errorf("%s leaks %q: %s", kv.Key, secret, kv.Value.Emit())
+			}
+		}
+	}
+}
+
+func TestTracerErrors(t *testing.T) {
+	t.Parallel()
+
+	client, srv, spans, reader := newTracedClient(t)
+	defer srv.Close()
+
+	srv.FailWithCode("userinfo.do", 10001)
+	if _, err := client.Funds(); err == nil {
+		t.Fatalf("funds: want non-nil error")
+	}
+	srv.FailWithStatus("ticker.do", http.StatusServiceUnavailable)
+	if _, err := client.Ticker(okcoin.BTCUSD); err == nil {
+		t.Fatalf("ticker: want non-nil error")
+	}
+
+	ended := spans.Ended()
+	if g, w := len(ended), 2; g != w {
+		t.Fatalf("spans: got=%d want=%d", g, w)
+	}
+	funds, ticker := ended[0], ended[1]
+	if g, w := funds.Status(), (sdktrace.Status{Code: codes.Error, Description: "error_code 10001"}); g != w {
+		t.Errorf("funds status: got=%+v want=%+v", g, w)
+	}
+	if g, w := attrs(funds)[otel.ErrorCodeKey], attribute.IntValue(10001); g != w {
+		t.Errorf("funds error_code: got=%v want=%v", g.Emit(), w.Emit())
+	}
+	if g := ticker.Status().Code; g != codes.Error {
+		t.Errorf("ticker status: got=%v want error", g)
+	}
+	if g, w := attrs(ticker)[otel.StatusCodeKey], attribute.IntValue(http.StatusServiceUnavailable); g != w {
+		t.Errorf("ticker status code: got=%v want=%v", g.Emit(), w.Emit())
+	}
+	if len(ticker.Events()) == 0 || ticker.Events()[0].Name != "exception" {
+		t.Errorf("ticker: want the error recorded, got events %+v", ticker.Events())
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	counts := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "okcoin.client.requests" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				endpoint, _ := dp.Attributes.Value(otel.EndpointKey)
+				code, _ := dp.Attributes.Value(otel.ErrorCodeKey)
+				status, _ := dp.Attributes.Value(otel.StatusCodeKey)
+				counts[endpoint.Emit()+"/"+code.Emit()+"/"+status.Emit()] += dp.Value
+			}
+		}
+	}
+	for key, want := range map[string]int64{"userinfo.do/10001/200": 1, "ticker.do//503": 1} {
+		if g := counts[key]; g != want {
+			t.Errorf("requests %s: got=%d want=%d (all: %v)", key, g, want, counts)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+type spanKey struct{}
+
+type span struct {
+	info   *okcoin.RequestInfo
+	result *okcoin.RequestResult
+}
+
+// recordingTracer keeps the spans of the requests it observes.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+var _ okcoin.Tracer = (*recordingTracer)(nil)
+
+func (rt *recordingTracer) StartRequest(ctx context.Context, info *okcoin.RequestInfo) (context.Context, func(*okcoin.RequestResult)) {
+	s := &span{info: info}
+	rt.mu.Lock()
+	rt.spans = append(rt.spans, s)
+	rt.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), func(res *okcoin.RequestResult) {
+		rt.mu.Lock()
+		s.result = res
+		rt.mu.Unlock()
+	}
+}
+
+func (rt *recordingTracer) last() *span {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.spans[len(rt.spans)-1]
+}
+
+// spanChecker fails requests that are not sent with the tracer's context.
+type spanChecker struct {
+	base http.RoundTripper
+}
+
+func (sc *spanChecker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := req.Context().Value(spanKey{}).(*span); !ok {
+		return makeResp("no span in the request's context", http.StatusBadRequest, nil)
+	}
+	return sc.base.RoundTrip(req)
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	tracer := new(recordingTracer)
+	client.SetTracer(tracer)
+
+	client.SetHTTPRoundTripper(&spanChecker{base: &backend{route: tickerRoute}})
+	if _, err := client.Ticker(okcoin.LTCUSD); err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	s := tracer.last()
+	if g, w := s.info.Endpoint, "ticker.do"; g != w {
+		t.Errorf("endpoint: got=%q want=%q", g, w)
+	}
+	if g, w := s.info.Symbol, okcoin.LTCUSD; g != w {
+		t.Errorf("symbol: got=%q want=%q", g, w)
+	}
+	if s.result == nil || s.result.StatusCode != http.StatusOK || s.result.ResponseBytes == 0 || s.result.Err != nil {
+		t.Errorf("ticker result: got=%+v", s.result)
+	}
+
+	client.SetHTTPRoundTripper(&spanChecker{base: &backend{route: ordersRoute}})
+	_, err = client.PlaceOrder(&okcoin.OrderRequest{
+		Symbol: okcoin.LTCUSD, Type: okcoin.Sell,
+		Price: okcoin.MustParseDecimal("100"), Amount: okcoin.MustParseDecimal("100000"),
+	})
+	if err == nil {
+		t.Fatalf("place order: want insufficient funds")
+	}
+	s = tracer.last()
+	if s.result == nil || s.result.ErrorCode != 10010 || s.result.Err != nil {
+		t.Errorf("place order result: got=%+v want error_code 10010", s.result)
+	}
+	if !strings.Contains(s.info.URL, "type=sell") {
+		t.Errorf("url: got=%q want the order's parameters", s.info.URL)
+	}
+
+	// net/http's errors name the URL, which must lose its secrets.
+	client.SetHTTPRoundTripper(&backend{route: "unreachable"})
+	if _, err := client.Funds(); err == nil {
+		t.Fatalf("funds: want non-nil error")
+	}
+	s = tracer.last()
+	if s.result == nil || s.result.Err == nil || s.result.StatusCode != 0 {
+		t.Errorf("transport failure result: got=%+v", s.result)
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	for i, s := range tracer.spans {
+		recorded := fmt.Sprintf("%+v %+v", s.info, s.result)
+		for _, secret := range []string{apiKey1, "api_key", "sign=", "secret_key"} {
+			if strings.Contains(recorded, secret) {
+				t.Errorf("span #%d leaks %q: %s", i, secret, recorded)
+			}
+		}
+	}
+}
+
+func TestTracerOff(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	tracer := new(recordingTracer)
+	client.SetTracer(tracer)
+	client.SetTracer(nil)
+	client.SetHTTPRoundTripper(&backend{route: tickerRoute})
+	if _, err := client.Ticker(okcoin.LTCUSD); err != nil {
+		t.Fatalf("ticker: %v", err)
+	}
+	if n := len(tracer.spans); n != 0 {
+		t.Errorf("spans after tracing was turned off: got=%d want=0", n)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+type TradeCrawlRequest struct {
+	Symbol Symbol `json:"symbol,omitempty"`
+
+	// AfterID is the ID of the trade to start crawling after.
+	// If 0, crawling starts at the most recent trades.
+	AfterID int64 `json:"after_id,omitempty"`
+
+	// PollInterval if positive makes the crawler keep tailing
+	// new trades at this interval once it has caught up.
+	PollInterval time.Duration `json:"poll_interval,omitempty"`
+}
+
+// TradeCrawler walks the public trade tape by repeatedly requesting
+// the trades after the highest trade ID seen so far. Every request
+// goes through the Client and thus its RateLimiter.
+type TradeCrawler struct {
+	client       *Client
+	symbol       Symbol
+	pollInterval time.Duration
+
+	lastID int64
+}
+
+var errNilTradeFunc = errors.New("expecting a non-nil trade callback")
+
+func (c *Client) NewTradeCrawler(tcr *TradeCrawlRequest) (*TradeCrawler, error) {
+	if tcr == nil {
+		tcr = new(TradeCrawlRequest)
+	}
+	symbol := tcr.Symbol
+	if symbol == "" {
+		symbol = defaultSymbol
+	}
+	afterID := tcr.AfterID
+	if afterID < 0 {
+		afterID = 0
+	}
+	return &TradeCrawler{
+		client:       c,
+		symbol:       symbol,
+		pollInterval: tcr.PollInterval,
+		lastID:       afterID,
+	}, nil
+}
+
+// LastID returns the ID of the last trade emitted,
+// from which a later crawl can resume.
+func (tc *TradeCrawler) LastID() int64 {
+	return tc.lastID
+}
+
+// Crawl invokes fn with every trade, in ascending ID order and exactly
+// once each, until it has caught up with the tape. If a PollInterval
+// was set it then keeps polling for new trades until ctx is done.
+// Crawling stops at the first error returned by fn or by a request.
+func (tc *TradeCrawler) Crawl(ctx context.Context, fn func(*Trade) error) error {
+	if fn == nil {
+		return errNilTradeFunc
+	}
+	for {
+		n, err := tc.crawlPage(ctx, fn)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+
+		// Caught up.
+		if tc.pollInterval <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(tc.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// crawlPage emits the new trades from one trades.do call
+// and returns how many there were.
+func (tc *TradeCrawler) crawlPage(ctx context.Context, fn func(*Trade) error) (int, error) {
+	ltres, err := tc.client.LastTradesContext(ctx, &LastTradesRequest{
+		Symbol:      tc.symbol,
+		LastTradeID: int(tc.lastID),
+	})
+	if err != nil {
+		return 0, err
+	}
+	trades := ltres.Trades
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].ID < trades[j].ID
+	})
+
+	emitted := 0
+	for _, trade := range trades {
+		// Skip trades already emitted and duplicates within the page.
+		if trade == nil || trade.ID <= tc.lastID {
+			continue
+		}
+		if err := fn(trade); err != nil {
+			return emitted, err
+		}
+		tc.lastID = trade.ID
+		emitted++
+	}
+	return emitted, nil
+}
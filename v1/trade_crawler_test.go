@@ -0,0 +1,174 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+// tape serves a trade tape with IDs 1 through n, at most pageSize
+// at a time and newest first like trades.do, repeating the trade at
+// the cursor to exercise de-duplication. Each request grows the tape
+// by growBy trades.
+type tape struct {
+	mu       sync.Mutex
+	n        int
+	pageSize int
+	growBy   int
+}
+
+var _ http.RoundTripper = (*tape)(nil)
+
+func (tp *tape) RoundTrip(req *http.Request) (*http.Response, error) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	if !strings.HasSuffix(req.URL.Path, "/api/v1/trades.do") {
+		return makeResp(fmt.Sprintf("unexpected path %q", req.URL.Path), http.StatusBadRequest, nil)
+	}
+	since := 0
+	if str := req.URL.Query().Get("since"); str != "" {
+		var err error
+		if since, err = strconv.Atoi(str); err != nil {
+			return makeResp(err.Error(), http.StatusBadRequest, nil)
+		}
+	}
+
+	lo, hi := since, since+tp.pageSize
+	if since == 0 {
+		lo, hi = tp.n-tp.pageSize, tp.n
+	}
+	if hi > tp.n {
+		hi = tp.n
+	}
+	var trades []*okcoin.Trade
+	for id := hi; id >= lo && id >= 1; id-- {
+		trades = append(trades, &okcoin.Trade{ID: int64(id), Price: 4000, Amount: 1, Type: "buy"})
+	}
+	tp.n += tp.growBy
+
+	blob, err := json.Marshal(trades)
+	if err != nil {
+		return makeResp(err.Error(), http.StatusInternalServerError, nil)
+	}
+	return makeResp("200 OK", http.StatusOK, ioutil.NopCloser(strings.NewReader(string(blob))))
+}
+
+type countingLimiter struct {
+	mu    sync.Mutex
+	waits int
+}
+
+func (cl *countingLimiter) Wait(ctx context.Context) error {
+	cl.mu.Lock()
+	cl.waits++
+	cl.mu.Unlock()
+	return nil
+}
+
+func TestTradeCrawler(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&tape{n: 150, pageSize: 60})
+	limiter := new(countingLimiter)
+	client.SetRateLimiter(limiter)
+
+	crawler, err := client.NewTradeCrawler(&okcoin.TradeCrawlRequest{Symbol: okcoin.BTCUSD, AfterID: 10})
+	if err != nil {
+		t.Fatalf("new crawler: %v", err)
+	}
+	if err := crawler.Crawl(context.Background(), nil); err == nil {
+		t.Errorf("expected an error for a nil callback")
+	}
+
+	var ids []int64
+	err = crawler.Crawl(context.Background(), func(trade *okcoin.Trade) error {
+		ids = append(ids, trade.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+	if g, w := len(ids), 140; g != w {
+		t.Fatalf("trades: got=%d want=%d", g, w)
+	}
+	for i, id := range ids {
+		if want := int64(11 + i); id != want {
+			t.Fatalf("#%d: got ID=%d want=%d", i, id, want)
+		}
+	}
+	if g, w := crawler.LastID(), int64(150); g != w {
+		t.Errorf("LastID: got=%d want=%d", g, w)
+	}
+	// Pages of 11-70, 71-130 and 131-150, then the empty page.
+	if g, w := limiter.waits, 4; g != w {
+		t.Errorf("rate limiter waits: got=%d want=%d", g, w)
+	}
+}
+
+func TestTradeCrawlerTailing(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetHTTPRoundTripper(&tape{n: 100, pageSize: 60, growBy: 3})
+
+	crawler, err := client.NewTradeCrawler(&okcoin.TradeCrawlRequest{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("new crawler: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errStop := fmt.Errorf("stop")
+	var ids []int64
+	err = crawler.Crawl(ctx, func(trade *okcoin.Trade) error {
+		ids = append(ids, trade.ID)
+		if trade.ID >= 130 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("crawl: got err=%v want=%v", err, errStop)
+	}
+	// Starting from the most recent page of 40 through 100.
+	if g, w := ids[0], int64(40); g != w {
+		t.Errorf("first ID: got=%d want=%d", g, w)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] != ids[i-1]+1 {
+			t.Fatalf("#%d: got ID=%d after %d", i, ids[i], ids[i-1])
+		}
+	}
+}
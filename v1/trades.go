@@ -15,9 +15,9 @@
 package okcoin
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"net/http"
+	"time"
 
 	"github.com/orijtech/otils"
 )
@@ -25,6 +25,13 @@ import (
 type LastTradesRequest struct {
 	LastTradeID int    `json:"id,omitempty"`
 	Symbol      Symbol `json:"symbol,omitempty"`
+
+	// FilterSince if set drops the trades of the returned page that
+	// happened before it. trades.do only accepts a trade ID cursor
+	// and returns about 60 trades, so older trades are never fetched:
+	// the response is Truncated if FilterSince is older than the page.
+	// A TradeCrawler walks the whole tape from a trade ID instead.
+	FilterSince time.Time `json:"-"`
 }
 
 func (ltr *LastTradesRequest) Validate() error {
@@ -46,6 +53,10 @@ type lastTradesReq struct {
 type LastTradesResponse struct {
 	Trades []*Trade `json:"trades,omitempty"`
 	Symbol Symbol   `json:"symbol,omitempty"`
+
+	// Truncated reports that the request's FilterSince is older
+	// than the page of trades, which may thus lack some after it.
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 type Trade struct {
@@ -55,9 +66,44 @@ type Trade struct {
 	Price  float64 `json:"price,string,omitempty"`
 	DateMs float64 `json:"date_ms,omitempty"`
 	Date   float64 `json:"date,omitempty"`
+
+	// raw is the JSON that the trade was decoded from, if any.
+	raw json.RawMessage
+}
+
+func (t *Trade) UnmarshalJSON(b []byte) error {
+	type trade Trade
+	recv := new(trade)
+	if err := json.Unmarshal(b, recv); err != nil {
+		return err
+	}
+	*t = Trade(*recv)
+	t.raw = append(json.RawMessage(nil), b...)
+	return nil
+}
+
+// PriceDecimal returns the price sent by the exchange, read from the
+// trade's JSON, or converts Price for trades not decoded from JSON.
+func (t *Trade) PriceDecimal() Decimal { return objectDecimal(t.raw, "price", t.Price) }
+
+// AmountDecimal is like PriceDecimal for the amount.
+func (t *Trade) AmountDecimal() Decimal { return objectDecimal(t.raw, "amount", t.Amount) }
+
+// Time returns the time of the trade, preferring the
+// millisecond precision of DateMs when it is set.
+func (t *Trade) Time() time.Time {
+	if t.DateMs != 0 {
+		return EpochToTime(t.DateMs)
+	}
+	return EpochToTime(t.Date)
 }
 
 func (c *Client) LastTrades(ltr *LastTradesRequest) (*LastTradesResponse, error) {
+	return c.LastTradesContext(context.Background(), ltr)
+}
+
+// LastTradesContext is like LastTrades but gives up once ctx is done.
+func (c *Client) LastTradesContext(ctx context.Context, ltr *LastTradesRequest) (*LastTradesResponse, error) {
 	if ltr == nil {
 		ltr = new(LastTradesRequest)
 	}
@@ -76,19 +122,43 @@ func (c *Client) LastTrades(ltr *LastTradesRequest) (*LastTradesResponse, error)
 	if err != nil {
 		return nil, err
 	}
-	fullURL := fmt.Sprintf("%s/trades.do?%s", baseURL, qv.Encode())
-	req, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	blob, _, err := c.doHTTPReq(req)
+	res, err := c.call(ctx, &Call{Endpoint: "trades.do", Params: qv})
 	if err != nil {
 		return nil, err
 	}
 	var trades []*Trade
-	if err := json.Unmarshal(blob, &trades); err != nil {
+	if err := json.Unmarshal(res.Body, &trades); err != nil {
 		return nil, err
 	}
-	ltres := &LastTradesResponse{Trades: trades, Symbol: symbol}
+	ltres := &LastTradesResponse{Symbol: symbol}
+	if !ltr.FilterSince.IsZero() {
+		ltres.Truncated = olderThanPage(trades, ltr.FilterSince)
+		trades = tradesSince(trades, ltr.FilterSince)
+	}
+	ltres.Trades = trades
 	return ltres, nil
 }
+
+// olderThanPage reports whether since is before every trade of the
+// page, in which case trades between them might have been left out.
+func olderThanPage(trades []*Trade, since time.Time) bool {
+	if len(trades) == 0 {
+		return false
+	}
+	for _, trade := range trades {
+		if !trade.Time().After(since) {
+			return false
+		}
+	}
+	return true
+}
+
+func tradesSince(trades []*Trade, since time.Time) []*Trade {
+	var kept []*Trade
+	for _, trade := range trades {
+		if !trade.Time().Before(since) {
+			kept = append(kept, trade)
+		}
+	}
+	return kept
+}
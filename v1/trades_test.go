@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/orijtech/okcoin/v1"
 )
@@ -36,8 +37,11 @@ func TestLastTrades(t *testing.T) {
 	tests := [...]struct {
 		req         *okcoin.LastTradesRequest
 		wantAtLeast int
+		wantAtMost  int
 		wantSymbol  okcoin.Symbol
 		wantErr     bool
+
+		wantTruncated bool
 	}{
 		0: {wantAtLeast: 20, wantSymbol: okcoin.BTCUSD}, // a nil request should return the defaults
 		1: {
@@ -47,6 +51,24 @@ func TestLastTrades(t *testing.T) {
 			},
 			wantAtLeast: 20,
 		},
+		2: {
+			req: &okcoin.LastTradesRequest{
+				Symbol:      okcoin.BTCUSD,
+				FilterSince: time.Unix(1503988000, 0),
+			},
+			wantAtLeast: 6,
+			wantAtMost:  6,
+		},
+		3: {
+			// Older than the page: every trade is kept but some may be missing.
+			req: &okcoin.LastTradesRequest{
+				Symbol:      okcoin.BTCUSD,
+				FilterSince: time.Unix(1503900000, 0),
+			},
+			wantAtLeast:   60,
+			wantAtMost:    60,
+			wantTruncated: true,
+		},
 	}
 
 	client.SetHTTPRoundTripper(&backend{route: lastNTradesRoute})
@@ -70,6 +92,19 @@ func TestLastTrades(t *testing.T) {
 		if g, w := len(tres.Trades), tt.wantAtLeast; g < w {
 			t.Errorf("#%d: got=%d, wantAtLeast=%d", i, g, w)
 		}
+		if g, w := len(tres.Trades), tt.wantAtMost; w > 0 && g > w {
+			t.Errorf("#%d: got=%d, wantAtMost=%d", i, g, w)
+		}
+		if tt.req != nil && !tt.req.FilterSince.IsZero() {
+			for j, trade := range tres.Trades {
+				if trade.Time().Before(tt.req.FilterSince) {
+					t.Errorf("#%d: trade #%d at %v is before %v", i, j, trade.Time(), tt.req.FilterSince)
+				}
+			}
+		}
+		if g, w := tres.Truncated, tt.wantTruncated; g != w {
+			t.Errorf("#%d: truncated: got=%v want=%v", i, g, w)
+		}
 	}
 }
 
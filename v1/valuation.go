@@ -0,0 +1,187 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// AssetValuation is the value of the holdings of one currency.
+type AssetValuation struct {
+	Currency Currency `json:"currency"`
+	Free     float64  `json:"free"`
+	Frozen   float64  `json:"frozen"`
+	Total    float64  `json:"total"`
+
+	// Price is the value of one unit in the quote currency and
+	// Value that of Total. Both are 0 if the asset is unpriced.
+	Price float64 `json:"price"`
+	Value float64 `json:"value"`
+
+	// Route lists the symbols whose last prices were multiplied to
+	// price the asset, e.g. [eth_btc btc_usd] when routed through BTC.
+	Route []Symbol `json:"route,omitempty"`
+	// PricedAt is the time of the oldest ticker along Route.
+	PricedAt time.Time `json:"priced_at"`
+}
+
+// Valuation is the value of an account's Funds in the Quote currency.
+type Valuation struct {
+	Quote Currency `json:"quote"`
+
+	// Assets are sorted by descending Value.
+	Assets []*AssetValuation `json:"assets"`
+	// Total is the sum of the values of every priced asset.
+	Total float64 `json:"total"`
+
+	// Unpriced lists the held currencies for
+	// which no route to Quote was found.
+	Unpriced []Currency `json:"unpriced,omitempty"`
+	// Prices holds every ticker that was used.
+	Prices map[Symbol]*TickerResponse `json:"prices"`
+}
+
+var (
+	errNilFunds   = errors.New("expecting non-nil funds")
+	errBlankQuote = errors.New("expecting a non-blank quote currency")
+	errNilTickers = errors.New("expecting non-nil tickers")
+)
+
+// Valuate prices the free and frozen balances of funds in quote using the
+// last prices of tickers. An asset without a direct asset_quote ticker is
+// routed through BTC with asset_btc and btc_quote. Zero balances are skipped.
+func Valuate(funds *Funds, quote Currency, tickers map[Symbol]*TickerResponse) (*Valuation, error) {
+	if funds == nil {
+		return nil, errNilFunds
+	}
+	if quote == "" {
+		return nil, errBlankQuote
+	}
+	if tickers == nil {
+		return nil, errNilTickers
+	}
+
+	val := &Valuation{Quote: quote, Prices: make(map[Symbol]*TickerResponse)}
+	for _, currency := range heldCurrencies(funds) {
+		av := &AssetValuation{
+			Currency: currency,
+			Free:     funds.Free.Balance(currency),
+			Frozen:   funds.Frozen.Balance(currency),
+		}
+		av.Total = av.Free + av.Frozen
+
+		if currency == quote {
+			av.Price = 1
+		} else if route := priceRoute(currency, quote, tickers); route != nil {
+			av.Price = 1
+			for _, sym := range route {
+				tres := tickers[sym]
+				av.Price *= tres.Ticker.Last
+				if t := tres.Time(); av.PricedAt.IsZero() || t.Before(av.PricedAt) {
+					av.PricedAt = t
+				}
+				val.Prices[sym] = tres
+			}
+			av.Route = route
+		} else {
+			val.Unpriced = append(val.Unpriced, currency)
+		}
+		av.Value = av.Price * av.Total
+		val.Total += av.Value
+		val.Assets = append(val.Assets, av)
+	}
+
+	sort.SliceStable(val.Assets, func(i, j int) bool {
+		return val.Assets[i].Value > val.Assets[j].Value
+	})
+	return val, nil
+}
+
+// heldCurrencies returns, sorted, the currencies with a
+// non-zero free or frozen balance.
+func heldCurrencies(funds *Funds) []Currency {
+	seen := make(map[Currency]bool)
+	var currencies []Currency
+	for _, fund := range []*Fund{funds.Free, funds.Frozen} {
+		if fund == nil {
+			continue
+		}
+		for currency, amount := range fund.Balances {
+			if amount != 0 && !seen[currency] {
+				seen[currency] = true
+				currencies = append(currencies, currency)
+			}
+		}
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i] < currencies[j] })
+	return currencies
+}
+
+func priceRoute(from, to Currency, tickers map[Symbol]*TickerResponse) []Symbol {
+	direct := NewSymbol(from, to)
+	if hasLastPrice(tickers[direct]) {
+		return []Symbol{direct}
+	}
+	if from == BTC || to == BTC {
+		return nil
+	}
+	viaBTC, btcTo := NewSymbol(from, BTC), NewSymbol(BTC, to)
+	if hasLastPrice(tickers[viaBTC]) && hasLastPrice(tickers[btcTo]) {
+		return []Symbol{viaBTC, btcTo}
+	}
+	return nil
+}
+
+func hasLastPrice(tres *TickerResponse) bool {
+	return tres != nil && tres.Ticker != nil && tres.Ticker.Last > 0
+}
+
+// Valuation retrieves the account's Funds and the tickers needed to
+// value them in quote, then combines them with Valuate. Tickers that
+// cannot be retrieved leave their assets unpriced rather than failing.
+func (c *Client) Valuation(ctx context.Context, quote Currency) (*Valuation, error) {
+	if quote == "" {
+		return nil, errBlankQuote
+	}
+	funds, err := c.FundsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	for _, currency := range heldCurrencies(funds) {
+		if currency == quote {
+			continue
+		}
+		symbols = append(symbols, NewSymbol(currency, quote))
+		if currency != BTC && quote != BTC {
+			symbols = append(symbols, NewSymbol(currency, BTC), NewSymbol(BTC, quote))
+		}
+	}
+
+	tickers := make(map[Symbol]*TickerResponse)
+	for sym, res := range c.Tickers(ctx, symbols...) {
+		if res.Err == nil && res.Ticker != nil {
+			tickers[sym] = res.Ticker
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return Valuate(funds, quote, tickers)
+}
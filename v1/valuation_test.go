@@ -0,0 +1,156 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package okcoin_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/okcoin/v1"
+)
+
+func tickerAt(last, epoch float64) *okcoin.TickerResponse {
+	return &okcoin.TickerResponse{
+		TimeAtEpoch: epoch,
+		Ticker:      &okcoin.Ticker{Last: last},
+	}
+}
+
+func TestValuate(t *testing.T) {
+	t.Parallel()
+
+	funds := &okcoin.Funds{
+		Free: &okcoin.Fund{Balances: map[okcoin.Currency]float64{
+			okcoin.USD: 100, okcoin.BTC: 1, okcoin.LTC: 10, okcoin.ETC: 0,
+		}},
+		Frozen: &okcoin.Fund{Balances: map[okcoin.Currency]float64{
+			okcoin.BTC: 1, okcoin.BCC: 3,
+		}},
+	}
+	tickers := map[okcoin.Symbol]*okcoin.TickerResponse{
+		"btc_usd": tickerAt(4000, 1503960025),
+		// LTC has no USD pair and is routed through BTC.
+		"ltc_btc": tickerAt(0.01, 1503960000),
+	}
+
+	val, err := okcoin.Valuate(funds, okcoin.USD, tickers)
+	if err != nil {
+		t.Fatalf("valuate: %v", err)
+	}
+
+	if g, w := val.Total, 100+2*4000+10*0.01*4000.0; math.Abs(g-w) > 1e-9 {
+		t.Errorf("total: got=%v want=%v", g, w)
+	}
+	if g, w := val.Unpriced, []okcoin.Currency{okcoin.BCC}; !reflect.DeepEqual(g, w) {
+		t.Errorf("unpriced: got=%v want=%v", g, w)
+	}
+
+	var gotOrder []okcoin.Currency
+	for _, av := range val.Assets {
+		gotOrder = append(gotOrder, av.Currency)
+	}
+	wantOrder := []okcoin.Currency{okcoin.BTC, okcoin.LTC, okcoin.USD, okcoin.BCC}
+	if !reflect.DeepEqual(gotOrder, wantOrder) {
+		t.Fatalf("assets: got=%v want=%v", gotOrder, wantOrder)
+	}
+
+	btc, ltc := val.Assets[0], val.Assets[1]
+	if btc.Free != 1 || btc.Frozen != 1 || btc.Total != 2 || btc.Value != 8000 {
+		t.Errorf("btc: got=%+v", btc)
+	}
+	if g, w := ltc.Route, []okcoin.Symbol{"ltc_btc", "btc_usd"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("ltc route: got=%v want=%v", g, w)
+	}
+	if g, w := ltc.Price, 40.0; math.Abs(g-w) > 1e-9 {
+		t.Errorf("ltc price: got=%v want=%v", g, w)
+	}
+	// The oldest ticker along the route dates the price.
+	if g, w := ltc.PricedAt, time.Unix(1503960000, 0).UTC(); !g.Equal(w) {
+		t.Errorf("ltc priced at: got=%v want=%v", g, w)
+	}
+	if g, w := len(val.Prices), 2; g != w {
+		t.Errorf("prices: got=%d want=%d", g, w)
+	}
+
+	if _, err := okcoin.Valuate(nil, okcoin.USD, tickers); err == nil {
+		t.Error("nil funds: want non-nil error")
+	}
+	if _, err := okcoin.Valuate(funds, "", tickers); err == nil {
+		t.Error("blank quote: want non-nil error")
+	}
+}
+
+func TestClientValuation(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(&backend{route: valuationRoute})
+
+	val, err := client.Valuation(context.Background(), okcoin.USD)
+	if err != nil {
+		t.Fatalf("valuation: %v", err)
+	}
+	// 4.51 BTC at 4594.17 and 20 ETH at 365.3 from the fixtures.
+	if g, w := val.Total, 4.51*4594.17+20*365.3; math.Abs(g-w) > 1e-6 {
+		t.Errorf("total: got=%v want=%v", g, w)
+	}
+	if len(val.Unpriced) != 0 {
+		t.Errorf("unpriced: got=%v want none", val.Unpriced)
+	}
+	for _, sym := range []okcoin.Symbol{"btc_usd", "eth_usd"} {
+		if val.Prices[sym] == nil {
+			t.Errorf("%s: want its price reported", sym)
+		}
+	}
+}
+
+func TestClientValuationCancelled(t *testing.T) {
+	t.Parallel()
+
+	client, err := okcoin.NewDefaultClient()
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	client.SetCredentials(&okcoin.Credentials{APIKey: apiKey1, Secret: apiSecret1})
+	client.SetHTTPRoundTripper(&ctxChecker{base: &backend{route: valuationRoute}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	val, err := client.Valuation(ctx, okcoin.USD)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got=(%v, %v) want context.Canceled", val, err)
+	}
+}
+
+func (b *backend) valuationRoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/userinfo.do") {
+		return b.fundsRoundTrip(req)
+	}
+	return b.tickerRoundTrip(req)
+}
+
+const (
+	valuationRoute = "/valuation"
+)